@@ -0,0 +1,107 @@
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	EAN13    = "ean13"
+	UPCA     = "upc_a"
+	Code128  = "code128"
+	Internal = "internal"
+)
+
+var types = map[string]bool{EAN13: true, UPCA: true, Code128: true, Internal: true}
+
+// Detect guesses the symbology of a scanned code: 13 or 12 digits with a
+// valid check digit are EAN-13 or UPC-A, anything else is Code 128.
+func Detect(code string) string {
+	switch {
+	case len(code) == 13 && digits(code) && checkDigit(code[:12]) == code[12]:
+		return EAN13
+	case len(code) == 12 && digits(code) && checkDigit(code[:11]) == code[11]:
+		return UPCA
+	default:
+		return Code128
+	}
+}
+
+// Validate checks code against its symbology. Internal codes are printed
+// in store and only need to be printable ASCII like Code 128.
+func Validate(code, typ string) error {
+	if !types[typ] {
+		return errors.New("Unknown barcode type: " + typ)
+	}
+	if code == "" || len(code) > 50 {
+		return errors.New("Barcode must be 1 to 50 characters")
+	}
+	switch typ {
+	case EAN13, UPCA:
+		length := 13
+		if typ == UPCA {
+			length = 12
+		}
+		if len(code) != length || !digits(code) {
+			return errors.New("Barcode " + code + " is not a valid " + typ + " code")
+		}
+		if checkDigit(code[:length-1]) != code[length-1] {
+			return errors.New("Barcode " + code + " has a wrong check digit")
+		}
+	default:
+		for _, c := range code {
+			if c < 0x20 || c > 0x7e {
+				return errors.New("Barcode " + code + " contains unprintable characters")
+			}
+		}
+	}
+	return nil
+}
+
+// Canonical returns the form a barcode is stored in: a UPC-A becomes the
+// EAN-13 with a leading zero that scanners may also report it as, so the
+// two cannot end up on different products. Other codes are kept as they are.
+func Canonical(code, typ string) (string, string) {
+	if typ == UPCA {
+		return "0" + code, EAN13
+	}
+	return code, typ
+}
+
+// Equivalents returns the codes a scan may be stored under, the canonical
+// EAN-13 form first. Scanners report a UPC-A either as is or as an EAN-13
+// with a leading zero.
+func Equivalents(code string) []string {
+	code = strings.TrimSpace(code)
+	switch {
+	case len(code) == 13 && code[0] == '0' && digits(code):
+		return []string{code, code[1:]}
+	case len(code) == 12 && digits(code):
+		return []string{"0" + code, code}
+	default:
+		return []string{code}
+	}
+}
+
+// checkDigit computes the GS1 mod 10 check digit, weighting digits 3 and 1
+// from the right.
+func checkDigit(data string) byte {
+	sum := 0
+	for i := 0; i < len(data); i++ {
+		d := int(data[len(data)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func digits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
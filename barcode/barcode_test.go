@@ -0,0 +1,59 @@
+package barcode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		typ     string
+		wantErr bool
+	}{
+		{"ean13", "4006381333931", EAN13, false},
+		{"ean13 wrong check digit", "4006381333932", EAN13, true},
+		{"ean13 too short", "400638133393", EAN13, true},
+		{"ean13 with letters", "40063813339A1", EAN13, true},
+		{"upc-a", "036000291452", UPCA, false},
+		{"upc-a wrong check digit", "036000291453", UPCA, true},
+		{"upc-a given as ean13", "0036000291452", UPCA, true},
+		{"code128", "ABC-123", Code128, false},
+		{"code128 unprintable", "AB\x01", Code128, true},
+		{"internal", "SHELF 12", Internal, false},
+		{"empty", "", Code128, true},
+		{"too long", strings.Repeat("A", 51), Code128, true},
+		{"unknown type", "4006381333931", "qr", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.code, tt.typ)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q, %q) = %v, want error %v", tt.code, tt.typ, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEquivalents(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{"upc-a", "036000291452", []string{"0036000291452", "036000291452"}},
+		{"upc-a as ean13", "0036000291452", []string{"0036000291452", "036000291452"}},
+		{"ean13", "4006381333931", []string{"4006381333931"}},
+		{"code128", "ABC-123", []string{"ABC-123"}},
+		{"trims spaces", " 036000291452 ", []string{"0036000291452", "036000291452"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equivalents(tt.code); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Equivalents(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
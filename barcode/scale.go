@@ -0,0 +1,118 @@
+package barcode
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	ScaleWeight = "weight"
+	ScalePrice  = "price"
+)
+
+// ScaleLayout describes an in-store EAN-13 label printed by a scale:
+// a two digit prefix, the PLU, the embedded value and the check digit.
+// PLUDigits and ValueDigits add up to 10.
+type ScaleLayout struct {
+	Prefix      string
+	PLUDigits   int
+	Kind        string // ScaleWeight or ScalePrice
+	ValueDigits int
+	Decimals    int // decimals of an embedded weight, 3 for grams in kg
+}
+
+// ScaleReading is what a scale label says.
+type ScaleReading struct {
+	Kind  string
+	PLU   string
+	Value int
+	// decimals of Value when Kind is ScaleWeight
+	decimals int
+}
+
+// Thousandths returns an embedded weight in thousandths of a unit, the
+// scale models.Quantity uses.
+func (r ScaleReading) Thousandths() int64 {
+	v := int64(r.Value)
+	for d := r.decimals; d < 3; d++ {
+		v *= 10
+	}
+	for d := r.decimals; d > 3; d-- {
+		v /= 10
+	}
+	return v
+}
+
+// DefaultScaleLayouts reads prefixes 20-24 as a 5 digit PLU with the weight
+// in grams, and 25-29 as a 4 digit PLU with the price in rupiah.
+func DefaultScaleLayouts() []ScaleLayout {
+	var layouts []ScaleLayout
+	for p := 20; p <= 29; p++ {
+		l := ScaleLayout{Prefix: strconv.Itoa(p), PLUDigits: 5, Kind: ScaleWeight, ValueDigits: 5, Decimals: 3}
+		if p >= 25 {
+			l = ScaleLayout{Prefix: strconv.Itoa(p), PLUDigits: 4, Kind: ScalePrice, ValueDigits: 6}
+		}
+		layouts = append(layouts, l)
+	}
+	return layouts
+}
+
+// ParseScaleLayouts reads a comma separated list of
+// prefix:plu_digits:kind:value_digits[:decimals], e.g.
+// "20:5:weight:5:3,28:4:price:6". An empty string gives the defaults.
+func ParseScaleLayouts(s string) ([]ScaleLayout, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultScaleLayouts(), nil
+	}
+	var layouts []ScaleLayout
+	for _, spec := range strings.Split(s, ",") {
+		f := strings.Split(strings.TrimSpace(spec), ":")
+		if len(f) != 4 && len(f) != 5 {
+			return nil, errors.New("Invalid scale barcode layout: " + spec)
+		}
+		l := ScaleLayout{Prefix: f[0], Kind: f[2]}
+		var err error
+		if l.PLUDigits, err = strconv.Atoi(f[1]); err != nil {
+			return nil, errors.New("Invalid PLU digits in scale barcode layout: " + spec)
+		}
+		if l.ValueDigits, err = strconv.Atoi(f[3]); err != nil {
+			return nil, errors.New("Invalid value digits in scale barcode layout: " + spec)
+		}
+		if len(f) == 5 {
+			if l.Decimals, err = strconv.Atoi(f[4]); err != nil {
+				return nil, errors.New("Invalid decimals in scale barcode layout: " + spec)
+			}
+		}
+		switch {
+		case len(l.Prefix) != 2 || l.Prefix < "20" || l.Prefix > "29":
+			return nil, errors.New("Scale barcode prefix must be 20 to 29: " + spec)
+		case l.Kind != ScaleWeight && l.Kind != ScalePrice:
+			return nil, errors.New("Scale barcode kind must be weight or price: " + spec)
+		case l.PLUDigits < 1 || l.ValueDigits < 1 || l.PLUDigits+l.ValueDigits != 10:
+			return nil, errors.New("PLU and value digits must add up to 10: " + spec)
+		}
+		layouts = append(layouts, l)
+	}
+	return layouts, nil
+}
+
+// DecodeScale reads a scale label. ok is false when code is not a valid
+// EAN-13 with one of the layouts' prefixes.
+func DecodeScale(layouts []ScaleLayout, code string) (reading ScaleReading, ok bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != 13 || Validate(code, EAN13) != nil {
+		return ScaleReading{}, false
+	}
+	for _, l := range layouts {
+		if code[:2] != l.Prefix {
+			continue
+		}
+		value, err := strconv.Atoi(code[2+l.PLUDigits : 12])
+		if err != nil {
+			return ScaleReading{}, false
+		}
+		return ScaleReading{Kind: l.Kind, PLU: code[2 : 2+l.PLUDigits], Value: value, decimals: l.Decimals}, true
+	}
+	return ScaleReading{}, false
+}
@@ -0,0 +1,50 @@
+package barcode
+
+import "testing"
+
+func TestDecodeScale(t *testing.T) {
+	// label completes the first 12 digits of a code with its check digit.
+	label := func(data string) string {
+		return data + string(checkDigit(data))
+	}
+	custom, err := ParseScaleLayouts("21:5:weight:5:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		layouts     []ScaleLayout
+		code        string
+		ok          bool
+		kind        string
+		plu         string
+		value       int
+		thousandths int64
+	}{
+		{"weight in grams", DefaultScaleLayouts(), label("201234501250"), true, ScaleWeight, "12345", 1250, 1250},
+		{"price in rupiah", DefaultScaleLayouts(), label("250042012500"), true, ScalePrice, "0042", 12500, 0},
+		{"weight in hundredths", custom, label("210000100150"), true, ScaleWeight, "00001", 150, 1500},
+		{"prefix without a layout", custom, label("201234501250"), false, "", "", 0, 0},
+		{"not a scale prefix", DefaultScaleLayouts(), "4006381333931", false, "", "", 0, 0},
+		{"wrong check digit", DefaultScaleLayouts(), "2012345012500", false, "", "", 0, 0},
+		{"too short", DefaultScaleLayouts(), "201234501250", false, "", "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := DecodeScale(tt.layouts, tt.code)
+			if ok != tt.ok {
+				t.Fatalf("DecodeScale(%q) ok = %v, want %v", tt.code, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if r.Kind != tt.kind || r.PLU != tt.plu || r.Value != tt.value {
+				t.Errorf("DecodeScale(%q) = %s %s %d, want %s %s %d", tt.code, r.Kind, r.PLU, r.Value, tt.kind, tt.plu, tt.value)
+			}
+			if r.Kind == ScaleWeight && r.Thousandths() != tt.thousandths {
+				t.Errorf("Thousandths() = %d, want %d", r.Thousandths(), tt.thousandths)
+			}
+		})
+	}
+}
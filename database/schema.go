@@ -0,0 +1,577 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+)
+
+// migrations are applied in order on every start, so each statement
+// must be safe to run more than once.
+var migrations = []string{
+	// Payments captured at checkout
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS paid_amount INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS change_amount INT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS payments (
+		id SERIAL PRIMARY KEY,
+		transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+		method VARCHAR(20) NOT NULL,
+		amount INT NOT NULL,
+		reference VARCHAR(100) NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_payments_transaction_id ON payments(transaction_id)`,
+
+	// Voids and refunds are stored as negative transactions
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS type VARCHAR(10) NOT NULL DEFAULT 'sale'`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reference_id INT REFERENCES transactions(id)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reason TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS created_by VARCHAR(100) NOT NULL DEFAULT ''`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS refund_of_detail_id INT REFERENCES transaction_details(id)`,
+
+	// Sales made before payments were captured were settled in full. A sale
+	// since then always has a payment unless its total is zero.
+	`UPDATE transactions SET paid_amount = total_amount WHERE type = 'sale' AND paid_amount = 0
+		AND NOT EXISTS (SELECT 1 FROM payments WHERE transaction_id = transactions.id)`,
+
+	// Promotions
+	`CREATE TABLE IF NOT EXISTS promotions (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		type VARCHAR(20) NOT NULL,
+		value INT NOT NULL DEFAULT 0,
+		category_id INT REFERENCES category(id),
+		product_ids INT[] NOT NULL DEFAULT '{}',
+		buy_qty INT NOT NULL DEFAULT 0,
+		get_qty INT NOT NULL DEFAULT 0,
+		min_purchase INT NOT NULL DEFAULT 0,
+		start_at TIMESTAMPTZ NOT NULL,
+		end_at TIMESTAMPTZ NOT NULL,
+		stackable BOOLEAN NOT NULL DEFAULT FALSE,
+		priority INT NOT NULL DEFAULT 0,
+		active BOOLEAN NOT NULL DEFAULT TRUE
+	)`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS gross_amount INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS discount INT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS transaction_detail_promotions (
+		detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+		promotion_id INT NOT NULL REFERENCES promotions(id),
+		amount INT NOT NULL,
+		PRIMARY KEY (detail_id, promotion_id)
+	)`,
+
+	// Tax (PPN) and service charge
+	`ALTER TABLE category ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5,2)`,
+	`ALTER TABLE category ADD COLUMN IF NOT EXISTS tax_exempt BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5,2)`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS tax_exempt BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS subtotal INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tax_base INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS service_charge INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_base INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS service_charge INT NOT NULL DEFAULT 0`,
+
+	// Customers and loyalty points
+	`CREATE TABLE IF NOT EXISTS customers (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		phone VARCHAR(30) NOT NULL DEFAULT '',
+		email VARCHAR(100) NOT NULL DEFAULT '',
+		tier VARCHAR(30) NOT NULL DEFAULT 'regular',
+		points INT NOT NULL DEFAULT 0,
+		total_spent BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone ON customers(phone) WHERE phone <> ''`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_id INT REFERENCES customers(id)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_earned INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_redeemed INT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS point_ledger (
+		id SERIAL PRIMARY KEY,
+		customer_id INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+		transaction_id INT REFERENCES transactions(id),
+		points INT NOT NULL,
+		reason VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_point_ledger_customer_id ON point_ledger(customer_id)`,
+
+	// Cashier shifts and cash drawer
+	`CREATE TABLE IF NOT EXISTS shifts (
+		id SERIAL PRIMARY KEY,
+		cashier VARCHAR(100) NOT NULL,
+		status VARCHAR(10) NOT NULL DEFAULT 'open',
+		opening_cash INT NOT NULL DEFAULT 0,
+		opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		closed_at TIMESTAMP,
+		expected_cash INT,
+		counted_cash INT,
+		variance INT,
+		denominations JSONB,
+		notes TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_one_open ON shifts(status) WHERE status = 'open'`,
+	`CREATE TABLE IF NOT EXISTS cash_movements (
+		id SERIAL PRIMARY KEY,
+		shift_id INT NOT NULL REFERENCES shifts(id),
+		type VARCHAR(3) NOT NULL,
+		amount INT NOT NULL,
+		reason TEXT NOT NULL,
+		created_by VARCHAR(100) NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS shift_id INT REFERENCES shifts(id)`,
+
+	// Outlets with their own stock and price overrides
+	`CREATE TABLE IF NOT EXISTS outlets (
+		id SERIAL PRIMARY KEY,
+		code VARCHAR(20) NOT NULL UNIQUE,
+		name VARCHAR(100) NOT NULL,
+		address TEXT NOT NULL DEFAULT '',
+		phone VARCHAR(30) NOT NULL DEFAULT '',
+		receipt_footer TEXT NOT NULL DEFAULT '',
+		paper_width INT NOT NULL DEFAULT 80,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS product_stock (
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		outlet_id INT NOT NULL REFERENCES outlets(id) ON DELETE CASCADE,
+		stock INT NOT NULL DEFAULT 0,
+		PRIMARY KEY (product_id, outlet_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS product_outlet_prices (
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		outlet_id INT NOT NULL REFERENCES outlets(id) ON DELETE CASCADE,
+		price INT NOT NULL,
+		PRIMARY KEY (product_id, outlet_id)
+	)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id)`,
+	`CREATE INDEX IF NOT EXISTS idx_transactions_outlet_id ON transactions(outlet_id)`,
+	`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id)`,
+	`DROP INDEX IF EXISTS idx_shifts_one_open`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_one_open_per_outlet ON shifts(COALESCE(outlet_id, 0)) WHERE status = 'open'`,
+
+	// Stock ledger, rows are only ever inserted
+	`CREATE TABLE IF NOT EXISTS stock_movements (
+		id SERIAL PRIMARY KEY,
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		outlet_id INT REFERENCES outlets(id),
+		delta INT NOT NULL,
+		balance INT NOT NULL,
+		reason VARCHAR(20) NOT NULL,
+		reference_type VARCHAR(30) NOT NULL DEFAULT '',
+		reference_id INT,
+		created_by VARCHAR(100) NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements(product_id, id)`,
+
+	// Suppliers, purchase orders and goods receiving
+	`CREATE TABLE IF NOT EXISTS suppliers (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		contact_name VARCHAR(100) NOT NULL DEFAULT '',
+		phone VARCHAR(30) NOT NULL DEFAULT '',
+		email VARCHAR(100) NOT NULL DEFAULT '',
+		address TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS purchase_orders (
+		id SERIAL PRIMARY KEY,
+		supplier_id INT NOT NULL REFERENCES suppliers(id),
+		outlet_id INT REFERENCES outlets(id),
+		status VARCHAR(20) NOT NULL DEFAULT 'draft',
+		notes TEXT NOT NULL DEFAULT '',
+		total_amount INT NOT NULL DEFAULT 0,
+		received_amount INT NOT NULL DEFAULT 0,
+		paid_amount INT NOT NULL DEFAULT 0,
+		created_by VARCHAR(100) NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		ordered_at TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier_id ON purchase_orders(supplier_id)`,
+	`CREATE TABLE IF NOT EXISTS purchase_order_items (
+		id SERIAL PRIMARY KEY,
+		purchase_order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+		product_id INT NOT NULL REFERENCES product(id),
+		quantity INT NOT NULL,
+		unit_cost INT NOT NULL,
+		received_qty INT NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE IF NOT EXISTS goods_receipts (
+		id SERIAL PRIMARY KEY,
+		purchase_order_id INT NOT NULL REFERENCES purchase_orders(id),
+		received_by VARCHAR(100) NOT NULL,
+		notes TEXT NOT NULL DEFAULT '',
+		received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS goods_receipt_items (
+		id SERIAL PRIMARY KEY,
+		receipt_id INT NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+		item_id INT NOT NULL REFERENCES purchase_order_items(id),
+		product_id INT NOT NULL REFERENCES product(id),
+		quantity INT NOT NULL,
+		unit_cost INT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS supplier_payments (
+		id SERIAL PRIMARY KEY,
+		purchase_order_id INT NOT NULL REFERENCES purchase_orders(id),
+		amount INT NOT NULL,
+		method VARCHAR(20) NOT NULL,
+		reference VARCHAR(100) NOT NULL DEFAULT '',
+		paid_by VARCHAR(100) NOT NULL DEFAULT '',
+		paid_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	// Stock-take (stock opname) sessions
+	`CREATE TABLE IF NOT EXISTS stock_takes (
+		id SERIAL PRIMARY KEY,
+		outlet_id INT REFERENCES outlets(id),
+		status VARCHAR(10) NOT NULL DEFAULT 'open',
+		notes TEXT NOT NULL DEFAULT '',
+		snapshot_movement_id INT NOT NULL DEFAULT 0,
+		created_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		approved_by VARCHAR(100) NOT NULL DEFAULT '',
+		approved_at TIMESTAMP
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_takes_one_open_per_outlet ON stock_takes(COALESCE(outlet_id, 0)) WHERE status = 'open'`,
+	`CREATE TABLE IF NOT EXISTS stock_take_items (
+		stock_take_id INT NOT NULL REFERENCES stock_takes(id) ON DELETE CASCADE,
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		system_qty INT NOT NULL,
+		unit_value INT NOT NULL,
+		PRIMARY KEY (stock_take_id, product_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS stock_take_counts (
+		stock_take_id INT NOT NULL,
+		product_id INT NOT NULL,
+		counter VARCHAR(100) NOT NULL,
+		quantity INT NOT NULL,
+		counted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (stock_take_id, product_id, counter),
+		FOREIGN KEY (stock_take_id, product_id) REFERENCES stock_take_items(stock_take_id, product_id) ON DELETE CASCADE
+	)`,
+
+	// Low-stock thresholds and notifications
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS min_stock INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS reorder_qty INT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS notifications (
+		id SERIAL PRIMARY KEY,
+		type VARCHAR(30) NOT NULL,
+		title VARCHAR(200) NOT NULL,
+		message TEXT NOT NULL DEFAULT '',
+		data JSONB,
+		read_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	// Product variants
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS sku VARCHAR(50) NOT NULL DEFAULT ''`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES product(id) ON DELETE CASCADE`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS options JSONB`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS option_values JSONB`,
+	`CREATE INDEX IF NOT EXISTS idx_product_parent_id ON product(parent_id)`,
+
+	// SKU and barcodes
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_product_sku ON product(sku) WHERE sku <> ''`,
+	`CREATE TABLE IF NOT EXISTS product_barcodes (
+		code VARCHAR(50) PRIMARY KEY,
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		type VARCHAR(20) NOT NULL DEFAULT 'internal'
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_product_barcodes_product_id ON product_barcodes(product_id)`,
+	// product.barcode held a single code per product, move it over once.
+	`DO $$ BEGIN
+		IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'product' AND column_name = 'barcode') THEN
+			INSERT INTO product_barcodes(code, product_id) SELECT barcode, id FROM product WHERE barcode <> '' ON CONFLICT DO NOTHING;
+			ALTER TABLE product DROP COLUMN barcode;
+		END IF;
+	END $$`,
+
+	// Bundles
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS is_bundle BOOLEAN NOT NULL DEFAULT FALSE`,
+	`CREATE TABLE IF NOT EXISTS bundle_components (
+		bundle_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		component_id INT NOT NULL REFERENCES product(id),
+		quantity INT NOT NULL CHECK (quantity > 0),
+		PRIMARY KEY (bundle_id, component_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS transaction_detail_components (
+		detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+		product_id INT NOT NULL REFERENCES product(id),
+		quantity INT NOT NULL,
+		revenue INT NOT NULL DEFAULT 0,
+		PRIMARY KEY (detail_id, product_id)
+	)`,
+
+	// Recipes
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS is_ingredient BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS unit VARCHAR(10) NOT NULL DEFAULT 'pcs'`,
+	`CREATE TABLE IF NOT EXISTS recipe_items (
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		ingredient_id INT NOT NULL REFERENCES product(id),
+		quantity INT NOT NULL CHECK (quantity > 0),
+		PRIMARY KEY (product_id, ingredient_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS transaction_detail_ingredients (
+		detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+		ingredient_id INT NOT NULL REFERENCES product(id),
+		quantity INT NOT NULL,
+		PRIMARY KEY (detail_id, ingredient_id)
+	)`,
+
+	// Fractional quantities: every quantity column becomes NUMERIC(14,3).
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS precision SMALLINT NOT NULL DEFAULT 0`,
+	`DO $$ DECLARE c RECORD; BEGIN
+		FOR c IN SELECT table_name, column_name FROM information_schema.columns
+			WHERE data_type = 'integer' AND (table_name, column_name) IN (
+				('product', 'stock'), ('product', 'min_stock'), ('product', 'reorder_qty'),
+				('product_stock', 'stock'), ('stock_movements', 'delta'), ('stock_movements', 'balance'),
+				('transaction_details', 'quantity'), ('purchase_order_items', 'quantity'), ('purchase_order_items', 'received_qty'),
+				('goods_receipt_items', 'quantity'), ('stock_take_items', 'system_qty'), ('stock_take_counts', 'quantity'),
+				('bundle_components', 'quantity'), ('transaction_detail_components', 'quantity'),
+				('recipe_items', 'quantity'), ('transaction_detail_ingredients', 'quantity'))
+		LOOP
+			EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE NUMERIC(14,3)', c.table_name, c.column_name);
+		END LOOP;
+	END $$`,
+
+	// Scale labels
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS plu VARCHAR(6) NOT NULL DEFAULT ''`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_product_plu ON product(plu) WHERE plu <> ''`,
+
+	// Units of measure
+	`CREATE TABLE IF NOT EXISTS product_units (
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		name VARCHAR(20) NOT NULL,
+		factor NUMERIC(14,3) NOT NULL CHECK (factor > 0),
+		price INT,
+		PRIMARY KEY (product_id, name)
+	)`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit VARCHAR(20) NOT NULL DEFAULT ''`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_quantity NUMERIC(14,3) NOT NULL DEFAULT 0`,
+	`ALTER TABLE purchase_order_items ADD COLUMN IF NOT EXISTS unit VARCHAR(20) NOT NULL DEFAULT ''`,
+	`ALTER TABLE purchase_order_items ADD COLUMN IF NOT EXISTS factor NUMERIC(14,3) NOT NULL DEFAULT 1`,
+
+	// Wholesale tiers and price lists
+	`CREATE TABLE IF NOT EXISTS price_tiers (
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		min_qty NUMERIC(14,3) NOT NULL CHECK (min_qty > 0),
+		price INT NOT NULL CHECK (price > 0),
+		PRIMARY KEY (product_id, min_qty)
+	)`,
+	`CREATE TABLE IF NOT EXISTS price_lists (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		customer_groups TEXT[] NOT NULL DEFAULT '{}',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS price_list_items (
+		price_list_id INT NOT NULL REFERENCES price_lists(id) ON DELETE CASCADE,
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		price INT NOT NULL CHECK (price > 0),
+		PRIMARY KEY (price_list_id, product_id)
+	)`,
+	`ALTER TABLE customers ADD COLUMN IF NOT EXISTS customer_group VARCHAR(50) NOT NULL DEFAULT ''`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_price INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS price_rule VARCHAR(20) NOT NULL DEFAULT ''`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS price_list_id INT REFERENCES price_lists(id) ON DELETE SET NULL`,
+
+	// Cost of goods sold
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS cost INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS cost_method VARCHAR(10) NOT NULL DEFAULT 'average'`,
+	`CREATE TABLE IF NOT EXISTS cost_layers (
+		id SERIAL PRIMARY KEY,
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		goods_receipt_id INT REFERENCES goods_receipts(id),
+		quantity NUMERIC(14,3) NOT NULL,
+		remaining NUMERIC(14,3) NOT NULL CHECK (remaining >= 0),
+		unit_cost INT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_cost_layers_product ON cost_layers(product_id) WHERE remaining > 0`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS cost INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transaction_detail_components ADD COLUMN IF NOT EXISTS cost INT NOT NULL DEFAULT 0`,
+
+	// Batches and expiry dates
+	`CREATE TABLE IF NOT EXISTS stock_lots (
+		id SERIAL PRIMARY KEY,
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		outlet_id INT REFERENCES outlets(id),
+		batch_number VARCHAR(50) NOT NULL,
+		expiry_date DATE,
+		quantity NUMERIC(14,3) NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+		received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_lots_batch ON stock_lots(product_id, COALESCE(outlet_id, 0), batch_number)`,
+	`CREATE INDEX IF NOT EXISTS idx_stock_lots_expiry ON stock_lots(expiry_date) WHERE quantity > 0`,
+	`CREATE TABLE IF NOT EXISTS transaction_detail_lots (
+		detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+		lot_id INT NOT NULL REFERENCES stock_lots(id),
+		quantity NUMERIC(14,3) NOT NULL,
+		PRIMARY KEY (detail_id, lot_id)
+	)`,
+	`ALTER TABLE goods_receipt_items ADD COLUMN IF NOT EXISTS lot_id INT REFERENCES stock_lots(id)`,
+	`ALTER TABLE stock_take_counts ADD COLUMN IF NOT EXISTS batch_number VARCHAR(50) NOT NULL DEFAULT ''`,
+	`ALTER TABLE stock_take_counts ADD COLUMN IF NOT EXISTS expiry_date DATE`,
+	`ALTER TABLE stock_take_counts DROP CONSTRAINT IF EXISTS stock_take_counts_pkey`,
+	// Counts are kept per unit too, so cartons and pieces add up.
+	`ALTER TABLE stock_take_counts ADD COLUMN IF NOT EXISTS unit VARCHAR(20) NOT NULL DEFAULT ''`,
+	`DROP INDEX IF EXISTS idx_stock_take_counts`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_take_counts_unit ON stock_take_counts(stock_take_id, product_id, counter, batch_number, unit)`,
+
+	// Serial numbers and warranty
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS serialized BOOLEAN NOT NULL DEFAULT false`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS warranty_months INT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS serial_numbers (
+		id SERIAL PRIMARY KEY,
+		serial VARCHAR(100) NOT NULL UNIQUE,
+		product_id INT NOT NULL REFERENCES product(id) ON DELETE CASCADE,
+		outlet_id INT REFERENCES outlets(id),
+		status VARCHAR(20) NOT NULL DEFAULT 'in_stock',
+		goods_receipt_id INT REFERENCES goods_receipts(id),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_serial_numbers_product ON serial_numbers(product_id, status)`,
+	`CREATE TABLE IF NOT EXISTS transaction_detail_serials (
+		detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+		serial_id INT NOT NULL REFERENCES serial_numbers(id),
+		warranty_months INT NOT NULL DEFAULT 0,
+		PRIMARY KEY (detail_id, serial_id)
+	)`,
+
+	// Stock transfers between outlets
+	`CREATE TABLE IF NOT EXISTS stock_transfers (
+		id SERIAL PRIMARY KEY,
+		from_outlet_id INT NOT NULL REFERENCES outlets(id),
+		to_outlet_id INT NOT NULL REFERENCES outlets(id),
+		status VARCHAR(20) NOT NULL DEFAULT 'draft',
+		notes TEXT NOT NULL DEFAULT '',
+		created_by VARCHAR(100) NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		dispatched_by VARCHAR(100) NOT NULL DEFAULT '',
+		dispatched_at TIMESTAMP,
+		received_by VARCHAR(100) NOT NULL DEFAULT '',
+		received_at TIMESTAMP,
+		CHECK (from_outlet_id <> to_outlet_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS stock_transfer_items (
+		id SERIAL PRIMARY KEY,
+		transfer_id INT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+		product_id INT NOT NULL REFERENCES product(id),
+		quantity NUMERIC(14,3) NOT NULL CHECK (quantity > 0),
+		received_qty NUMERIC(14,3),
+		discrepancy_value INT NOT NULL DEFAULT 0,
+		discrepancy_reason VARCHAR(200) NOT NULL DEFAULT '',
+		serials TEXT[] NOT NULL DEFAULT '{}',
+		received_serials TEXT[] NOT NULL DEFAULT '{}',
+		UNIQUE (transfer_id, product_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS stock_transfer_lots (
+		item_id INT NOT NULL REFERENCES stock_transfer_items(id) ON DELETE CASCADE,
+		lot_id INT NOT NULL REFERENCES stock_lots(id),
+		quantity NUMERIC(14,3) NOT NULL,
+		PRIMARY KEY (item_id, lot_id)
+	)`,
+
+	// Stock write-offs
+	`CREATE TABLE IF NOT EXISTS write_offs (
+		id SERIAL PRIMARY KEY,
+		outlet_id INT REFERENCES outlets(id),
+		reason VARCHAR(20) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		notes TEXT NOT NULL DEFAULT '',
+		total_value INT NOT NULL DEFAULT 0,
+		created_by VARCHAR(100) NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		approved_by VARCHAR(100) NOT NULL DEFAULT '',
+		approved_at TIMESTAMP,
+		posted_at TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_write_offs_posted_at ON write_offs(posted_at)`,
+	`CREATE TABLE IF NOT EXISTS write_off_items (
+		id SERIAL PRIMARY KEY,
+		write_off_id INT NOT NULL REFERENCES write_offs(id) ON DELETE CASCADE,
+		product_id INT NOT NULL REFERENCES product(id),
+		quantity NUMERIC(14,3) NOT NULL CHECK (quantity > 0),
+		batch_number VARCHAR(50) NOT NULL DEFAULT '',
+		value INT NOT NULL DEFAULT 0,
+		serials TEXT[] NOT NULL DEFAULT '{}'
+	)`,
+	`CREATE TABLE IF NOT EXISTS write_off_lots (
+		item_id INT NOT NULL REFERENCES write_off_items(id) ON DELETE CASCADE,
+		lot_id INT NOT NULL REFERENCES stock_lots(id),
+		quantity NUMERIC(14,3) NOT NULL,
+		PRIMARY KEY (item_id, lot_id)
+	)`,
+
+	// Tax inclusive pricing per product or category
+	`ALTER TABLE category ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN`,
+
+	// Default outlet: stock always sits at an outlet, what was kept without
+	// one moves to the default outlet so product.stock = SUM(product_stock).
+	// It is created without a name or paper width so its receipts keep the
+	// RECEIPT_* settings until it is given its own.
+	`ALTER TABLE outlets ADD COLUMN IF NOT EXISTS is_default BOOLEAN NOT NULL DEFAULT FALSE`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_outlets_one_default ON outlets(is_default) WHERE is_default`,
+	`INSERT INTO outlets(code, name, paper_width, is_default) SELECT 'MAIN', '', 0, TRUE
+		WHERE NOT EXISTS (SELECT 1 FROM outlets WHERE is_default)
+		ON CONFLICT (code) DO UPDATE SET is_default = TRUE`,
+	`INSERT INTO product_stock (product_id, outlet_id, stock)
+		SELECT p.id, o.id, p.stock - COALESCE(s.stock, 0)
+		FROM product p CROSS JOIN outlets o
+		LEFT JOIN (SELECT product_id, SUM(stock) AS stock FROM product_stock GROUP BY product_id) s ON s.product_id = p.id
+		WHERE o.is_default AND NOT p.is_bundle AND p.stock <> COALESCE(s.stock, 0)
+		ON CONFLICT (product_id, outlet_id) DO UPDATE SET stock = product_stock.stock + EXCLUDED.stock`,
+	`UPDATE stock_lots d SET quantity = d.quantity + n.quantity FROM stock_lots n
+		WHERE n.outlet_id IS NULL AND n.quantity > 0 AND n.product_id = d.product_id AND n.batch_number = d.batch_number
+		AND d.outlet_id = (SELECT id FROM outlets WHERE is_default)`,
+	`UPDATE stock_lots n SET quantity = 0 WHERE n.outlet_id IS NULL AND EXISTS (SELECT 1 FROM stock_lots d
+		WHERE d.product_id = n.product_id AND d.batch_number = n.batch_number AND d.outlet_id = (SELECT id FROM outlets WHERE is_default))`,
+	`UPDATE stock_lots n SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE n.outlet_id IS NULL AND NOT EXISTS (SELECT 1 FROM stock_lots d
+		WHERE d.product_id = n.product_id AND d.batch_number = n.batch_number AND d.outlet_id = (SELECT id FROM outlets WHERE is_default))`,
+	`UPDATE serial_numbers SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL`,
+	`UPDATE transactions SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL`,
+	`UPDATE purchase_orders SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL`,
+	`UPDATE write_offs SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL`,
+	`UPDATE shifts SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL
+		AND (status <> 'open' OR NOT EXISTS (SELECT 1 FROM shifts s WHERE s.status = 'open' AND s.outlet_id = (SELECT id FROM outlets WHERE is_default)))`,
+	`UPDATE stock_takes SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL
+		AND (status <> 'open' OR NOT EXISTS (SELECT 1 FROM stock_takes s WHERE s.status = 'open' AND s.outlet_id = (SELECT id FROM outlets WHERE is_default)))`,
+
+	// Variants whose option combination is no longer generated
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS retired BOOLEAN NOT NULL DEFAULT FALSE`,
+
+	// UPC-A barcodes are stored as their EAN-13 form with a leading zero
+	`UPDATE product_barcodes b SET code = '0' || b.code, type = 'ean13'
+		WHERE b.type = 'upc_a' AND NOT EXISTS (SELECT 1 FROM product_barcodes e WHERE e.code = '0' || b.code)`,
+
+	// PLUs are stored without leading zeros, as scale labels are looked up
+	`UPDATE product p SET plu = LTRIM(p.plu, '0') WHERE p.plu LIKE '0%' AND LTRIM(p.plu, '0') <> ''
+		AND NOT EXISTS (SELECT 1 FROM product o WHERE o.id <> p.id AND o.plu <> '' AND LTRIM(o.plu, '0') = LTRIM(p.plu, '0'))`,
+
+	// Promotion periods are instants, so checkout compares them the same
+	// way whatever time zone the server runs in. Existing periods are read
+	// in the database's time zone.
+	`DO $$ DECLARE c RECORD; BEGIN
+		FOR c IN SELECT column_name FROM information_schema.columns
+			WHERE table_name = 'promotions' AND column_name IN ('start_at', 'end_at') AND data_type = 'timestamp without time zone'
+		LOOP
+			EXECUTE format('ALTER TABLE promotions ALTER COLUMN %I TYPE TIMESTAMPTZ', c.column_name);
+		END LOOP;
+	END $$`,
+}
+
+func Migrate(db *sql.DB) error {
+	for _, stmt := range migrations {
+		if _, err := db.Exec(stmt); err != nil {
+			log.Printf("Error running migration: %v", err)
+			return err
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ go 1.24.1
 require (
 	github.com/lib/pq v1.10.9
 	github.com/spf13/viper v1.21.0
+	golang.org/x/text v0.28.0
 )
 
 require (
@@ -19,5 +20,4 @@ require (
 	github.com/subosito/gotenv v1.6.0 // indirect
 	go.yaml.in/yaml/v3 v3.0.4 // indirect
 	golang.org/x/sys v0.29.0 // indirect
-	golang.org/x/text v0.28.0 // indirect
 )
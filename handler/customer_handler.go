@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CustomerHandler struct {
+	service service.CustomerService
+}
+
+func NewCustomerHandler(service service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+func (h *CustomerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/customer")
+	if r.URL.Path == "/api/v1/customer" || r.URL.Path == "/api/v1/customer/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		if len(parts) > 1 {
+			if r.Method != http.MethodGet {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			switch parts[1] {
+			case "transactions":
+				h.handleGetHistory(w, r, id)
+			case "points":
+				h.handleGetPoints(w, r, id)
+			default:
+				http.NotFound(w, r)
+			}
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case http.MethodPut:
+			h.handleUpdate(w, r, id)
+		case http.MethodPatch:
+			h.handlePatch(w, r, id)
+		case http.MethodDelete:
+			h.handleDelete(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *CustomerHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.service.GetAllCustomer(r.URL.Query().Get("q"))
+	if err != nil {
+		log.Printf("Error handling get customer: %v", err)
+		http.Error(w, "Error handling get customer", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&customers)
+}
+
+func (h *CustomerHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.CreateCustomerRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	customer, err := h.service.CreateCustomer(&req)
+	if err != nil {
+		log.Printf("Error handling creating customer: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&customer)
+}
+
+func (h *CustomerHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	customer, err := h.service.GetCustomerByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&customer)
+}
+
+func (h *CustomerHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.UpdateCustomerRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	customer, err := h.service.UpdateCustomer(id, &req)
+	if err != nil {
+		log.Printf("Error handling updating customer: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&customer)
+}
+
+func (h *CustomerHandler) handlePatch(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.PatchCustomerRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	customer, err := h.service.PatchCustomer(id, &req)
+	if err != nil {
+		log.Printf("Error handling patching customer: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&customer)
+}
+
+func (h *CustomerHandler) handleDelete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.DeleteCustomer(id); err != nil {
+		log.Printf("Error handling deleting customer: %v", err)
+		if errors.Is(err, models.ErrCustomerInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CustomerHandler) handleGetHistory(w http.ResponseWriter, r *http.Request, id int) {
+	transactions, err := h.service.GetPurchaseHistory(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&transactions)
+}
+
+func (h *CustomerHandler) handleGetPoints(w http.ResponseWriter, r *http.Request, id int) {
+	entries, err := h.service.GetPointEntries(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&entries)
+}
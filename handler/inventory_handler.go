@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"gokasir-api/service"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+type InventoryHandler struct {
+	service service.InventoryService
+}
+
+func NewInventoryHandler(service service.InventoryService) *InventoryHandler {
+	return &InventoryHandler{service: service}
+}
+
+func (h *InventoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v1/inventory/low-stock" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetLowStock(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if r.URL.Path == "/api/v1/inventory/expiring" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetExpiring(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if r.URL.Path == "/api/v1/inventory/ingredient-usage" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetIngredientUsage(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *InventoryHandler) handleGetLowStock(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items, err := h.service.GetLowStock(outletID)
+	if err != nil {
+		log.Printf("Error handling get low stock: %v", err)
+		http.Error(w, "Error handling get low stock", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&items)
+}
+
+func (h *InventoryHandler) handleGetIngredientUsage(w http.ResponseWriter, r *http.Request) {
+	start := r.URL.Query().Get("start_date")
+	end := r.URL.Query().Get("end_date")
+	if start == "" || end == "" {
+		http.Error(w, "start_date and end_date are required", http.StatusBadRequest)
+		return
+	}
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	usage, err := h.service.GetIngredientUsage(start, end, outletID)
+	if err != nil {
+		log.Printf("Error handling get ingredient usage: %v", err)
+		http.Error(w, "Error handling get ingredient usage", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&usage)
+}
+
+// handleGetExpiring lists lots expiring within ?days= days, 30 by default.
+func (h *InventoryHandler) handleGetExpiring(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid days", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lots, err := h.service.GetExpiringLots(days, outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&lots)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"gokasir-api/service"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type NotificationHandler struct {
+	service service.NotificationService
+}
+
+func NewNotificationHandler(service service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v1/notifications" || r.URL.Path == "/api/v1/notifications/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/notifications/"), "/")
+	if len(parts) == 2 && parts[1] == "read" {
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleMarkRead(w, r, id)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *NotificationHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	notifications, err := h.service.GetAllNotification(r.URL.Query().Get("unread") == "true")
+	if err != nil {
+		log.Printf("Error handling get notifications: %v", err)
+		http.Error(w, "Error handling get notifications", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&notifications)
+}
+
+func (h *NotificationHandler) handleMarkRead(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.MarkRead(id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type OutletHandler struct {
+	service service.OutletService
+}
+
+func NewOutletHandler(service service.OutletService) *OutletHandler {
+	return &OutletHandler{service: service}
+}
+
+// outletParam reads the optional outlet_id query parameter.
+func outletParam(r *http.Request) (*int, error) {
+	value := r.URL.Query().Get("outlet_id")
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, errors.New("Invalid outlet_id")
+	}
+	return &id, nil
+}
+
+func (h *OutletHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/outlet")
+	if r.URL.Path == "/api/v1/outlet" || r.URL.Path == "/api/v1/outlet/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		if len(parts) == 2 && parts[1] == "stock" {
+			if r.Method != http.MethodGet {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			h.handleGetStock(w, r, id)
+			return
+		}
+		if len(parts) == 3 {
+			productID, err := strconv.Atoi(parts[2])
+			if err != nil {
+				http.Error(w, "Invalid product ID", http.StatusBadRequest)
+				return
+			}
+			switch {
+			case parts[1] == "stock" && r.Method == http.MethodPut:
+				h.handleSetStock(w, r, id, productID)
+			case parts[1] == "price" && r.Method == http.MethodPut:
+				h.handleSetPrice(w, r, id, productID)
+			case parts[1] == "price" && r.Method == http.MethodDelete:
+				h.handleDeletePrice(w, r, id, productID)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if len(parts) > 1 {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case http.MethodPut:
+			h.handleUpdate(w, r, id)
+		case http.MethodDelete:
+			h.handleDelete(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *OutletHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	outlets, err := h.service.GetAllOutlet()
+	if err != nil {
+		log.Printf("Error handling get outlet: %v", err)
+		http.Error(w, "Error handling get outlet", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&outlets)
+}
+
+func (h *OutletHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.OutletRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	outlet, err := h.service.CreateOutlet(&req)
+	if err != nil {
+		log.Printf("Error handling creating outlet: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&outlet)
+}
+
+func (h *OutletHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	outlet, err := h.service.GetOutletByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&outlet)
+}
+
+func (h *OutletHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.OutletRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	outlet, err := h.service.UpdateOutlet(id, &req)
+	if err != nil {
+		log.Printf("Error handling updating outlet: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&outlet)
+}
+
+func (h *OutletHandler) handleDelete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.DeleteOutlet(id); err != nil {
+		log.Printf("Error handling deleting outlet: %v", err)
+		if errors.Is(err, models.ErrOutletInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OutletHandler) handleGetStock(w http.ResponseWriter, r *http.Request, id int) {
+	stocks, err := h.service.GetOutletStock(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&stocks)
+}
+
+func (h *OutletHandler) handleSetStock(w http.ResponseWriter, r *http.Request, id, productID int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.SetOutletStockRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.SetOutletStock(id, productID, &req); err != nil {
+		log.Printf("Error handling set outlet stock: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OutletHandler) handleSetPrice(w http.ResponseWriter, r *http.Request, id, productID int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.SetOutletPriceRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.SetOutletPrice(id, productID, &req); err != nil {
+		log.Printf("Error handling set outlet price: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OutletHandler) handleDeletePrice(w http.ResponseWriter, r *http.Request, id, productID int) {
+	if err := h.service.DeleteOutletPrice(id, productID); err != nil {
+		log.Printf("Error handling delete outlet price: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
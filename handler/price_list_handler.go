@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type PriceListHandler struct {
+	service service.PriceListService
+}
+
+func NewPriceListHandler(service service.PriceListService) *PriceListHandler {
+	return &PriceListHandler{service: service}
+}
+
+func (h *PriceListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/price-list")
+	if r.URL.Path == "/api/v1/price-list" || r.URL.Path == "/api/v1/price-list/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		id, err := strconv.Atoi(strings.TrimPrefix(path, "/"))
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case http.MethodPut:
+			h.handleUpdate(w, r, id)
+		case http.MethodDelete:
+			h.handleDelete(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *PriceListHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	priceLists, err := h.service.GetAllPriceList()
+	if err != nil {
+		log.Printf("Error handling get price list: %v", err)
+		http.Error(w, "Error handling get price list", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&priceLists)
+}
+
+func (h *PriceListHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.PriceListRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	priceList, err := h.service.CreatePriceList(&req)
+	if err != nil {
+		log.Printf("Error handling creating price list: %v", err)
+		if errors.Is(err, models.ErrDuplicateCustomerGroup) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&priceList)
+}
+
+func (h *PriceListHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	priceList, err := h.service.GetPriceListByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&priceList)
+}
+
+func (h *PriceListHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.PriceListRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	priceList, err := h.service.UpdatePriceList(id, &req)
+	if err != nil {
+		log.Printf("Error handling updating price list: %v", err)
+		if errors.Is(err, models.ErrDuplicateCustomerGroup) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&priceList)
+}
+
+func (h *PriceListHandler) handleDelete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.DeletePriceList(id); err != nil {
+		log.Printf("Error handling deleting price list: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"gokasir-api/models"
 	"gokasir-api/service"
 	"io"
@@ -33,12 +34,53 @@ func (h *ProductHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if strings.HasPrefix(path, "/") {
-		idStr := strings.TrimPrefix(path, "/")
-		id, err := strconv.Atoi(idStr)
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		if parts[0] == "barcode" && len(parts) == 2 {
+			if r.Method != http.MethodGet {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			h.handleGetByBarcode(w, r, parts[1])
+			return
+		}
+		id, err := strconv.Atoi(parts[0])
 		if err != nil {
 			http.Error(w, "Invalid ID", http.StatusInternalServerError)
 			return
 		}
+		if len(parts) > 1 {
+			switch {
+			case parts[1] == "stock-history" && r.Method == http.MethodGet:
+				h.handleGetStockHistory(w, r, id)
+			case parts[1] == "variants" && r.Method == http.MethodGet:
+				h.handleGetVariants(w, r, id)
+			case parts[1] == "variants" && r.Method == http.MethodPost:
+				h.handleGenerateVariants(w, r, id)
+			case parts[1] == "components" && r.Method == http.MethodGet:
+				h.handleGetBundle(w, r, id)
+			case parts[1] == "components" && r.Method == http.MethodPut:
+				h.handleSetBundle(w, r, id)
+			case parts[1] == "recipe" && r.Method == http.MethodGet:
+				h.handleGetRecipe(w, r, id)
+			case parts[1] == "recipe" && r.Method == http.MethodPut:
+				h.handleSetRecipe(w, r, id)
+			case parts[1] == "units" && r.Method == http.MethodGet:
+				h.handleGetUnits(w, r, id)
+			case parts[1] == "units" && r.Method == http.MethodPut:
+				h.handleSetUnits(w, r, id)
+			case parts[1] == "price-tiers" && r.Method == http.MethodGet:
+				h.handleGetPriceTiers(w, r, id)
+			case parts[1] == "price-tiers" && r.Method == http.MethodPut:
+				h.handleSetPriceTiers(w, r, id)
+			case parts[1] == "lots" && r.Method == http.MethodGet:
+				h.handleGetLots(w, r, id)
+			case parts[1] == "stock-history" || parts[1] == "variants" || parts[1] == "components" || parts[1] == "recipe" || parts[1] == "units" || parts[1] == "price-tiers" || parts[1] == "lots":
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			default:
+				http.NotFound(w, r)
+			}
+			return
+		}
 		switch r.Method {
 		case http.MethodGet:
 			h.handleGetByID(w, r, id)
@@ -56,7 +98,7 @@ func (h *ProductHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func (h *ProductHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
-	products, err := h.service.GetAllProduct(name)
+	products, err := h.service.GetAllProduct(name, r.URL.Query().Get("grouped") == "true")
 	if err != nil {
 		log.Printf("Error handling get product: %v", err)
 		http.Error(w, "Error handling get product", http.StatusInternalServerError)
@@ -82,6 +124,10 @@ func (h *ProductHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
 	product, err := h.service.CreateProduct(&req)
 	if err != nil {
 		log.Printf("Error handling creating product: %v", err)
+		if isConflict(err) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error handling creating product", http.StatusInternalServerError)
 		return
 	}
@@ -102,6 +148,17 @@ func (h *ProductHandler) handleGetByID(w http.ResponseWriter, r *http.Request, i
 	json.NewEncoder(w).Encode(&product)
 }
 
+func (h *ProductHandler) handleGetByBarcode(w http.ResponseWriter, r *http.Request, code string) {
+	product, err := h.service.GetProductByBarcode(code)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&product)
+}
+
 func (h *ProductHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id int) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -117,6 +174,10 @@ func (h *ProductHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id
 	product, err := h.service.UpdateProduct(id, &req)
 	if err != nil {
 		log.Printf("Error handling updating product: %v", err)
+		if isConflict(err) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error handling updating product", http.StatusInternalServerError)
 		return
 	}
@@ -141,6 +202,10 @@ func (h *ProductHandler) handlePatch(w http.ResponseWriter, r *http.Request, id
 	product, err := h.service.PatchProduct(id, &req)
 	if err != nil {
 		log.Printf("Error handling patching product: %v", err)
+		if isConflict(err) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error handling patching product", http.StatusInternalServerError)
 		return
 	}
@@ -157,3 +222,219 @@ func (h *ProductHandler) handleDelete(w http.ResponseWriter, r *http.Request, id
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *ProductHandler) handleGetStockHistory(w http.ResponseWriter, r *http.Request, id int) {
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	movements, err := h.service.GetStockHistory(id, outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&movements)
+}
+
+func (h *ProductHandler) handleGetVariants(w http.ResponseWriter, r *http.Request, id int) {
+	variants, err := h.service.GetVariants(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&variants)
+}
+
+func (h *ProductHandler) handleGenerateVariants(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.GenerateVariantsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	variants, err := h.service.GenerateVariants(id, &req)
+	if err != nil {
+		log.Printf("Error handling generating variants: %v", err)
+		if isConflict(err) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&variants)
+}
+
+// isConflict reports whether err is a duplicate SKU or barcode.
+func isConflict(err error) bool {
+	return errors.Is(err, models.ErrDuplicateSKU) || errors.Is(err, models.ErrDuplicateBarcode) || errors.Is(err, models.ErrDuplicatePLU)
+}
+
+func (h *ProductHandler) handleGetBundle(w http.ResponseWriter, r *http.Request, id int) {
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	bundle, err := h.service.GetBundle(id, outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&bundle)
+}
+
+func (h *ProductHandler) handleSetBundle(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.SetBundleRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	bundle, err := h.service.SetBundleComponents(id, &req)
+	if err != nil {
+		log.Printf("Error handling setting bundle components: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&bundle)
+}
+
+func (h *ProductHandler) handleGetRecipe(w http.ResponseWriter, r *http.Request, id int) {
+	recipe, err := h.service.GetRecipe(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&recipe)
+}
+
+func (h *ProductHandler) handleSetRecipe(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.SetRecipeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	recipe, err := h.service.SetRecipe(id, &req)
+	if err != nil {
+		log.Printf("Error handling setting recipe: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&recipe)
+}
+
+func (h *ProductHandler) handleGetUnits(w http.ResponseWriter, r *http.Request, id int) {
+	units, err := h.service.GetUnits(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&units)
+}
+
+func (h *ProductHandler) handleSetUnits(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.SetUnitsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	units, err := h.service.SetUnits(id, &req)
+	if err != nil {
+		log.Printf("Error handling setting units: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&units)
+}
+
+func (h *ProductHandler) handleGetPriceTiers(w http.ResponseWriter, r *http.Request, id int) {
+	tiers, err := h.service.GetPriceTiers(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&tiers)
+}
+
+func (h *ProductHandler) handleSetPriceTiers(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.SetPriceTiersRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	tiers, err := h.service.SetPriceTiers(id, &req)
+	if err != nil {
+		log.Printf("Error handling setting price tiers: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&tiers)
+}
+
+func (h *ProductHandler) handleGetLots(w http.ResponseWriter, r *http.Request, id int) {
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lots, err := h.service.GetLots(id, outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&lots)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type PromotionHandler struct {
+	service service.PromotionService
+}
+
+func NewPromotionHandler(service service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{service: service}
+}
+
+func (h *PromotionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/promotion")
+	if r.URL.Path == "/api/v1/promotion" || r.URL.Path == "/api/v1/promotion/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		idStr := strings.TrimPrefix(path, "/")
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case http.MethodPut:
+			h.handleUpdate(w, r, id)
+		case http.MethodDelete:
+			h.handleDelete(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *PromotionHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.service.GetAllPromotion()
+	if err != nil {
+		log.Printf("Error handling get promotion: %v", err)
+		http.Error(w, "Error handling get promotion", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&promotions)
+}
+
+func (h *PromotionHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.PromotionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	promotion, err := h.service.CreatePromotion(&req)
+	if err != nil {
+		log.Printf("Error handling creating promotion: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&promotion)
+}
+
+func (h *PromotionHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	promotion, err := h.service.GetPromotionByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&promotion)
+}
+
+func (h *PromotionHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.PromotionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	promotion, err := h.service.UpdatePromotion(id, &req)
+	if err != nil {
+		log.Printf("Error handling updating promotion: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&promotion)
+}
+
+func (h *PromotionHandler) handleDelete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.DeletePromotion(id); err != nil {
+		log.Printf("Error handling deleting promotion: %v", err)
+		if errors.Is(err, models.ErrPromotionInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"encoding/json"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type PurchaseOrderHandler struct {
+	service service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service service.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service}
+}
+
+func (h *PurchaseOrderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/purchase-order")
+	if r.URL.Path == "/api/v1/purchase-order" || r.URL.Path == "/api/v1/purchase-order/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		action := ""
+		if len(parts) > 1 {
+			action = parts[1]
+		}
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case action == "" && r.Method == http.MethodPut:
+			h.handleUpdate(w, r, id)
+		case action == "order" && r.Method == http.MethodPost:
+			h.handleOrder(w, r, id)
+		case action == "cancel" && r.Method == http.MethodPost:
+			h.handleCancel(w, r, id)
+		case action == "receive" && r.Method == http.MethodPost:
+			h.handleReceive(w, r, id)
+		case action == "payments" && r.Method == http.MethodPost:
+			h.handlePay(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *PurchaseOrderHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	var supplierID *int
+	if value := r.URL.Query().Get("supplier_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid supplier_id", http.StatusBadRequest)
+			return
+		}
+		supplierID = &id
+	}
+	orders, err := h.service.GetAllPurchaseOrder(r.URL.Query().Get("status"), supplierID)
+	if err != nil {
+		log.Printf("Error handling get purchase order: %v", err)
+		http.Error(w, "Error handling get purchase order", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&orders)
+}
+
+func (h *PurchaseOrderHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.PurchaseOrderRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	order, err := h.service.CreatePurchaseOrder(&req)
+	if err != nil {
+		log.Printf("Error handling creating purchase order: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&order)
+}
+
+func (h *PurchaseOrderHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	order, err := h.service.GetPurchaseOrderByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&order)
+}
+
+func (h *PurchaseOrderHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.PurchaseOrderRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	order, err := h.service.UpdatePurchaseOrder(id, &req)
+	if err != nil {
+		log.Printf("Error handling updating purchase order: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&order)
+}
+
+func (h *PurchaseOrderHandler) handleOrder(w http.ResponseWriter, r *http.Request, id int) {
+	order, err := h.service.OrderPurchaseOrder(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&order)
+}
+
+func (h *PurchaseOrderHandler) handleCancel(w http.ResponseWriter, r *http.Request, id int) {
+	order, err := h.service.CancelPurchaseOrder(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&order)
+}
+
+func (h *PurchaseOrderHandler) handleReceive(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.ReceiveRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	receipt, err := h.service.ReceivePurchaseOrder(id, &req)
+	if err != nil {
+		log.Printf("Error handling receiving purchase order: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&receipt)
+}
+
+func (h *PurchaseOrderHandler) handlePay(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.SupplierPaymentRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	payment, err := h.service.PaySupplier(id, &req)
+	if err != nil {
+		log.Printf("Error handling supplier payment: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&payment)
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type SerialHandler struct {
+	service service.SerialService
+}
+
+func NewSerialHandler(service service.SerialService) *SerialHandler {
+	return &SerialHandler{service: service}
+}
+
+func (h *SerialHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/serials")
+	if r.URL.Path == "/api/v1/serials" || r.URL.Path == "/api/v1/serials/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleRegister(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetBySerial(w, r, strings.TrimPrefix(path, "/"))
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// handleGetAll lists the serials of ?product_id=, optionally narrowed by
+// ?outlet_id= and ?status=.
+func (h *SerialHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.URL.Query().Get("product_id"))
+	if err != nil {
+		http.Error(w, "product_id is required", http.StatusBadRequest)
+		return
+	}
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	serials, err := h.service.GetAllSerial(productID, outletID, r.URL.Query().Get("status"))
+	if err != nil {
+		log.Printf("Error handling get serials: %v", err)
+		http.Error(w, "Error handling get serials", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&serials)
+}
+
+func (h *SerialHandler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.RegisterSerialsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	serials, err := h.service.RegisterSerials(&req)
+	if err != nil {
+		log.Printf("Error handling registering serials: %v", err)
+		if errors.Is(err, models.ErrDuplicateSerial) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&serials)
+}
+
+func (h *SerialHandler) handleGetBySerial(w http.ResponseWriter, r *http.Request, serial string) {
+	s, err := h.service.GetSerial(serial)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&s)
+}
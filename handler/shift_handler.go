@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ShiftHandler struct {
+	service service.ShiftService
+}
+
+func NewShiftHandler(service service.ShiftService) *ShiftHandler {
+	return &ShiftHandler{service: service}
+}
+
+func (h *ShiftHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/shift"), "/")
+	switch {
+	case path == "open" && r.Method == http.MethodPost:
+		h.handleOpen(w, r)
+		return
+	case path == "current" && r.Method == http.MethodGet:
+		h.handleGetCurrent(w, r)
+		return
+	}
+	parts := strings.Split(path, "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.handleGetSummary(w, r, id)
+	case action == "cash" && r.Method == http.MethodPost:
+		h.handleCashMovement(w, r, id)
+	case action == "close" && r.Method == http.MethodPost:
+		h.handleClose(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ShiftHandler) handleOpen(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.OpenShiftRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	shift, err := h.service.OpenShift(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shift)
+}
+
+func (h *ShiftHandler) handleGetCurrent(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	shift, err := h.service.GetCurrentShift(outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(shift)
+}
+
+func (h *ShiftHandler) handleGetSummary(w http.ResponseWriter, r *http.Request, id int) {
+	summary, err := h.service.GetShiftSummary(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (h *ShiftHandler) handleCashMovement(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.CashMovementRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	movement, err := h.service.RecordCashMovement(id, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+func (h *ShiftHandler) handleClose(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.CloseShiftRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	summary, err := h.service.CloseShift(id, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
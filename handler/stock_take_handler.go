@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StockTakeHandler struct {
+	service service.StockTakeService
+}
+
+func NewStockTakeHandler(service service.StockTakeService) *StockTakeHandler {
+	return &StockTakeHandler{service: service}
+}
+
+func (h *StockTakeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/stock-take")
+	if r.URL.Path == "/api/v1/stock-take" || r.URL.Path == "/api/v1/stock-take/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleStart(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		action := ""
+		if len(parts) > 1 {
+			action = parts[1]
+		}
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case action == "counts" && r.Method == http.MethodPost:
+			h.handleCount(w, r, id)
+		case action == "approve" && r.Method == http.MethodPost:
+			h.handleApprove(w, r, id)
+		case action == "cancel" && r.Method == http.MethodPost:
+			h.handleCancel(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *StockTakeHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	stockTakes, err := h.service.GetAllStockTake(r.URL.Query().Get("status"))
+	if err != nil {
+		log.Printf("Error handling get stock take: %v", err)
+		http.Error(w, "Error handling get stock take", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&stockTakes)
+}
+
+func (h *StockTakeHandler) handleStart(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.StartStockTakeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	stockTake, err := h.service.StartStockTake(&req)
+	if err != nil {
+		log.Printf("Error handling starting stock take: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&stockTake)
+}
+
+func (h *StockTakeHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	stockTake, err := h.service.GetStockTakeByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&stockTake)
+}
+
+func (h *StockTakeHandler) handleCount(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.StockCountRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	stockTake, err := h.service.RecordCounts(id, &req)
+	if err != nil {
+		log.Printf("Error handling stock count: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&stockTake)
+}
+
+func (h *StockTakeHandler) handleApprove(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.ApproveStockTakeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	stockTake, err := h.service.ApproveStockTake(id, &req)
+	if err != nil {
+		log.Printf("Error handling approving stock take: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&stockTake)
+}
+
+func (h *StockTakeHandler) handleCancel(w http.ResponseWriter, r *http.Request, id int) {
+	stockTake, err := h.service.CancelStockTake(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&stockTake)
+}
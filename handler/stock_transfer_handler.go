@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StockTransferHandler struct {
+	service service.StockTransferService
+}
+
+func NewStockTransferHandler(service service.StockTransferService) *StockTransferHandler {
+	return &StockTransferHandler{service: service}
+}
+
+func (h *StockTransferHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/stock-transfer")
+	if r.URL.Path == "/api/v1/stock-transfer" || r.URL.Path == "/api/v1/stock-transfer/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if path == "/in-transit" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleGetInTransit(w, r)
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		action := ""
+		if len(parts) > 1 {
+			action = parts[1]
+		}
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case action == "dispatch" && r.Method == http.MethodPost:
+			h.handleDispatch(w, r, id)
+		case action == "receive" && r.Method == http.MethodPost:
+			h.handleReceive(w, r, id)
+		case action == "cancel" && r.Method == http.MethodPost:
+			h.handleCancel(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// handleGetAll lists transfers, optionally narrowed by ?status= and by
+// ?outlet_id= on either end.
+func (h *StockTransferHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	transfers, err := h.service.GetAllStockTransfer(r.URL.Query().Get("status"), outletID)
+	if err != nil {
+		log.Printf("Error handling get stock transfer: %v", err)
+		http.Error(w, "Error handling get stock transfer", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&transfers)
+}
+
+func (h *StockTransferHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.TransferRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	transfer, err := h.service.CreateStockTransfer(&req)
+	if err != nil {
+		log.Printf("Error handling creating stock transfer: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&transfer)
+}
+
+func (h *StockTransferHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	transfer, err := h.service.GetStockTransferByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&transfer)
+}
+
+func (h *StockTransferHandler) handleDispatch(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.DispatchTransferRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	transfer, err := h.service.DispatchStockTransfer(id, &req)
+	if err != nil {
+		log.Printf("Error handling dispatching stock transfer: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&transfer)
+}
+
+func (h *StockTransferHandler) handleReceive(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.ReceiveTransferRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	transfer, err := h.service.ReceiveStockTransfer(id, &req)
+	if err != nil {
+		log.Printf("Error handling receiving stock transfer: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&transfer)
+}
+
+func (h *StockTransferHandler) handleCancel(w http.ResponseWriter, r *http.Request, id int) {
+	transfer, err := h.service.CancelStockTransfer(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&transfer)
+}
+
+// handleGetInTransit reports stock dispatched and not yet received,
+// optionally only transfers from or to ?outlet_id=.
+func (h *StockTransferHandler) handleGetInTransit(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	stock, err := h.service.GetInTransit(outletID)
+	if err != nil {
+		log.Printf("Error handling get stock in transit: %v", err)
+		http.Error(w, "Error handling get stock in transit", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&stock)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type SupplierHandler struct {
+	service service.SupplierService
+}
+
+func NewSupplierHandler(service service.SupplierService) *SupplierHandler {
+	return &SupplierHandler{service: service}
+}
+
+func (h *SupplierHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/supplier")
+	if r.URL.Path == "/api/v1/supplier" || r.URL.Path == "/api/v1/supplier/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		id, err := strconv.Atoi(strings.TrimPrefix(path, "/"))
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case http.MethodPut:
+			h.handleUpdate(w, r, id)
+		case http.MethodDelete:
+			h.handleDelete(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *SupplierHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := h.service.GetAllSupplier(r.URL.Query().Get("q"))
+	if err != nil {
+		log.Printf("Error handling get supplier: %v", err)
+		http.Error(w, "Error handling get supplier", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&suppliers)
+}
+
+func (h *SupplierHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.SupplierRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	supplier, err := h.service.CreateSupplier(&req)
+	if err != nil {
+		log.Printf("Error handling creating supplier: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&supplier)
+}
+
+func (h *SupplierHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	supplier, err := h.service.GetSupplierByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&supplier)
+}
+
+func (h *SupplierHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.SupplierRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	supplier, err := h.service.UpdateSupplier(id, &req)
+	if err != nil {
+		log.Printf("Error handling updating supplier: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&supplier)
+}
+
+func (h *SupplierHandler) handleDelete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.DeleteSupplier(id); err != nil {
+		log.Printf("Error handling deleting supplier: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -6,6 +6,8 @@ import (
 	"gokasir-api/service"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type TransactionHandler struct {
@@ -44,6 +46,58 @@ func (h *TransactionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if r.URL.Path == "/api/v1/report/promotions" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetPromotionReport(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if r.URL.Path == "/api/v1/report/products" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetProductSalesReport(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if r.URL.Path == "/api/v1/report/tax" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetTaxReport(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v1/transactions/") {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/transactions/"), "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		action := ""
+		if len(parts) > 1 {
+			action = parts[1]
+		}
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case action == "receipt" && r.Method == http.MethodGet:
+			h.handleGetReceipt(w, r, id)
+		case action == "void" && r.Method == http.MethodPost:
+			h.handleRefund(w, r, id, true)
+		case action == "refund" && r.Method == http.MethodPost:
+			h.handleRefund(w, r, id, false)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
 	http.NotFound(w, r)
 }
 
@@ -59,7 +113,7 @@ func (h *TransactionHandler) handleCheckout(w http.ResponseWriter, r *http.Reque
 		http.Error(w, "Error handling unmarshal", http.StatusInternalServerError)
 		return
 	}
-	checkout, err := h.service.Checkout(req.Items)
+	checkout, err := h.service.Checkout(&req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -69,11 +123,54 @@ func (h *TransactionHandler) handleCheckout(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(checkout)
 }
 
+func (h *TransactionHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	transaction, err := h.service.GetTransactionByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *TransactionHandler) handleRefund(w http.ResponseWriter, r *http.Request, id int, void bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.RefundRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusInternalServerError)
+		return
+	}
+	var refund *models.Transaction
+	if void {
+		refund, err = h.service.VoidTransaction(id, &req)
+	} else {
+		refund, err = h.service.RefundTransaction(id, &req)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
+
 func (h *TransactionHandler) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	start := r.URL.Query().Get("start_date")
 	end := r.URL.Query().Get("end_date")
 	if start != "" && end != "" {
-		rangeTransaction, err := h.service.RangeTransaction(start, end)
+		rangeTransaction, err := h.service.RangeTransaction(start, end, outletID)
 		if err != nil {
 			http.Error(w, "Error handling get range transaction", http.StatusInternalServerError)
 			return
@@ -82,7 +179,7 @@ func (h *TransactionHandler) handleGetTransaction(w http.ResponseWriter, r *http
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(rangeTransaction)
 	} else {
-		transactions, err := h.service.GetAllTransaction()
+		transactions, err := h.service.GetAllTransaction(outletID)
 		if err != nil {
 			http.Error(w, "Error handling get all transactions", http.StatusInternalServerError)
 			return
@@ -94,7 +191,12 @@ func (h *TransactionHandler) handleGetTransaction(w http.ResponseWriter, r *http
 }
 
 func (h *TransactionHandler) handleGetTodaysTransaction(w http.ResponseWriter, r *http.Request) {
-	todaysTransaction, err := h.service.TodaysTransaction()
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	todaysTransaction, err := h.service.TodaysTransaction(outletID)
 	if err != nil {
 		http.Error(w, "Error handling get today's transaction", http.StatusInternalServerError)
 		return
@@ -103,3 +205,80 @@ func (h *TransactionHandler) handleGetTodaysTransaction(w http.ResponseWriter, r
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(todaysTransaction)
 }
+
+func (h *TransactionHandler) handleGetPromotionReport(w http.ResponseWriter, r *http.Request) {
+	start := r.URL.Query().Get("start_date")
+	end := r.URL.Query().Get("end_date")
+	if start == "" || end == "" {
+		http.Error(w, "start_date and end_date are required", http.StatusBadRequest)
+		return
+	}
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := h.service.PromotionReport(start, end, outletID)
+	if err != nil {
+		http.Error(w, "Error handling get promotion report", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *TransactionHandler) handleGetTaxReport(w http.ResponseWriter, r *http.Request) {
+	start := r.URL.Query().Get("start_date")
+	end := r.URL.Query().Get("end_date")
+	if start == "" || end == "" {
+		http.Error(w, "start_date and end_date are required", http.StatusBadRequest)
+		return
+	}
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := h.service.TaxReport(start, end, outletID)
+	if err != nil {
+		http.Error(w, "Error handling get tax report", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *TransactionHandler) handleGetProductSalesReport(w http.ResponseWriter, r *http.Request) {
+	start := r.URL.Query().Get("start_date")
+	end := r.URL.Query().Get("end_date")
+	if start == "" || end == "" {
+		http.Error(w, "start_date and end_date are required", http.StatusBadRequest)
+		return
+	}
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := h.service.ProductSalesReport(start, end, r.URL.Query().Get("group_by"), outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *TransactionHandler) handleGetReceipt(w http.ResponseWriter, r *http.Request, id int) {
+	body, contentType, err := h.service.GetReceipt(id, r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
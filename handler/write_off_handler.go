@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"encoding/json"
+	"gokasir-api/middleware"
+	"gokasir-api/models"
+	"gokasir-api/service"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type WriteOffHandler struct {
+	service    service.WriteOffService
+	managerKey string
+}
+
+func NewWriteOffHandler(service service.WriteOffService, managerKey string) *WriteOffHandler {
+	return &WriteOffHandler{service: service, managerKey: managerKey}
+}
+
+func (h *WriteOffHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v1/report/write-offs" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetReport(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/write-off")
+	if r.URL.Path == "/api/v1/write-off" || r.URL.Path == "/api/v1/write-off/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleGetAll(w, r)
+		case http.MethodPost:
+			h.handleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if strings.HasPrefix(path, "/") {
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		action := ""
+		if len(parts) > 1 {
+			action = parts[1]
+		}
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			h.handleGetByID(w, r, id)
+		case action == "approve" && r.Method == http.MethodPost:
+			h.handleApprove(w, r, id)
+		case action == "cancel" && r.Method == http.MethodPost:
+			h.handleCancel(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// handleGetAll lists write-offs, optionally narrowed by ?status= and
+// ?outlet_id=.
+func (h *WriteOffHandler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeOffs, err := h.service.GetAllWriteOff(r.URL.Query().Get("status"), outletID)
+	if err != nil {
+		log.Printf("Error handling get write off: %v", err)
+		http.Error(w, "Error handling get write off", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&writeOffs)
+}
+
+func (h *WriteOffHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.WriteOffRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	writeOff, err := h.service.CreateWriteOff(&req)
+	if err != nil {
+		log.Printf("Error handling creating write off: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&writeOff)
+}
+
+func (h *WriteOffHandler) handleGetByID(w http.ResponseWriter, r *http.Request, id int) {
+	writeOff, err := h.service.GetWriteOffByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&writeOff)
+}
+
+// handleApprove posts a pending write-off. Only a manager can, so the
+// request must carry the manager key on top of the API key.
+func (h *WriteOffHandler) handleApprove(w http.ResponseWriter, r *http.Request, id int) {
+	if !middleware.ManagerKey(r, h.managerKey) {
+		http.Error(w, "Approving a write off needs the manager key", http.StatusForbidden)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error handling reading request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	var req models.ApproveWriteOffRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Error handling unmarshal", http.StatusBadRequest)
+		return
+	}
+	writeOff, err := h.service.ApproveWriteOff(id, &req)
+	if err != nil {
+		log.Printf("Error handling approving write off: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&writeOff)
+}
+
+func (h *WriteOffHandler) handleCancel(w http.ResponseWriter, r *http.Request, id int) {
+	writeOff, err := h.service.CancelWriteOff(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&writeOff)
+}
+
+func (h *WriteOffHandler) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	start := r.URL.Query().Get("start_date")
+	end := r.URL.Query().Get("end_date")
+	if start == "" || end == "" {
+		http.Error(w, "start_date and end_date are required", http.StatusBadRequest)
+		return
+	}
+	outletID, err := outletParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := h.service.WriteOffReport(start, end, outletID)
+	if err != nil {
+		log.Printf("Error handling get write off report: %v", err)
+		http.Error(w, "Error handling get write off report", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"gokasir-api/barcode"
 	"gokasir-api/database"
 	"gokasir-api/handler"
 	"gokasir-api/middleware"
+	"gokasir-api/notify"
+	"gokasir-api/pricing"
+	"gokasir-api/receipt"
 	"gokasir-api/repository"
 	"gokasir-api/service"
 	"log"
@@ -17,12 +21,25 @@ import (
 
 var message = `{
 	"endpoint" : {
-		"GET	/api/v1/product" : "show all product",
+		"GET	/api/v1/product?grouped=true" : "show all product, variants nested under parent when grouped",
 		"POST	/api/v1/product"	: "add product",
 		"GET	/api/v1/product/{id}" : "show 1 product",
+		"GET	/api/v1/product/barcode/{code}" : "find product by barcode or sku",
 		"PUT"	/api/v1/product/{id}" : "update product",
 		"PATCH	/api/v1/product{id}" : "update field product",
 		"DELETE	/api/v1/product/{id}" : "delete 1 product",
+		"GET	/api/v1/product/{id}/stock-history?outlet_id={outlet_id}" : "show stock movements of product",
+		"GET	/api/v1/product/{id}/variants" : "show variants of product",
+		"POST	/api/v1/product/{id}/variants" : "generate variants from options",
+		"GET	/api/v1/product/{id}/components?outlet_id={outlet_id}" : "show bundle components and availability",
+		"PUT	/api/v1/product/{id}/components" : "set bundle components",
+		"GET	/api/v1/product/{id}/recipe" : "show recipe of menu item",
+		"PUT	/api/v1/product/{id}/recipe" : "set recipe of menu item",
+		"GET	/api/v1/product/{id}/units" : "show units and pack conversions of product",
+		"PUT	/api/v1/product/{id}/units" : "set units and pack conversions of product",
+		"GET	/api/v1/product/{id}/price-tiers" : "show wholesale quantity price tiers",
+		"PUT	/api/v1/product/{id}/price-tiers" : "set wholesale quantity price tiers",
+		"GET	/api/v1/product/{id}/lots?outlet_id={outlet_id}" : "show batches and expiry dates of product in stock",
 		"GET	/api/v1/category" : "show all category",
 		"POST	/api/v1/category" : "add kategori",
 		"GET	/api/v1/category/{id}" : "show 1 category",
@@ -30,9 +47,88 @@ var message = `{
 		"PATCH	/api/v1/category{id}" : "update field category",
 		"DELETE	/api/v1/category/{id}" : "delete 1 category",
 		"POST	/api/v1/checkout" : "create transaction",
+		"GET	/api/v1/transactions/{id}" : "show 1 transaction",
+		"GET	/api/v1/transactions/{id}/receipt?format=text|escpos|pdf" : "print receipt",
+		"POST	/api/v1/transactions/{id}/void" : "void transaction",
+		"POST	/api/v1/transactions/{id}/refund" : "refund transaction lines",
 		"GET	/api/v1/report" : "show all transaction",
-		"GET	/api/v1/report/today" : "show today's transaction",
+		"GET	/api/v1/report/today?outlet_id={outlet_id}" : "show today's transaction, all outlets when outlet_id is omitted",
 		"GET	/api/v1/report?start_date={start_day}&end_date={end_day}" : "show transaction between days",
+		"GET	/api/v1/report/promotions?start_date={start_day}&end_date={end_day}&outlet_id={outlet_id}" : "show discount given by each promotion",
+		"GET	/api/v1/report/tax?start_date={start_day}&end_date={end_day}&outlet_id={outlet_id}" : "show tax summary per rate",
+		"GET	/api/v1/report/products?start_date={start_day}&end_date={end_day}&group_by={parent|component|category}" : "show sales, cost of goods sold and margin per product, per parent product, per category or broken down into bundle components",
+		"GET	/api/v1/report/write-offs?start_date={start_day}&end_date={end_day}&outlet_id={outlet_id}" : "show stock written off at cost per reason and per product",
+		"GET	/api/v1/promotion" : "show all promotion",
+		"POST	/api/v1/promotion" : "add promotion",
+		"GET	/api/v1/promotion/{id}" : "show 1 promotion",
+		"PUT	/api/v1/promotion/{id}" : "update promotion",
+		"DELETE	/api/v1/promotion/{id}" : "delete 1 promotion",
+		"GET	/api/v1/price-list" : "show all price list",
+		"POST	/api/v1/price-list" : "add price list for customer groups",
+		"GET	/api/v1/price-list/{id}" : "show 1 price list with prices",
+		"PUT	/api/v1/price-list/{id}" : "update price list",
+		"DELETE	/api/v1/price-list/{id}" : "delete 1 price list",
+		"GET	/api/v1/serials?product_id={product_id}&outlet_id={outlet_id}&status={status}" : "show serial numbers of product",
+		"POST	/api/v1/serials" : "register serial numbers for stock on hand",
+		"GET	/api/v1/serials/{serial}" : "look up serial with its sale, customer and warranty expiry",
+		"GET	/api/v1/customer?q={search}" : "show all customer",
+		"POST	/api/v1/customer" : "add customer",
+		"GET	/api/v1/customer/{id}" : "show 1 customer",
+		"PUT	/api/v1/customer/{id}" : "update customer",
+		"PATCH	/api/v1/customer/{id}" : "update field customer",
+		"DELETE	/api/v1/customer/{id}" : "delete 1 customer",
+		"GET	/api/v1/customer/{id}/transactions" : "show customer purchase history",
+		"GET	/api/v1/customer/{id}/points" : "show customer points ledger",
+		"POST	/api/v1/shift/open" : "open cashier shift",
+		"GET	/api/v1/shift/current" : "show open shift",
+		"GET	/api/v1/shift/{id}" : "show shift summary",
+		"POST	/api/v1/shift/{id}/cash" : "record cash in/out",
+		"POST	/api/v1/shift/{id}/close" : "close shift with counted cash",
+		"GET	/api/v1/outlet" : "list outlets",
+		"POST	/api/v1/outlet" : "create outlet",
+		"GET	/api/v1/outlet/{id}" : "show outlet",
+		"PUT	/api/v1/outlet/{id}" : "update outlet",
+		"DELETE	/api/v1/outlet/{id}" : "delete outlet",
+		"GET	/api/v1/outlet/{id}/stock" : "list stock and prices at outlet",
+		"PUT	/api/v1/outlet/{id}/stock/{product_id}" : "set product stock at outlet",
+		"PUT	/api/v1/outlet/{id}/price/{product_id}" : "set outlet price override",
+		"DELETE	/api/v1/outlet/{id}/price/{product_id}" : "remove outlet price override",
+		"GET	/api/v1/supplier?q={search}" : "show all supplier with amount owed",
+		"POST	/api/v1/supplier" : "add supplier",
+		"GET	/api/v1/supplier/{id}" : "show 1 supplier",
+		"PUT	/api/v1/supplier/{id}" : "update supplier",
+		"DELETE	/api/v1/supplier/{id}" : "delete 1 supplier",
+		"GET	/api/v1/purchase-order?status={status}&supplier_id={id}" : "show purchase orders",
+		"POST	/api/v1/purchase-order" : "create draft purchase order",
+		"GET	/api/v1/purchase-order/{id}" : "show purchase order with receipts and payments",
+		"PUT	/api/v1/purchase-order/{id}" : "update draft purchase order",
+		"POST	/api/v1/purchase-order/{id}/order" : "send purchase order to supplier",
+		"POST	/api/v1/purchase-order/{id}/cancel" : "cancel purchase order",
+		"POST	/api/v1/purchase-order/{id}/receive" : "receive goods into stock",
+		"POST	/api/v1/purchase-order/{id}/payments" : "record payment to supplier",
+		"GET	/api/v1/stock-take?status={status}" : "show stock take sessions",
+		"POST	/api/v1/stock-take" : "start stock take and snapshot stock",
+		"GET	/api/v1/stock-take/{id}" : "show stock take with variances",
+		"POST	/api/v1/stock-take/{id}/counts" : "record counted quantities",
+		"POST	/api/v1/stock-take/{id}/approve" : "approve and post adjustments",
+		"POST	/api/v1/stock-take/{id}/cancel" : "cancel stock take",
+		"GET	/api/v1/stock-transfer?status={status}&outlet_id={outlet_id}" : "show stock transfers from or to outlet",
+		"POST	/api/v1/stock-transfer" : "create draft stock transfer between outlets",
+		"GET	/api/v1/stock-transfer/{id}" : "show stock transfer with lots and discrepancies",
+		"POST	/api/v1/stock-transfer/{id}/dispatch" : "take stock out of source outlet into transit",
+		"POST	/api/v1/stock-transfer/{id}/receive" : "receive stock at destination and record discrepancies",
+		"POST	/api/v1/stock-transfer/{id}/cancel" : "cancel draft stock transfer",
+		"GET	/api/v1/stock-transfer/in-transit?outlet_id={outlet_id}" : "show stock in transit between outlets",
+		"GET	/api/v1/write-off?status={status}&outlet_id={outlet_id}" : "show write-offs",
+		"POST	/api/v1/write-off" : "write off expired, damaged, staff meal or sample stock, pending approval above the limit",
+		"GET	/api/v1/write-off/{id}" : "show write-off with lines at cost",
+		"POST	/api/v1/write-off/{id}/approve" : "approve pending write-off and take it out of stock, needs the MANAGER_KEY in the X-Manager-Key header",
+		"POST	/api/v1/write-off/{id}/cancel" : "cancel pending write-off",
+		"GET	/api/v1/inventory/low-stock?outlet_id={outlet_id}" : "show products at or below minimum stock",
+		"GET	/api/v1/inventory/expiring?days={days}&outlet_id={outlet_id}" : "show lots expiring within days, expired lots included",
+		"GET	/api/v1/inventory/ingredient-usage?start_date={start_day}&end_date={end_day}&outlet_id={outlet_id}" : "compare recipe usage of ingredients with stock take variances",
+		"GET	/api/v1/notifications?unread=true" : "show notification feed",
+		"POST	/api/v1/notifications/{id}/read" : "mark notification read",
 	},
 	"environtment" : "production",
 	"message" : "simple API",
@@ -40,10 +136,26 @@ var message = `{
 }`
 
 type Config struct {
-	Port        string `mapstructure:"PORT"`
-	DBConn      string `mapstructure:"DB_CONN"`
-	APIKey      string `mapstructure:"API_KEY"`
-	corsOrigins string `mapstructure:"ALLOWED_ORIGINS"`
+	Port              string  `mapstructure:"PORT"`
+	DBConn            string  `mapstructure:"DB_CONN"`
+	APIKey            string  `mapstructure:"API_KEY"`
+	corsOrigins       string  `mapstructure:"ALLOWED_ORIGINS"`
+	TaxRate           float64 `mapstructure:"TAX_RATE"`
+	TaxInclusive      bool    `mapstructure:"TAX_INCLUSIVE"`
+	ServiceChargeRate float64 `mapstructure:"SERVICE_CHARGE_RATE"`
+	ReceiptStoreName  string  `mapstructure:"RECEIPT_STORE_NAME"`
+	ReceiptAddress    string  `mapstructure:"RECEIPT_ADDRESS"`
+	ReceiptPhone      string  `mapstructure:"RECEIPT_PHONE"`
+	ReceiptFooter     string  `mapstructure:"RECEIPT_FOOTER"`
+	ReceiptWidth      int     `mapstructure:"RECEIPT_PAPER_WIDTH"`
+	LoyaltyEarnPer    int     `mapstructure:"LOYALTY_EARN_PER"`
+	LoyaltyPointValue int     `mapstructure:"LOYALTY_POINT_VALUE"`
+	LoyaltyTiers      string  `mapstructure:"LOYALTY_TIERS"`
+	AlertWebhookURL   string  `mapstructure:"ALERT_WEBHOOK_URL"`
+	ScaleBarcodes     string  `mapstructure:"SCALE_BARCODES"`
+	WriteOffLimit     int     `mapstructure:"WRITE_OFF_APPROVAL_LIMIT"`
+	RequireShift      bool    `mapstructure:"REQUIRE_OPEN_SHIFT"`
+	ManagerKey        string  `mapstructure:"MANAGER_KEY"`
 }
 
 func main() {
@@ -56,11 +168,35 @@ func main() {
 		_ = viper.ReadInConfig()
 	}
 
+	viper.SetDefault("TAX_RATE", pricing.DefaultTaxConfig().Rate)
+	viper.SetDefault("RECEIPT_STORE_NAME", receipt.DefaultTemplate().StoreName)
+	viper.SetDefault("RECEIPT_FOOTER", receipt.DefaultTemplate().Footer)
+	viper.SetDefault("RECEIPT_PAPER_WIDTH", receipt.DefaultTemplate().PaperWidth)
+	viper.SetDefault("LOYALTY_EARN_PER", pricing.DefaultLoyaltyConfig().EarnPer)
+	viper.SetDefault("LOYALTY_POINT_VALUE", pricing.DefaultLoyaltyConfig().PointValue)
+	viper.SetDefault("WRITE_OFF_APPROVAL_LIMIT", service.DefaultWriteOffApprovalLimit)
+
 	config := Config{
-		Port:        viper.GetString("PORT"),
-		DBConn:      viper.GetString("DB_CONN"),
-		APIKey:      viper.GetString("API_KEY"),
-		corsOrigins: viper.GetString("ALLOWED_ORIGINS"),
+		Port:              viper.GetString("PORT"),
+		DBConn:            viper.GetString("DB_CONN"),
+		APIKey:            viper.GetString("API_KEY"),
+		corsOrigins:       viper.GetString("ALLOWED_ORIGINS"),
+		TaxRate:           viper.GetFloat64("TAX_RATE"),
+		TaxInclusive:      viper.GetBool("TAX_INCLUSIVE"),
+		ServiceChargeRate: viper.GetFloat64("SERVICE_CHARGE_RATE"),
+		ReceiptStoreName:  viper.GetString("RECEIPT_STORE_NAME"),
+		ReceiptAddress:    viper.GetString("RECEIPT_ADDRESS"),
+		ReceiptPhone:      viper.GetString("RECEIPT_PHONE"),
+		ReceiptFooter:     viper.GetString("RECEIPT_FOOTER"),
+		ReceiptWidth:      viper.GetInt("RECEIPT_PAPER_WIDTH"),
+		LoyaltyEarnPer:    viper.GetInt("LOYALTY_EARN_PER"),
+		LoyaltyPointValue: viper.GetInt("LOYALTY_POINT_VALUE"),
+		LoyaltyTiers:      viper.GetString("LOYALTY_TIERS"),
+		AlertWebhookURL:   viper.GetString("ALERT_WEBHOOK_URL"),
+		ScaleBarcodes:     viper.GetString("SCALE_BARCODES"),
+		WriteOffLimit:     viper.GetInt("WRITE_OFF_APPROVAL_LIMIT"),
+		RequireShift:      viper.GetBool("REQUIRE_OPEN_SHIFT"),
+		ManagerKey:        viper.GetString("MANAGER_KEY"),
 	}
 
 	// Init DB
@@ -69,6 +205,9 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
 
 	// Endpoint
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -85,10 +224,103 @@ func main() {
 	categoryService := service.NewCategoryService(categoryRepository)
 	categoryHandler := handler.NewCategoryHandler(categoryService)
 
-	transactionRepository := repository.NewTransactionRepository(db)
-	transactionService := service.NewTransactionService(transactionRepository)
+	// Tax config
+	taxCfg := pricing.DefaultTaxConfig()
+	taxCfg.Rate = config.TaxRate
+	taxCfg.Inclusive = config.TaxInclusive
+	taxCfg.ServiceChargeRate = config.ServiceChargeRate
+
+	// Loyalty config
+	loyaltyCfg := pricing.DefaultLoyaltyConfig()
+	loyaltyCfg.EarnPer = config.LoyaltyEarnPer
+	loyaltyCfg.PointValue = config.LoyaltyPointValue
+	if config.LoyaltyTiers != "" {
+		tiers, err := pricing.ParseTiers(config.LoyaltyTiers)
+		if err != nil {
+			log.Fatalf("Invalid LOYALTY_TIERS: %v", err)
+		}
+		loyaltyCfg.Tiers = tiers
+	}
+
+	// Scale barcode layouts
+	scaleLayouts, err := barcode.ParseScaleLayouts(config.ScaleBarcodes)
+	if err != nil {
+		log.Fatalf("Invalid SCALE_BARCODES: %v", err)
+	}
+
+	transactionRepository := repository.NewTransactionRepository(db, taxCfg, loyaltyCfg, scaleLayouts, config.RequireShift)
+	// Receipt template
+	receiptTpl := receipt.Template{
+		StoreName:  config.ReceiptStoreName,
+		Address:    config.ReceiptAddress,
+		Phone:      config.ReceiptPhone,
+		Footer:     config.ReceiptFooter,
+		PaperWidth: config.ReceiptWidth,
+	}
+	if err := receiptTpl.Validate(); err != nil {
+		log.Fatalf("Invalid RECEIPT_PAPER_WIDTH: %v", err)
+	}
+
+	outletRepository := repository.NewOutletRepository(db)
+	outletService := service.NewOutletService(outletRepository)
+	outletHandler := handler.NewOutletHandler(outletService)
+
+	supplierRepository := repository.NewSupplierRepository(db)
+	supplierService := service.NewSupplierService(supplierRepository)
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+
+	priceListRepository := repository.NewPriceListRepository(db)
+	priceListService := service.NewPriceListService(priceListRepository)
+	priceListHandler := handler.NewPriceListHandler(priceListService)
+
+	serialRepository := repository.NewSerialRepository(db)
+	serialService := service.NewSerialService(serialRepository)
+	serialHandler := handler.NewSerialHandler(serialService)
+
+	purchaseOrderRepository := repository.NewPurchaseOrderRepository(db)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderRepository, supplierRepository)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+
+	stockTransferRepository := repository.NewStockTransferRepository(db)
+	stockTransferService := service.NewStockTransferService(stockTransferRepository, outletRepository)
+	stockTransferHandler := handler.NewStockTransferHandler(stockTransferService)
+
+	writeOffRepository := repository.NewWriteOffRepository(db)
+	writeOffService := service.NewWriteOffService(writeOffRepository, outletRepository, config.WriteOffLimit)
+	writeOffHandler := handler.NewWriteOffHandler(writeOffService, config.ManagerKey)
+
+	stockTakeRepository := repository.NewStockTakeRepository(db)
+	stockTakeService := service.NewStockTakeService(stockTakeRepository)
+	stockTakeHandler := handler.NewStockTakeHandler(stockTakeService)
+
+	// Alerts always land in the notification feed, the webhook is optional
+	var alertWebhook *notify.Webhook
+	if config.AlertWebhookURL != "" {
+		alertWebhook = notify.NewWebhook(config.AlertWebhookURL)
+	}
+	notificationRepository := repository.NewNotificationRepository(db)
+	notificationService := service.NewNotificationService(notificationRepository, alertWebhook)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+
+	inventoryRepository := repository.NewInventoryRepository(db)
+	inventoryService := service.NewInventoryService(inventoryRepository, notificationService)
+	inventoryHandler := handler.NewInventoryHandler(inventoryService)
+
+	transactionService := service.NewTransactionService(transactionRepository, outletRepository, inventoryService, receiptTpl)
 	transactionHandler := handler.NewTransactionHandler(transactionService)
 
+	promotionRepository := repository.NewPromotionRepository(db)
+	promotionService := service.NewPromotionService(promotionRepository)
+	promotionHandler := handler.NewPromotionHandler(promotionService)
+
+	customerRepository := repository.NewCustomerRepository(db)
+	customerService := service.NewCustomerService(customerRepository, loyaltyCfg)
+	customerHandler := handler.NewCustomerHandler(customerService)
+
+	shiftRepository := repository.NewShiftRepository(db)
+	shiftService := service.NewShiftService(shiftRepository)
+	shiftHandler := handler.NewShiftHandler(shiftService)
+
 	// CORS config
 	corsCfg := middleware.DefaultCORSConfig()
 	if config.corsOrigins != "" {
@@ -96,38 +328,34 @@ func main() {
 	}
 
 	// Build middleware chain: Logging → APIKey → Handler
-	protectedProductHandler := middleware.Chain(
-		productHandler,
-		middleware.LoggingMiddleware,
-		func(next http.Handler) http.Handler {
-			return middleware.APIKeyMiddleware(config.APIKey, next)
-		},
-		func(next http.Handler) http.Handler {
-			return middleware.CORSMiddleware(corsCfg, next)
-		},
-	)
-
-	protectedCategoryHandler := middleware.Chain(
-		categoryHandler,
-		middleware.LoggingMiddleware,
-		func(next http.Handler) http.Handler {
-			return middleware.APIKeyMiddleware(config.APIKey, next)
-		},
-		func(next http.Handler) http.Handler {
-			return middleware.CORSMiddleware(corsCfg, next)
-		},
-	)
-
-	protectedTransactionHandler := middleware.Chain(
-		transactionHandler,
-		middleware.LoggingMiddleware,
-		func(next http.Handler) http.Handler {
-			return middleware.APIKeyMiddleware(config.APIKey, next)
-		},
-		func(next http.Handler) http.Handler {
-			return middleware.CORSMiddleware(corsCfg, next)
-		},
-	)
+	protect := func(h http.Handler) http.Handler {
+		return middleware.Chain(
+			h,
+			middleware.LoggingMiddleware,
+			func(next http.Handler) http.Handler {
+				return middleware.APIKeyMiddleware(config.APIKey, next)
+			},
+			func(next http.Handler) http.Handler {
+				return middleware.CORSMiddleware(corsCfg, next)
+			},
+		)
+	}
+	protectedProductHandler := protect(productHandler)
+	protectedCategoryHandler := protect(categoryHandler)
+	protectedTransactionHandler := protect(transactionHandler)
+	protectedPromotionHandler := protect(promotionHandler)
+	protectedCustomerHandler := protect(customerHandler)
+	protectedShiftHandler := protect(shiftHandler)
+	protectedOutletHandler := protect(outletHandler)
+	protectedSupplierHandler := protect(supplierHandler)
+	protectedPriceListHandler := protect(priceListHandler)
+	protectedSerialHandler := protect(serialHandler)
+	protectedPurchaseOrderHandler := protect(purchaseOrderHandler)
+	protectedStockTakeHandler := protect(stockTakeHandler)
+	protectedStockTransferHandler := protect(stockTransferHandler)
+	protectedWriteOffHandler := protect(writeOffHandler)
+	protectedInventoryHandler := protect(inventoryHandler)
+	protectedNotificationHandler := protect(notificationHandler)
 
 	// Handler
 	http.Handle("/api/v1/product", productHandler)
@@ -135,8 +363,37 @@ func main() {
 	http.Handle("/api/v1/category", categoryHandler)
 	http.Handle("/api/v1/category/", protectedCategoryHandler)
 	http.Handle("/api/v1/checkout", protectedTransactionHandler)
+	http.Handle("/api/v1/transactions/", protectedTransactionHandler)
 	http.Handle("/api/v1/report", transactionHandler)
 	http.Handle("/api/v1/report/today", transactionHandler)
+	http.Handle("/api/v1/report/promotions", protectedTransactionHandler)
+	http.Handle("/api/v1/report/tax", protectedTransactionHandler)
+	http.Handle("/api/v1/report/products", protectedTransactionHandler)
+	http.Handle("/api/v1/report/write-offs", protectedWriteOffHandler)
+	http.Handle("/api/v1/promotion", protectedPromotionHandler)
+	http.Handle("/api/v1/promotion/", protectedPromotionHandler)
+	http.Handle("/api/v1/customer", protectedCustomerHandler)
+	http.Handle("/api/v1/customer/", protectedCustomerHandler)
+	http.Handle("/api/v1/shift/", protectedShiftHandler)
+	http.Handle("/api/v1/outlet", protectedOutletHandler)
+	http.Handle("/api/v1/outlet/", protectedOutletHandler)
+	http.Handle("/api/v1/supplier", protectedSupplierHandler)
+	http.Handle("/api/v1/supplier/", protectedSupplierHandler)
+	http.Handle("/api/v1/price-list", protectedPriceListHandler)
+	http.Handle("/api/v1/price-list/", protectedPriceListHandler)
+	http.Handle("/api/v1/serials", protectedSerialHandler)
+	http.Handle("/api/v1/serials/", protectedSerialHandler)
+	http.Handle("/api/v1/purchase-order", protectedPurchaseOrderHandler)
+	http.Handle("/api/v1/purchase-order/", protectedPurchaseOrderHandler)
+	http.Handle("/api/v1/stock-take", protectedStockTakeHandler)
+	http.Handle("/api/v1/stock-take/", protectedStockTakeHandler)
+	http.Handle("/api/v1/stock-transfer", protectedStockTransferHandler)
+	http.Handle("/api/v1/stock-transfer/", protectedStockTransferHandler)
+	http.Handle("/api/v1/write-off", protectedWriteOffHandler)
+	http.Handle("/api/v1/write-off/", protectedWriteOffHandler)
+	http.Handle("/api/v1/inventory/", protectedInventoryHandler)
+	http.Handle("/api/v1/notifications", protectedNotificationHandler)
+	http.Handle("/api/v1/notifications/", protectedNotificationHandler)
 
 	// Health check
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
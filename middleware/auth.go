@@ -32,6 +32,12 @@ func APIKeyMiddleware(validKey string, next http.Handler) http.Handler {
 	})
 }
 
+// ManagerKey reports whether the request carries the manager key in the
+// X-Manager-Key header. Without a manager key configured nobody has one.
+func ManagerKey(r *http.Request, managerKey string) bool {
+	return managerKey != "" && validateKey(r.Header.Get("X-Manager-Key"), managerKey)
+}
+
 // validateKey does a constant-time comparison to prevent timing attacks.
 func validateKey(provided, valid string) bool {
 	if len(provided) != len(valid) {
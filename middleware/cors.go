@@ -17,7 +17,7 @@ func DefaultCORSConfig() CORSConfig {
 	return CORSConfig{
 		AllowedOrigins: []string{"http://localhost:3000"},
 		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key", "X-Manager-Key"},
 		MaxAge:         "86400", // 24 hours
 	}
 }
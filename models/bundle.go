@@ -0,0 +1,51 @@
+package models
+
+import "errors"
+
+// BundleComponent is a product and the quantity of it one bundle holds.
+// Stock is the component's stock where availability was asked for.
+type BundleComponent struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	Quantity    Quantity `json:"quantity"`
+	Stock       Quantity `json:"stock"`
+}
+
+// Bundle holds no stock of its own. Available is how many bundles the
+// component stock is enough for.
+type Bundle struct {
+	ProductID  int               `json:"product_id"`
+	Name       string            `json:"name"`
+	Available  int               `json:"available"`
+	Components []BundleComponent `json:"components"`
+}
+
+// DetailComponent is the component stock a sold bundle line took, with the
+// share of the line's revenue it stands for and its cost.
+type DetailComponent struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	Quantity    Quantity `json:"quantity"`
+	Revenue     int      `json:"revenue"`
+	Cost        int      `json:"cost"`
+}
+
+// SetBundleRequest replaces the component list. An empty list turns the
+// bundle back into a normal product.
+type SetBundleRequest struct {
+	Components []BundleComponent `json:"components"`
+}
+
+func (b *SetBundleRequest) Validate() error {
+	seen := make(map[int]bool)
+	for _, c := range b.Components {
+		if c.ProductID == 0 || c.Quantity <= 0 {
+			return errors.New("Each component needs product_id and a positive quantity")
+		}
+		if seen[c.ProductID] {
+			return errors.New("Each component can only be listed once")
+		}
+		seen[c.ProductID] = true
+	}
+	return nil
+}
@@ -3,33 +3,48 @@ package models
 import "errors"
 
 type Category struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Description string    `json:"description"`
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	TaxRate      *float64 `json:"tax_rate"`
+	TaxExempt    bool     `json:"tax_exempt"`
+	TaxInclusive *bool    `json:"tax_inclusive"`
 }
 
 type CreateCategoryRequest struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Description string    `json:"description"`
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	TaxRate      *float64 `json:"tax_rate"`
+	TaxExempt    bool     `json:"tax_exempt"`
+	TaxInclusive *bool    `json:"tax_inclusive"`
 }
 
 type UpdateCategoryRequest struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Description string    `json:"description"`
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	TaxRate      *float64 `json:"tax_rate"`
+	TaxExempt    bool     `json:"tax_exempt"`
+	TaxInclusive *bool    `json:"tax_inclusive"`
 }
 
 type PatchCategoryRequest struct {
-	ID    *int    `json:"id,omitempty"`
-	Name  *string `json:"name,omitempty"`
-	Description *string    `json:"description,omitempty"`
+	ID           *int     `json:"id,omitempty"`
+	Name         *string  `json:"name,omitempty"`
+	Description  *string  `json:"description,omitempty"`
+	TaxRate      *float64 `json:"tax_rate,omitempty"`
+	TaxExempt    *bool    `json:"tax_exempt,omitempty"`
+	TaxInclusive *bool    `json:"tax_inclusive,omitempty"`
 }
 
 func (p *CreateCategoryRequest) Validate() error {
 	if p.Name == "" || p.Description == "" {
 		return errors.New("Name and description are required")
 	}
+	if !validTaxRate(p.TaxRate) {
+		return errors.New("Tax rate must be between 0 and 100")
+	}
 	return nil
 }
 
@@ -37,5 +52,15 @@ func (p *UpdateCategoryRequest) Validate() error {
 	if p.Name == "" || p.Description == "" {
 		return errors.New("Name and description are required")
 	}
+	if !validTaxRate(p.TaxRate) {
+		return errors.New("Tax rate must be between 0 and 100")
+	}
+	return nil
+}
+
+func (p *PatchCategoryRequest) Validate() error {
+	if !validTaxRate(p.TaxRate) {
+		return errors.New("Tax rate must be between 0 and 100")
+	}
 	return nil
 }
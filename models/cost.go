@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+// Costing methods of a product. A moving average product folds each
+// delivery into one average cost; a FIFO product keeps a cost layer per
+// delivery and sells the oldest layers first.
+const (
+	CostAverage = "average"
+	CostFIFO    = "fifo"
+)
+
+func validCostMethod(method *string, cost int) error {
+	if *method == "" {
+		*method = CostAverage
+	}
+	if *method != CostAverage && *method != CostFIFO {
+		return errors.New("cost_method must be average or fifo")
+	}
+	if cost < 0 {
+		return errors.New("Cost cannot be negative")
+	}
+	return nil
+}
+
+// Margin is profit as a percentage of revenue, rounded to two decimals.
+func Margin(profit, revenue int) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(profit)*10000/float64(revenue)) / 100
+}
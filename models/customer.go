@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrCustomerInUse is returned when deleting a customer who has
+// transactions; their purchase history has to stay.
+var ErrCustomerInUse = errors.New("Customer has transactions and cannot be deleted")
+
+type Customer struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+	Tier  string `json:"tier"`
+	// Group, e.g. reseller, decides which price list the customer buys at.
+	Group      string    `json:"group"`
+	Points     int       `json:"points"`
+	TotalSpent int       `json:"total_spent"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateCustomerRequest struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+	Group string `json:"group"`
+}
+
+type UpdateCustomerRequest struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+	Group string `json:"group"`
+}
+
+type PatchCustomerRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Phone *string `json:"phone,omitempty"`
+	Email *string `json:"email,omitempty"`
+	Group *string `json:"group,omitempty"`
+}
+
+// PointEntry is one line of a customer's points ledger. Earned points are
+// positive, redeemed or reversed points negative.
+type PointEntry struct {
+	ID            int       `json:"id"`
+	CustomerID    int       `json:"customer_id"`
+	TransactionID *int      `json:"transaction_id"`
+	Points        int       `json:"points"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+const (
+	PointsEarn   = "earn"
+	PointsRedeem = "redeem"
+	PointsRefund = "refund"
+)
+
+func (c *CreateCustomerRequest) Validate() error {
+	if c.Name == "" || (c.Phone == "" && c.Email == "") {
+		return errors.New("Name and phone or email are required")
+	}
+	return nil
+}
+
+func (c *UpdateCustomerRequest) Validate() error {
+	if c.Name == "" || (c.Phone == "" && c.Email == "") {
+		return errors.New("Name and phone or email are required")
+	}
+	return nil
+}
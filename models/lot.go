@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// DateLayout is how expiry dates are written, e.g. 2026-03-31.
+const DateLayout = "2006-01-02"
+
+// StockLot is a batch of a product at an outlet. A product is sold from its
+// lots once it has any, first expiring first out; stock that no lot
+// accounts for, such as stock from before lots were tracked, is sold after
+// the lots. A lot can be sold up to and including its expiry date.
+type StockLot struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	OutletID    *int      `json:"outlet_id"`
+	BatchNumber string    `json:"batch_number"`
+	ExpiryDate  string    `json:"expiry_date,omitempty"`
+	Quantity    Quantity  `json:"quantity"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
+// ProductLots lists the lots of a product together with the stock no lot
+// accounts for.
+type ProductLots struct {
+	ProductID int        `json:"product_id"`
+	Name      string     `json:"name"`
+	Stock     Quantity   `json:"stock"`
+	Unlotted  Quantity   `json:"unlotted"`
+	Lots      []StockLot `json:"lots"`
+}
+
+// DetailLot is how much of a lot a transaction or transfer line took, or
+// gave back when negative on a refund line.
+type DetailLot struct {
+	LotID       int      `json:"lot_id"`
+	ProductID   int      `json:"product_id"`
+	BatchNumber string   `json:"batch_number"`
+	ExpiryDate  string   `json:"expiry_date,omitempty"`
+	Quantity    Quantity `json:"quantity"`
+}
+
+// ExpiringLot is a lot in stock that expires within the requested number
+// of days, or already has when DaysLeft is negative. Value is the lot at
+// the product's cost.
+type ExpiringLot struct {
+	StockLot
+	DaysLeft int  `json:"days_left"`
+	Expired  bool `json:"expired"`
+	Value    int  `json:"value"`
+}
+
+// validLot checks the batch number and expiry date given for a lot. A lot
+// needs a batch number; the expiry date is optional.
+func validLot(batch, expiry string) error {
+	if batch == "" {
+		if expiry != "" {
+			return errors.New("expiry_date needs a batch_number")
+		}
+		return nil
+	}
+	if len(batch) > 50 {
+		return errors.New("batch_number can be at most 50 characters")
+	}
+	if expiry != "" {
+		if _, err := time.Parse(DateLayout, expiry); err != nil {
+			return errors.New("expiry_date must be a date like 2026-03-31")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const NotificationLowStock = "low_stock"
+
+// Notification is an entry of the in-app feed. The same payload is posted
+// to the alert webhook when one is configured.
+type Notification struct {
+	ID        int             `json:"id"`
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import "errors"
+
+type Outlet struct {
+	ID            int    `json:"id"`
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Address       string `json:"address"`
+	Phone         string `json:"phone"`
+	ReceiptFooter string `json:"receipt_footer"`
+	PaperWidth    int    `json:"paper_width"`
+	// IsDefault marks the outlet stock is kept at when a request names none.
+	IsDefault bool `json:"is_default"`
+}
+
+// ErrOutletInUse is returned when deleting the default outlet or one that
+// still holds stock or has transactions.
+var ErrOutletInUse = errors.New("Outlet is the default outlet, holds stock or has transactions")
+
+type OutletRequest struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Address       string `json:"address"`
+	Phone         string `json:"phone"`
+	ReceiptFooter string `json:"receipt_footer"`
+	PaperWidth    int    `json:"paper_width"`
+}
+
+// OutletStock is a product as seen from one outlet: its stock there and
+// the price charged there.
+type OutletStock struct {
+	ProductID     int      `json:"product_id"`
+	ProductName   string   `json:"product_name"`
+	Stock         Quantity `json:"stock"`
+	Price         int      `json:"price"`
+	PriceOverride *int     `json:"price_override"`
+}
+
+type SetOutletStockRequest struct {
+	Stock     Quantity `json:"stock"`
+	UpdatedBy string   `json:"updated_by"`
+}
+
+type SetOutletPriceRequest struct {
+	Price int `json:"price"`
+}
+
+func (o *OutletRequest) Validate() error {
+	if o.Code == "" || o.Name == "" {
+		return errors.New("Code and name are required")
+	}
+	if o.PaperWidth == 0 {
+		o.PaperWidth = 80
+	}
+	if o.PaperWidth != 58 && o.PaperWidth != 80 {
+		return errors.New("Paper width must be 58 or 80")
+	}
+	return nil
+}
+
+func (s *SetOutletStockRequest) Validate() error {
+	if s.Stock < 0 {
+		return errors.New("Stock cannot be negative")
+	}
+	return nil
+}
+
+func (s *SetOutletPriceRequest) Validate() error {
+	if s.Price <= 0 {
+		return errors.New("Price must be positive")
+	}
+	return nil
+}
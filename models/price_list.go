@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Rules a sold line can be priced by, recorded on each transaction detail.
+const (
+	PriceRuleStandard  = "standard"   // the product's price
+	PriceRuleOutlet    = "outlet"     // the outlet's price override
+	PriceRuleTier      = "tier"       // a wholesale quantity break
+	PriceRulePriceList = "price_list" // the price list of the customer's group
+	PriceRuleUnit      = "unit"       // the own price of a pack or box
+	PriceRuleLabel     = "label"      // the price printed on a scale label
+)
+
+// PriceTier is a wholesale ("grosir") price per base unit for buying at
+// least MinQty of a product in one basket.
+type PriceTier struct {
+	MinQty Quantity `json:"min_qty"`
+	Price  int      `json:"price"`
+}
+
+type ProductPriceTiers struct {
+	ProductID int         `json:"product_id"`
+	Name      string      `json:"name"`
+	Price     int         `json:"price"`
+	Tiers     []PriceTier `json:"tiers"`
+}
+
+// SetPriceTiersRequest replaces the quantity breaks of a product. An empty
+// list removes them.
+type SetPriceTiersRequest struct {
+	Tiers []PriceTier `json:"tiers"`
+}
+
+// PriceList is a named set of prices for the customers of its groups, e.g.
+// resellers. A customer group belongs to at most one price list.
+type PriceList struct {
+	ID             int             `json:"id"`
+	Name           string          `json:"name"`
+	CustomerGroups []string        `json:"customer_groups"`
+	Items          []PriceListItem `json:"items"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type PriceListItem struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Price       int    `json:"price"`
+}
+
+type PriceListRequest struct {
+	Name           string          `json:"name"`
+	CustomerGroups []string        `json:"customer_groups"`
+	Items          []PriceListItem `json:"items"`
+}
+
+var ErrDuplicateCustomerGroup = errors.New("Customer group is already assigned to another price list")
+
+func (s *SetPriceTiersRequest) Validate() error {
+	seen := make(map[Quantity]bool)
+	for _, t := range s.Tiers {
+		if t.MinQty <= 0 || t.Price <= 0 {
+			return errors.New("Each tier needs a positive min_qty and price")
+		}
+		if seen[t.MinQty] {
+			return errors.New("Each min_qty can only be listed once")
+		}
+		seen[t.MinQty] = true
+	}
+	return nil
+}
+
+func (p *PriceListRequest) Validate() error {
+	if p.Name == "" {
+		return errors.New("Name is required")
+	}
+	groups := make(map[string]bool)
+	for _, g := range p.CustomerGroups {
+		if g == "" || groups[g] {
+			return errors.New("Customer groups must be named and listed once")
+		}
+		groups[g] = true
+	}
+	products := make(map[int]bool)
+	for _, item := range p.Items {
+		if item.ProductID == 0 || item.Price <= 0 {
+			return errors.New("Each item needs product_id and a positive price")
+		}
+		if products[item.ProductID] {
+			return errors.New("Each product can only be listed once")
+		}
+		products[item.ProductID] = true
+	}
+	return nil
+}
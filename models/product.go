@@ -1,47 +1,347 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"gokasir-api/barcode"
+	"strings"
+)
 
 type Product struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Price int    `json:"price"`
-	Stock int    `json:"stock"`
-	Category_ID int `json:"category_id"`
-	Category_Name string `json:"category_name"`
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	Price         int      `json:"price"`
+	Stock         Quantity `json:"stock"`
+	Category_ID   int      `json:"category_id"`
+	Category_Name string   `json:"category_name"`
+	TaxRate       *float64 `json:"tax_rate"`
+	TaxExempt     bool     `json:"tax_exempt"`
+	// TaxInclusive says whether Price already includes tax, falling back to
+	// the category and then the global setting when nil.
+	TaxInclusive *bool    `json:"tax_inclusive"`
+	MinStock     Quantity `json:"min_stock"`
+	ReorderQty   Quantity `json:"reorder_qty"`
+	SKU          string   `json:"sku"`
+	// PLU is the item code a deli scale prints into its barcode labels.
+	PLU string `json:"plu"`
+	// IsBundle products are sold from the stock of their components; Stock
+	// then shows how many bundles that stock is enough for.
+	IsBundle bool `json:"is_bundle"`
+	// IsIngredient products are stocked in Unit (e.g. g, ml) for recipes and
+	// cannot be sold themselves.
+	IsIngredient bool   `json:"is_ingredient"`
+	Unit         string `json:"unit"`
+	// Precision is the number of decimals a quantity may have, 0 for
+	// products sold by the piece up to 3 for e.g. 0.375 kg.
+	Precision int `json:"precision"`
+	// Cost is what one base unit cost us, CostMethod how receipts update it.
+	Cost       int    `json:"cost"`
+	CostMethod string `json:"cost_method"`
+	// Serialized products have a serial number per unit that is registered
+	// on receiving and picked at checkout. WarrantyMonths runs from the sale.
+	Serialized     bool `json:"serialized"`
+	WarrantyMonths int  `json:"warranty_months"`
+	// Barcodes are unique across all products, a product may have several.
+	Barcodes []ProductBarcode `json:"barcodes"`
+	// ParentID is set on variants. A parent lists its option dimensions in
+	// Options, each variant its own choice per dimension in OptionValues.
+	ParentID     *int              `json:"parent_id"`
+	Options      []ProductOption   `json:"options,omitempty"`
+	OptionValues map[string]string `json:"option_values,omitempty"`
+	Variants     []Product         `json:"variants,omitempty"`
+	// Retired variants belong to an option combination that is no longer
+	// generated. They keep their history but cannot be sold.
+	Retired bool `json:"retired"`
+}
+
+// DefaultUnit is the unit of products counted in pieces.
+const DefaultUnit = "pcs"
+
+type ProductBarcode struct {
+	Code string `json:"code"`
+	Type string `json:"type"`
+}
+
+var (
+	ErrDuplicateSKU     = errors.New("SKU is already used by another product")
+	ErrDuplicateBarcode = errors.New("Barcode is already used by another product")
+	ErrDuplicatePLU     = errors.New("PLU is already used by another product")
+)
+
+// ProductOption is one dimension a parent product varies in, e.g. size.
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// GenerateVariantsRequest creates one variant per combination of option
+// values. Price defaults to the parent's price and SKUPrefix to the parent's
+// SKU.
+type GenerateVariantsRequest struct {
+	Options   []ProductOption `json:"options"`
+	Price     int             `json:"price"`
+	SKUPrefix string          `json:"sku_prefix"`
 }
 
 type CreateProductRequest struct {
-	Name  string `json:"name"`
-	Price int    `json:"price"`
-	Stock int    `json:"stock"`
-	Category_ID int `json:"category_id"`
+	Name           string           `json:"name"`
+	Price          int              `json:"price"`
+	Stock          Quantity         `json:"stock"`
+	Category_ID    int              `json:"category_id"`
+	TaxRate        *float64         `json:"tax_rate"`
+	TaxExempt      bool             `json:"tax_exempt"`
+	TaxInclusive   *bool            `json:"tax_inclusive"`
+	MinStock       Quantity         `json:"min_stock"`
+	ReorderQty     Quantity         `json:"reorder_qty"`
+	SKU            string           `json:"sku"`
+	PLU            string           `json:"plu"`
+	Barcodes       []ProductBarcode `json:"barcodes"`
+	IsIngredient   bool             `json:"is_ingredient"`
+	Unit           string           `json:"unit"`
+	Precision      int              `json:"precision"`
+	Cost           int              `json:"cost"`
+	CostMethod     string           `json:"cost_method"`
+	Serialized     bool             `json:"serialized"`
+	WarrantyMonths int              `json:"warranty_months"`
 }
 
 type UpdateProductRequest struct {
-	Name  string `json:"name"`
-	Price int    `json:"price"`
-	Stock int    `json:"stock"`
-	Category_ID int `json:"category_id"`
+	Name         string           `json:"name"`
+	Price        int              `json:"price"`
+	Stock        Quantity         `json:"stock"`
+	Category_ID  int              `json:"category_id"`
+	TaxRate      *float64         `json:"tax_rate"`
+	TaxExempt    bool             `json:"tax_exempt"`
+	TaxInclusive *bool            `json:"tax_inclusive"`
+	MinStock     Quantity         `json:"min_stock"`
+	ReorderQty   Quantity         `json:"reorder_qty"`
+	SKU          string           `json:"sku"`
+	PLU          string           `json:"plu"`
+	Barcodes     []ProductBarcode `json:"barcodes"`
+	IsIngredient bool             `json:"is_ingredient"`
+	Unit         string           `json:"unit"`
+	Precision    int              `json:"precision"`
+	// Cost and CostMethod are kept as they are when left out.
+	Cost           *int    `json:"cost,omitempty"`
+	CostMethod     *string `json:"cost_method,omitempty"`
+	Serialized     bool    `json:"serialized"`
+	WarrantyMonths int     `json:"warranty_months"`
 }
 
 type PatchProductRequest struct {
-	Name  *string `json:"name,omitempty"`
-	Price *int    `json:"price,omitempty"`
-	Stock *int    `json:"stock,omitempty"`
-	Category_ID *int `json:"category_id,omitempty"`
+	Name         *string   `json:"name,omitempty"`
+	Price        *int      `json:"price,omitempty"`
+	Stock        *Quantity `json:"stock,omitempty"`
+	Category_ID  *int      `json:"category_id,omitempty"`
+	TaxRate      *float64  `json:"tax_rate,omitempty"`
+	TaxExempt    *bool     `json:"tax_exempt,omitempty"`
+	TaxInclusive *bool     `json:"tax_inclusive,omitempty"`
+	MinStock     *Quantity `json:"min_stock,omitempty"`
+	ReorderQty   *Quantity `json:"reorder_qty,omitempty"`
+	SKU          *string   `json:"sku,omitempty"`
+	PLU          *string   `json:"plu,omitempty"`
+	// Barcodes replaces every barcode of the product when set.
+	Barcodes       *[]ProductBarcode `json:"barcodes,omitempty"`
+	IsIngredient   *bool             `json:"is_ingredient,omitempty"`
+	Unit           *string           `json:"unit,omitempty"`
+	Precision      *int              `json:"precision,omitempty"`
+	Cost           *int              `json:"cost,omitempty"`
+	CostMethod     *string           `json:"cost_method,omitempty"`
+	Serialized     *bool             `json:"serialized,omitempty"`
+	WarrantyMonths *int              `json:"warranty_months,omitempty"`
+	// UpdatedBy is recorded on the stock ledger when Stock changes.
+	UpdatedBy string `json:"updated_by,omitempty"`
+}
+
+func validTaxRate(rate *float64) bool {
+	return rate == nil || (*rate >= 0 && *rate <= 100)
+}
+
+// validPrecision checks the precision is 0 to 3 decimals and q fits in it.
+func validPrecision(precision int, q Quantity) error {
+	if precision < 0 || precision > 3 {
+		return errors.New("Precision must be between 0 and 3")
+	}
+	if q.Decimals() > precision {
+		return fmt.Errorf("Quantity %s has more than %d decimals", q, precision)
+	}
+	return nil
+}
+
+// maxPLUDigits is the widest PLU the default scale layouts can encode.
+const maxPLUDigits = 5
+
+// validPLU checks a PLU and stores it without leading zeros, the way a
+// scale label's PLU is looked up.
+func validPLU(plu *string) error {
+	for _, c := range *plu {
+		if c < '0' || c > '9' {
+			return errors.New("PLU must be digits only")
+		}
+	}
+	if *plu == "" {
+		return nil
+	}
+	*plu = strings.TrimLeft(*plu, "0")
+	if *plu == "" {
+		return errors.New("PLU cannot be all zeros")
+	}
+	if len(*plu) > maxPLUDigits {
+		return fmt.Errorf("PLU cannot be longer than %d digits", maxPLUDigits)
+	}
+	return nil
+}
+
+func validReorder(minStock, reorderQty Quantity) error {
+	if minStock < 0 || reorderQty < 0 {
+		return errors.New("min_stock and reorder_qty cannot be negative")
+	}
+	return nil
+}
+
+// validBarcodes fills in a missing type from the code itself, checks every
+// barcode against its type and turns it into its canonical form.
+func validBarcodes(codes []ProductBarcode) error {
+	seen := make(map[string]bool)
+	for i := range codes {
+		c := &codes[i]
+		if c.Type == "" {
+			c.Type = barcode.Detect(c.Code)
+		}
+		if err := barcode.Validate(c.Code, c.Type); err != nil {
+			return err
+		}
+		c.Code, c.Type = barcode.Canonical(c.Code, c.Type)
+		if seen[c.Code] {
+			return ErrDuplicateBarcode
+		}
+		seen[c.Code] = true
+	}
+	return nil
 }
 
 func (p *CreateProductRequest) Validate() error {
 	if p.Name == "" || p.Price == 0 || p.Stock == 0 || p.Category_ID == 0 {
 		return errors.New("Name, price, stock and category_id are required")
 	}
-	return nil
+	if !validTaxRate(p.TaxRate) {
+		return errors.New("Tax rate must be between 0 and 100")
+	}
+	if err := validBarcodes(p.Barcodes); err != nil {
+		return err
+	}
+	if err := validPLU(&p.PLU); err != nil {
+		return err
+	}
+	if p.Unit == "" {
+		p.Unit = DefaultUnit
+	}
+	if err := validPrecision(p.Precision, p.Stock); err != nil {
+		return err
+	}
+	if err := validCostMethod(&p.CostMethod, p.Cost); err != nil {
+		return err
+	}
+	if err := validSerialized(p.Serialized, p.Precision, p.WarrantyMonths); err != nil {
+		return err
+	}
+	return validReorder(p.MinStock, p.ReorderQty)
 }
 
 func (p *UpdateProductRequest) Validate() error {
-	if p.Name == "" || p.Price == 0 || p.Stock == 0 || p.Category_ID == 0{
+	if p.Name == "" || p.Price == 0 || p.Stock == 0 || p.Category_ID == 0 {
 		return errors.New("Name, price, stock and category_id are required")
 	}
+	if !validTaxRate(p.TaxRate) {
+		return errors.New("Tax rate must be between 0 and 100")
+	}
+	if err := validBarcodes(p.Barcodes); err != nil {
+		return err
+	}
+	if err := validPLU(&p.PLU); err != nil {
+		return err
+	}
+	if p.Unit == "" {
+		p.Unit = DefaultUnit
+	}
+	if err := validPrecision(p.Precision, p.Stock); err != nil {
+		return err
+	}
+	if p.Cost != nil && *p.Cost < 0 {
+		return errors.New("Cost cannot be negative")
+	}
+	if p.CostMethod != nil {
+		if err := validCostMethod(p.CostMethod, 0); err != nil {
+			return err
+		}
+	}
+	if err := validSerialized(p.Serialized, p.Precision, p.WarrantyMonths); err != nil {
+		return err
+	}
+	return validReorder(p.MinStock, p.ReorderQty)
+}
+
+func (g *GenerateVariantsRequest) Validate() error {
+	if len(g.Options) == 0 {
+		return errors.New("Options are required")
+	}
+	for _, o := range g.Options {
+		if o.Name == "" || len(o.Values) == 0 {
+			return errors.New("Each option needs a name and values")
+		}
+	}
+	if g.Price < 0 {
+		return errors.New("Price cannot be negative")
+	}
 	return nil
 }
+
+func (p *PatchProductRequest) Validate() error {
+	if !validTaxRate(p.TaxRate) {
+		return errors.New("Tax rate must be between 0 and 100")
+	}
+	if (p.MinStock != nil && *p.MinStock < 0) || (p.ReorderQty != nil && *p.ReorderQty < 0) {
+		return errors.New("min_stock and reorder_qty cannot be negative")
+	}
+	if p.PLU != nil {
+		if err := validPLU(p.PLU); err != nil {
+			return err
+		}
+	}
+	if p.Unit != nil && *p.Unit == "" {
+		return errors.New("Unit cannot be empty")
+	}
+	if p.Precision != nil {
+		if err := validPrecision(*p.Precision, 0); err != nil {
+			return err
+		}
+	}
+	if p.Cost != nil && *p.Cost < 0 {
+		return errors.New("Cost cannot be negative")
+	}
+	if p.CostMethod != nil {
+		if err := validCostMethod(p.CostMethod, 0); err != nil {
+			return err
+		}
+	}
+	if p.Barcodes != nil {
+		return validBarcodes(*p.Barcodes)
+	}
+	return nil
+}
+
+// ValidateFor checks the serial settings of the patch against the product
+// it changes, taking what the patch leaves out from the product.
+func (p *PatchProductRequest) ValidateFor(current *Product) error {
+	serialized, precision, warrantyMonths := current.Serialized, current.Precision, current.WarrantyMonths
+	if p.Serialized != nil {
+		serialized = *p.Serialized
+	}
+	if p.Precision != nil {
+		precision = *p.Precision
+	}
+	if p.WarrantyMonths != nil {
+		warrantyMonths = *p.WarrantyMonths
+	}
+	return validSerialized(serialized, precision, warrantyMonths)
+}
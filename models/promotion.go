@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	PromoPercentage  = "percentage"   // Value percent off matching products
+	PromoBuyXGetY    = "buy_x_get_y"  // buy BuyQty, get GetQty of the same product free
+	PromoFixedAmount = "fixed_amount" // Value rupiah off when the basket reaches MinPurchase
+	PromoBundlePrice = "bundle_price" // ProductIDs for Value rupiah, a product listed twice is needed twice
+)
+
+// ErrPromotionInUse is returned when deleting a promotion that discounted a
+// sale; it stays for the promotion report and can be deactivated instead.
+var ErrPromotionInUse = errors.New("Promotion has been used on transactions, deactivate it instead")
+
+type Promotion struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Value       int       `json:"value"`
+	CategoryID  *int      `json:"category_id"`
+	ProductIDs  []int     `json:"product_ids"`
+	BuyQty      int       `json:"buy_qty"`
+	GetQty      int       `json:"get_qty"`
+	MinPurchase int       `json:"min_purchase"`
+	StartAt     time.Time `json:"start_at"`
+	EndAt       time.Time `json:"end_at"`
+	Stackable   bool      `json:"stackable"`
+	Priority    int       `json:"priority"`
+	Active      bool      `json:"active"`
+}
+
+type PromotionRequest struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Value       int       `json:"value"`
+	CategoryID  *int      `json:"category_id"`
+	ProductIDs  []int     `json:"product_ids"`
+	BuyQty      int       `json:"buy_qty"`
+	GetQty      int       `json:"get_qty"`
+	MinPurchase int       `json:"min_purchase"`
+	StartAt     time.Time `json:"start_at"`
+	EndAt       time.Time `json:"end_at"`
+	Stackable   bool      `json:"stackable"`
+	Priority    int       `json:"priority"`
+	Active      bool      `json:"active"`
+}
+
+type PromotionUsage struct {
+	PromotionID   int    `json:"promotion_id"`
+	Name          string `json:"name"`
+	TimesApplied  int    `json:"times_applied"`
+	TotalDiscount int    `json:"total_discount"`
+}
+
+func (p *PromotionRequest) Validate() error {
+	if p.Name == "" {
+		return errors.New("Name is required")
+	}
+	if p.StartAt.IsZero() || p.EndAt.IsZero() || !p.EndAt.After(p.StartAt) {
+		return errors.New("start_at and end_at are required and end_at must be after start_at")
+	}
+	switch p.Type {
+	case PromoPercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return errors.New("Percentage value must be between 1 and 100")
+		}
+	case PromoBuyXGetY:
+		if p.BuyQty <= 0 || p.GetQty <= 0 {
+			return errors.New("buy_qty and get_qty are required")
+		}
+	case PromoFixedAmount:
+		if p.Value <= 0 {
+			return errors.New("Value is required")
+		}
+	case PromoBundlePrice:
+		if p.Value <= 0 || len(p.ProductIDs) < 2 {
+			return errors.New("Bundle price needs a value and at least two product_ids")
+		}
+	default:
+		return errors.New("Unknown promotion type: " + p.Type)
+	}
+	return nil
+}
+
+// ToPromotion copies the request into a Promotion.
+func (p *PromotionRequest) ToPromotion() *Promotion {
+	return &Promotion{
+		Name:        p.Name,
+		Type:        p.Type,
+		Value:       p.Value,
+		CategoryID:  p.CategoryID,
+		ProductIDs:  p.ProductIDs,
+		BuyQty:      p.BuyQty,
+		GetQty:      p.GetQty,
+		MinPurchase: p.MinPurchase,
+		StartAt:     p.StartAt,
+		EndAt:       p.EndAt,
+		Stackable:   p.Stackable,
+		Priority:    p.Priority,
+		Active:      p.Active,
+	}
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	POStatusDraft             = "draft"
+	POStatusOrdered           = "ordered"
+	POStatusPartiallyReceived = "partially_received"
+	POStatusReceived          = "received"
+	POStatusCancelled         = "cancelled"
+)
+
+// PurchaseOrder tracks goods ordered from a supplier. TotalAmount is the
+// ordered value, ReceivedAmount the value of what actually arrived at the
+// received unit cost, and Outstanding what is still owed for it.
+type PurchaseOrder struct {
+	ID             int                 `json:"id"`
+	SupplierID     int                 `json:"supplier_id"`
+	SupplierName   string              `json:"supplier_name"`
+	OutletID       *int                `json:"outlet_id"`
+	Status         string              `json:"status"`
+	Notes          string              `json:"notes"`
+	TotalAmount    int                 `json:"total_amount"`
+	ReceivedAmount int                 `json:"received_amount"`
+	PaidAmount     int                 `json:"paid_amount"`
+	Outstanding    int                 `json:"outstanding"`
+	CreatedBy      string              `json:"created_by"`
+	CreatedAt      time.Time           `json:"created_at"`
+	OrderedAt      *time.Time          `json:"ordered_at"`
+	Items          []PurchaseOrderItem `json:"items,omitempty"`
+	Receipts       []GoodsReceipt      `json:"receipts,omitempty"`
+	Payments       []SupplierPayment   `json:"payments,omitempty"`
+}
+
+type PurchaseOrderItem struct {
+	ID              int      `json:"id"`
+	PurchaseOrderID int      `json:"purchase_order_id"`
+	ProductID       int      `json:"product_id"`
+	ProductName     string   `json:"product_name"`
+	Quantity        Quantity `json:"quantity"`
+	UnitCost        int      `json:"unit_cost"`
+	ReceivedQty     Quantity `json:"received_qty"`
+	// Unit is what the line is ordered, costed and received in, Factor the
+	// number of base units in one of it.
+	Unit   string   `json:"unit"`
+	Factor Quantity `json:"factor"`
+}
+
+// GoodsReceipt is one delivery against a purchase order.
+type GoodsReceipt struct {
+	ID              int                `json:"id"`
+	PurchaseOrderID int                `json:"purchase_order_id"`
+	ReceivedBy      string             `json:"received_by"`
+	Notes           string             `json:"notes"`
+	ReceivedAt      time.Time          `json:"received_at"`
+	Items           []GoodsReceiptItem `json:"items"`
+}
+
+type GoodsReceiptItem struct {
+	ID          int      `json:"id"`
+	ItemID      int      `json:"item_id"`
+	ProductID   int      `json:"product_id"`
+	Quantity    Quantity `json:"quantity"`
+	UnitCost    int      `json:"unit_cost"`
+	LotID       *int     `json:"lot_id,omitempty"`
+	BatchNumber string   `json:"batch_number,omitempty"`
+	ExpiryDate  string   `json:"expiry_date,omitempty"`
+	Serials     []string `json:"serials,omitempty"`
+}
+
+type SupplierPayment struct {
+	ID              int       `json:"id"`
+	PurchaseOrderID int       `json:"purchase_order_id"`
+	Amount          int       `json:"amount"`
+	Method          string    `json:"method"`
+	Reference       string    `json:"reference"`
+	PaidBy          string    `json:"paid_by"`
+	PaidAt          time.Time `json:"paid_at"`
+}
+
+// PurchaseOrderItemRequest orders Quantity of the product in Unit at
+// UnitCost per Unit, the product's base unit when left out.
+type PurchaseOrderItemRequest struct {
+	ProductID int      `json:"product_id"`
+	Quantity  Quantity `json:"quantity"`
+	UnitCost  int      `json:"unit_cost"`
+	Unit      string   `json:"unit"`
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID int                        `json:"supplier_id"`
+	OutletID   *int                       `json:"outlet_id"`
+	Notes      string                     `json:"notes"`
+	CreatedBy  string                     `json:"created_by"`
+	Items      []PurchaseOrderItemRequest `json:"items"`
+}
+
+// ReceiveItem receives Quantity of a purchase order line, in the unit it
+// was ordered in. UnitCost defaults to the ordered cost when left out.
+// With a BatchNumber the goods go into that lot, which is created with
+// ExpiryDate the first time it is received. A serialised product lists
+// the serial of every base unit received in Serials.
+type ReceiveItem struct {
+	ItemID      int      `json:"item_id"`
+	Quantity    Quantity `json:"quantity"`
+	UnitCost    *int     `json:"unit_cost"`
+	BatchNumber string   `json:"batch_number,omitempty"`
+	ExpiryDate  string   `json:"expiry_date,omitempty"`
+	Serials     []string `json:"serials,omitempty"`
+}
+
+type ReceiveRequest struct {
+	ReceivedBy string        `json:"received_by"`
+	Notes      string        `json:"notes"`
+	Items      []ReceiveItem `json:"items"`
+}
+
+type SupplierPaymentRequest struct {
+	Amount    int    `json:"amount"`
+	Method    string `json:"method"`
+	Reference string `json:"reference"`
+	PaidBy    string `json:"paid_by"`
+}
+
+func (p *PurchaseOrderRequest) Validate() error {
+	if p.SupplierID == 0 {
+		return errors.New("Supplier is required")
+	}
+	if len(p.Items) == 0 {
+		return errors.New("Items are required")
+	}
+	seen := make(map[int]bool)
+	for _, item := range p.Items {
+		if item.ProductID == 0 || item.Quantity <= 0 || item.UnitCost < 0 {
+			return errors.New("Each item needs product_id, a positive quantity and unit_cost")
+		}
+		if seen[item.ProductID] {
+			return errors.New("Each product can only be listed once")
+		}
+		seen[item.ProductID] = true
+	}
+	return nil
+}
+
+func (r *ReceiveRequest) Validate() error {
+	if r.ReceivedBy == "" {
+		return errors.New("received_by is required")
+	}
+	if len(r.Items) == 0 {
+		return errors.New("Items are required")
+	}
+	for _, item := range r.Items {
+		if item.ItemID == 0 || item.Quantity <= 0 {
+			return errors.New("Each item needs item_id and a positive quantity")
+		}
+		if item.UnitCost != nil && *item.UnitCost < 0 {
+			return errors.New("Unit cost cannot be negative")
+		}
+		if err := validLot(item.BatchNumber, item.ExpiryDate); err != nil {
+			return err
+		}
+		if err := validSerials(item.Serials); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SupplierPaymentRequest) Validate() error {
+	if s.Amount <= 0 {
+		return errors.New("Amount must be positive")
+	}
+	if s.Method == "" {
+		s.Method = PaymentTransfer
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QuantityScale is the number of thousandths in one unit. Quantities are
+// stored as NUMERIC(14,3), so three decimals is the finest precision.
+const QuantityScale = 1000
+
+// Quantity is an exact decimal amount of a product in thousandths of its
+// unit, e.g. 375 for 0.375 kg. Adding and comparing quantities is plain
+// integer arithmetic; multiply with Mul, never with *.
+type Quantity int64
+
+// Qty returns n whole units.
+func Qty(n int) Quantity {
+	return Quantity(n) * QuantityScale
+}
+
+// ParseQuantity reads a decimal such as "0.375" without going through a
+// float.
+func ParseQuantity(s string) (Quantity, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	whole, frac, _ := strings.Cut(s, ".")
+	frac = strings.TrimRight(frac, "0")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("Invalid quantity %q", s)
+	}
+	if len(frac) > 3 {
+		return 0, fmt.Errorf("Quantity %s has more than 3 decimals", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid quantity %q", s)
+	}
+	f := int64(0)
+	if frac != "" {
+		if f, err = strconv.ParseInt(frac+strings.Repeat("0", 3-len(frac)), 10, 64); err != nil {
+			return 0, fmt.Errorf("Invalid quantity %q", s)
+		}
+	}
+	q := Quantity(w*QuantityScale + f)
+	if neg {
+		q = -q
+	}
+	return q, nil
+}
+
+func (q Quantity) String() string {
+	sign := ""
+	if q < 0 {
+		sign, q = "-", -q
+	}
+	s := strconv.FormatInt(int64(q/QuantityScale), 10)
+	if frac := q % QuantityScale; frac != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%03d", frac), "0")
+	}
+	return sign + s
+}
+
+// Whole returns the number of whole units, dropping any fraction.
+func (q Quantity) Whole() int {
+	return int(q / QuantityScale)
+}
+
+// Decimals returns how many decimals q needs, 0 to 3.
+func (q Quantity) Decimals() int {
+	frac := q % QuantityScale
+	if frac < 0 {
+		frac = -frac
+	}
+	if frac == 0 {
+		return 0
+	}
+	d := 3
+	for frac%10 == 0 {
+		frac /= 10
+		d--
+	}
+	return d
+}
+
+// Mul multiplies two quantities, rounding half away from zero to the
+// nearest thousandth.
+func (q Quantity) Mul(o Quantity) Quantity {
+	return Quantity(roundDiv(int64(q)*int64(o), QuantityScale))
+}
+
+// Amount prices q at price per unit, rounded half away from zero to a
+// whole rupiah.
+func (q Quantity) Amount(price int) int {
+	return int(roundDiv(int64(q)*int64(price), QuantityScale))
+}
+
+// Div returns how many times o fits in q, e.g. how many bundles a stock is
+// enough for.
+func (q Quantity) Div(o Quantity) int {
+	if o <= 0 {
+		return 0
+	}
+	return int(q / o)
+}
+
+func roundDiv(n, d int64) int64 {
+	if n < 0 {
+		return -((-n + d/2) / d)
+	}
+	return (n + d/2) / d
+}
+
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or string.
+func (q *Quantity) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "null" {
+		return nil
+	}
+	if strings.ContainsAny(s, "eE") {
+		return errors.New("Quantities cannot use exponents")
+	}
+	v, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q = v
+	return nil
+}
+
+func (q Quantity) Value() (driver.Value, error) {
+	return q.String(), nil
+}
+
+func (q *Quantity) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*q = 0
+		return nil
+	case int64:
+		*q = Quantity(v * QuantityScale)
+		return nil
+	case []byte:
+		return q.scanString(string(v))
+	case string:
+		return q.scanString(v)
+	}
+	return fmt.Errorf("Cannot scan %T into a quantity", src)
+}
+
+func (q *Quantity) scanString(s string) error {
+	v, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q = v
+	return nil
+}
@@ -0,0 +1,133 @@
+package models
+
+import "testing"
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Quantity
+		wantErr bool
+	}{
+		{"2", 2000, false},
+		{"0.375", 375, false},
+		{"-1.5", -1500, false},
+		{".5", 500, false},
+		{"1.2500", 1250, false},
+		{"+3", 3000, false},
+		{"1.2345", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseQuantity(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuantity(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseQuantity(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantityString(t *testing.T) {
+	tests := []struct {
+		q    Quantity
+		want string
+	}{
+		{Qty(2), "2"},
+		{1500, "1.5"},
+		{-250, "-0.25"},
+		{1, "0.001"},
+		{0, "0"},
+	}
+	for _, tt := range tests {
+		if got := tt.q.String(); got != tt.want {
+			t.Errorf("Quantity(%d).String() = %q, want %q", int64(tt.q), got, tt.want)
+		}
+	}
+}
+
+func TestQuantityMul(t *testing.T) {
+	tests := []struct {
+		name string
+		q, o Quantity
+		want Quantity
+	}{
+		{"whole units", Qty(2), Qty(3), Qty(6)},
+		{"unit factor", Qty(2), 1500, 3000},
+		{"rounds half up", 333, 1500, 500},
+		{"rounds half away from zero", -333, 1500, -500},
+		{"rounds down", 333, 1001, 333},
+		{"zero", 0, Qty(12), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Mul(tt.o); got != tt.want {
+				t.Errorf("%s.Mul(%s) = %s, want %s", tt.q, tt.o, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantityAmount(t *testing.T) {
+	tests := []struct {
+		name  string
+		q     Quantity
+		price int
+		want  int
+	}{
+		{"whole units", Qty(3), 12500, 37500},
+		{"weighed", 1500, 12345, 18518},
+		{"refund", -1500, 12345, -18518},
+		{"below half a rupiah", 1, 499, 0},
+		{"half a rupiah", 1, 500, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Amount(tt.price); got != tt.want {
+				t.Errorf("%s.Amount(%d) = %d, want %d", tt.q, tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantityDecimals(t *testing.T) {
+	tests := []struct {
+		q    Quantity
+		want int
+	}{
+		{Qty(3), 0},
+		{0, 0},
+		{1500, 1},
+		{1250, 2},
+		{1001, 3},
+		{-250, 2},
+	}
+	for _, tt := range tests {
+		if got := tt.q.Decimals(); got != tt.want {
+			t.Errorf("%s.Decimals() = %d, want %d", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestQuantityDiv(t *testing.T) {
+	tests := []struct {
+		name string
+		q, o Quantity
+		want int
+	}{
+		{"whole", Qty(7), Qty(2), 3},
+		{"fractional", 2500, 500, 5},
+		{"by zero", Qty(7), 0, 0},
+		{"by negative", Qty(7), -Qty(1), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Div(tt.o); got != tt.want {
+				t.Errorf("%s.Div(%s) = %d, want %d", tt.q, tt.o, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import "errors"
+
+// RecipeItem is the quantity of an ingredient, in the ingredient's unit,
+// that goes into one unit of a menu item.
+type RecipeItem struct {
+	IngredientID   int      `json:"ingredient_id"`
+	IngredientName string   `json:"ingredient_name"`
+	Unit           string   `json:"unit"`
+	Quantity       Quantity `json:"quantity"`
+}
+
+type Recipe struct {
+	ProductID int          `json:"product_id"`
+	Name      string       `json:"name"`
+	Items     []RecipeItem `json:"items"`
+}
+
+// SetRecipeRequest replaces the recipe of a product. An empty list removes
+// it.
+type SetRecipeRequest struct {
+	Items []RecipeItem `json:"items"`
+}
+
+func (r *SetRecipeRequest) Validate() error {
+	seen := make(map[int]bool)
+	for _, item := range r.Items {
+		if item.IngredientID == 0 || item.Quantity <= 0 {
+			return errors.New("Each item needs ingredient_id and a positive quantity")
+		}
+		if seen[item.IngredientID] {
+			return errors.New("Each ingredient can only be listed once")
+		}
+		seen[item.IngredientID] = true
+	}
+	return nil
+}
+
+// IngredientUsage compares what recipes say was used in a period with the
+// differences stock takes found. A negative variance means less was counted
+// than expected.
+type IngredientUsage struct {
+	IngredientID      int      `json:"ingredient_id"`
+	Name              string   `json:"name"`
+	Unit              string   `json:"unit"`
+	TheoreticalUsage  Quantity `json:"theoretical_usage"`
+	StockTakeVariance Quantity `json:"stock_take_variance"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// States of a serial number.
+const (
+	SerialInStock = "in_stock"
+	SerialSold    = "sold"
+	// SerialInTransit units are on their way to another outlet.
+	SerialInTransit = "in_transit"
+	// SerialMissing units were sent on a transfer and never arrived.
+	SerialMissing = "missing"
+	// SerialWrittenOff units were thrown away or used up without a sale.
+	SerialWrittenOff = "written_off"
+)
+
+// SerialNumber is one unit of a serialised product. Sale is set while the
+// unit is sold and not returned.
+type SerialNumber struct {
+	ID             int         `json:"id"`
+	Serial         string      `json:"serial"`
+	ProductID      int         `json:"product_id"`
+	ProductName    string      `json:"product_name"`
+	OutletID       *int        `json:"outlet_id"`
+	Status         string      `json:"status"`
+	GoodsReceiptID *int        `json:"goods_receipt_id,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	Sale           *SerialSale `json:"sale,omitempty"`
+}
+
+// SerialSale is the proof of purchase of a serialised unit. WarrantyExpiry
+// is the last day of the product's warranty counted from the sale, empty
+// when the product has none.
+type SerialSale struct {
+	TransactionID  int       `json:"transaction_id"`
+	DetailID       int       `json:"detail_id"`
+	SoldAt         time.Time `json:"sold_at"`
+	CustomerID     *int      `json:"customer_id"`
+	CustomerName   string    `json:"customer_name,omitempty"`
+	WarrantyMonths int       `json:"warranty_months"`
+	WarrantyExpiry string    `json:"warranty_expiry,omitempty"`
+	UnderWarranty  bool      `json:"under_warranty"`
+}
+
+// RegisterSerialsRequest registers serials for units of a product already
+// in stock at an outlet, e.g. opening stock that was not received against a
+// purchase order.
+type RegisterSerialsRequest struct {
+	ProductID int      `json:"product_id"`
+	OutletID  *int     `json:"outlet_id"`
+	Serials   []string `json:"serials"`
+}
+
+var ErrDuplicateSerial = errors.New("Serial number is already registered")
+
+// validSerialized checks the serial and warranty settings of a product. A
+// serialised product is counted in whole units.
+func validSerialized(serialized bool, precision, warrantyMonths int) error {
+	if serialized && precision != 0 {
+		return errors.New("A serialized product cannot have decimals")
+	}
+	if warrantyMonths < 0 {
+		return errors.New("warranty_months cannot be negative")
+	}
+	return nil
+}
+
+// validSerials checks a list of serials is filled in and lists each once.
+func validSerials(serials []string) error {
+	seen := make(map[string]bool)
+	for _, s := range serials {
+		if s == "" || len(s) > 100 {
+			return errors.New("Serials must be 1 to 100 characters")
+		}
+		if seen[s] {
+			return errors.New("Serial " + s + " is listed more than once")
+		}
+		seen[s] = true
+	}
+	return nil
+}
+
+func (r *RegisterSerialsRequest) Validate() error {
+	if r.ProductID == 0 || len(r.Serials) == 0 {
+		return errors.New("product_id and serials are required")
+	}
+	return validSerials(r.Serials)
+}
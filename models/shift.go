@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ShiftOpen   = "open"
+	ShiftClosed = "closed"
+
+	CashIn  = "in"
+	CashOut = "out"
+)
+
+type Shift struct {
+	ID            int         `json:"id"`
+	OutletID      *int        `json:"outlet_id"`
+	Cashier       string      `json:"cashier"`
+	Status        string      `json:"status"`
+	OpeningCash   int         `json:"opening_cash"`
+	OpenedAt      time.Time   `json:"opened_at"`
+	ClosedAt      *time.Time  `json:"closed_at"`
+	ExpectedCash  *int        `json:"expected_cash"`
+	CountedCash   *int        `json:"counted_cash"`
+	Variance      *int        `json:"variance"`
+	Denominations map[int]int `json:"denominations,omitempty"`
+	Notes         string      `json:"notes"`
+}
+
+type CashMovement struct {
+	ID        int       `json:"id"`
+	ShiftID   int       `json:"shift_id"`
+	Type      string    `json:"type"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShiftSummary reports the cash drawer of a shift. ExpectedCash is the
+// opening float plus cash taken, minus change given and cash refunded,
+// plus cash-in and minus cash-out movements.
+type ShiftSummary struct {
+	Shift            Shift          `json:"shift"`
+	CashReceived     int            `json:"cash_received"`
+	ChangeGiven      int            `json:"change_given"`
+	CashIn           int            `json:"cash_in"`
+	CashOut          int            `json:"cash_out"`
+	ExpectedCash     int            `json:"expected_cash"`
+	PaymentsByMethod map[string]int `json:"payments_by_method"`
+	Report           *Report        `json:"report"`
+	Movements        []CashMovement `json:"movements"`
+}
+
+type OpenShiftRequest struct {
+	OutletID    *int   `json:"outlet_id"`
+	Cashier     string `json:"cashier"`
+	OpeningCash int    `json:"opening_cash"`
+}
+
+type CashMovementRequest struct {
+	Type      string `json:"type"`
+	Amount    int    `json:"amount"`
+	Reason    string `json:"reason"`
+	CreatedBy string `json:"created_by"`
+}
+
+// CloseShiftRequest takes the counted cash either broken down by
+// denomination (value -> count) or as a single CountedCash amount.
+type CloseShiftRequest struct {
+	Denominations map[int]int `json:"denominations"`
+	CountedCash   *int        `json:"counted_cash"`
+	Notes         string      `json:"notes"`
+}
+
+func (o *OpenShiftRequest) Validate() error {
+	if o.Cashier == "" {
+		return errors.New("Cashier is required")
+	}
+	if o.OpeningCash < 0 {
+		return errors.New("Opening cash cannot be negative")
+	}
+	return nil
+}
+
+func (c *CashMovementRequest) Validate() error {
+	if c.Type != CashIn && c.Type != CashOut {
+		return errors.New("Type must be in or out")
+	}
+	if c.Amount <= 0 || c.Reason == "" {
+		return errors.New("Amount and reason are required")
+	}
+	return nil
+}
+
+func (c *CloseShiftRequest) Validate() error {
+	if len(c.Denominations) == 0 && c.CountedCash == nil {
+		return errors.New("Denominations or counted_cash is required")
+	}
+	for value, count := range c.Denominations {
+		if value <= 0 || count < 0 {
+			return errors.New("Denominations must be positive values with non-negative counts")
+		}
+	}
+	return nil
+}
+
+// Counted returns the cash counted in the drawer.
+func (c *CloseShiftRequest) Counted() int {
+	if len(c.Denominations) == 0 {
+		return *c.CountedCash
+	}
+	total := 0
+	for value, count := range c.Denominations {
+		total += value * count
+	}
+	return total
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Reasons a stock movement is recorded for.
+const (
+	StockSale       = "sale"
+	StockRefund     = "refund"
+	StockAdjustment = "adjustment"
+	StockReceiving  = "receiving"
+	StockTransfer   = "transfer"
+	// StockUsage is an ingredient used up by a recipe when its menu item sells.
+	StockUsage = "usage"
+	// StockWriteOff is stock thrown away or used up without being sold.
+	StockWriteOff = "write_off"
+)
+
+// Documents a stock movement can point back to.
+const (
+	RefTransaction  = "transaction"
+	RefProduct      = "product"
+	RefOutlet       = "outlet"
+	RefGoodsReceipt = "goods_receipt"
+	RefStockTake    = "stock_take"
+	RefTransfer     = "stock_transfer"
+	RefWriteOff     = "write_off"
+)
+
+// StockMovement is one entry of the append-only stock ledger. Balance is the
+// stock after the movement, at the outlet when OutletID is set and over all
+// outlets otherwise.
+type StockMovement struct {
+	ID            int       `json:"id"`
+	ProductID     int       `json:"product_id"`
+	OutletID      *int      `json:"outlet_id"`
+	Delta         Quantity  `json:"delta"`
+	Balance       Quantity  `json:"balance"`
+	Reason        string    `json:"reason"`
+	ReferenceType string    `json:"reference_type"`
+	ReferenceID   *int      `json:"reference_id"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LowStockItem is a product at or below its minimum stock. SuggestedOrder
+// is the reorder quantity, or enough to get back to the minimum when no
+// reorder quantity is set.
+type LowStockItem struct {
+	ProductID      int      `json:"product_id"`
+	ProductName    string   `json:"product_name"`
+	OutletID       *int     `json:"outlet_id"`
+	Stock          Quantity `json:"stock"`
+	MinStock       Quantity `json:"min_stock"`
+	ReorderQty     Quantity `json:"reorder_qty"`
+	SuggestedOrder Quantity `json:"suggested_order"`
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	StockTakeOpen      = "open"
+	StockTakeApproved  = "approved"
+	StockTakeCancelled = "cancelled"
+)
+
+// StockTake is a stock opname session. System quantities are snapshotted
+// when it starts; stock that moves while it is open (sales, receiving) is
+// added to the expected quantity up to the moment a product was counted.
+type StockTake struct {
+	ID         int             `json:"id"`
+	OutletID   *int            `json:"outlet_id"`
+	Status     string          `json:"status"`
+	Notes      string          `json:"notes"`
+	CreatedBy  string          `json:"created_by"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ApprovedBy string          `json:"approved_by,omitempty"`
+	ApprovedAt *time.Time      `json:"approved_at"`
+	Lines      []StockTakeLine `json:"lines,omitempty"`
+	// Totals only cover counted lines.
+	CountedLines       int      `json:"counted_lines"`
+	UncountedLines     int      `json:"uncounted_lines"`
+	TotalVarianceQty   Quantity `json:"total_variance_qty"`
+	TotalVarianceValue int      `json:"total_variance_value"`
+}
+
+// StockTakeLine compares what the system expects on the shelf with what was
+// counted. Variance is counted minus expected and is valued at UnitValue,
+// the product's cost per base unit when the session started.
+type StockTakeLine struct {
+	ProductID     int          `json:"product_id"`
+	ProductName   string       `json:"product_name"`
+	SystemQty     Quantity     `json:"system_qty"`
+	MovedQty      Quantity     `json:"moved_qty"`
+	ExpectedQty   Quantity     `json:"expected_qty"`
+	CountedQty    *Quantity    `json:"counted_qty"`
+	VarianceQty   Quantity     `json:"variance_qty"`
+	UnitValue     int          `json:"unit_value"`
+	VarianceValue int          `json:"variance_value"`
+	Counts        []StockCount `json:"counts,omitempty"`
+}
+
+// StockCount is one counter's count of a product, or of one of its lots
+// when BatchNumber is set. Quantity is in the base unit; Unit is what was
+// counted in when not the base unit. Counts from different counters or in
+// different units add up, a new count by the same counter in the same unit
+// replaces the old one.
+type StockCount struct {
+	Counter     string    `json:"counter"`
+	BatchNumber string    `json:"batch_number,omitempty"`
+	Unit        string    `json:"unit,omitempty"`
+	Quantity    Quantity  `json:"quantity"`
+	CountedAt   time.Time `json:"counted_at"`
+}
+
+type StartStockTakeRequest struct {
+	OutletID   *int   `json:"outlet_id"`
+	Notes      string `json:"notes"`
+	CreatedBy  string `json:"created_by"`
+	ProductIDs []int  `json:"product_ids"` // empty takes every product
+}
+
+// StockCountItem is a count in Unit, e.g. 3 cartons, which is recorded in
+// the product's base unit. Unit defaults to the base unit. A product kept
+// in lots is counted per BatchNumber; ExpiryDate is only needed for a batch
+// that is not in stock yet.
+type StockCountItem struct {
+	ProductID   int      `json:"product_id"`
+	Quantity    Quantity `json:"quantity"`
+	Unit        string   `json:"unit,omitempty"`
+	BatchNumber string   `json:"batch_number,omitempty"`
+	ExpiryDate  string   `json:"expiry_date,omitempty"`
+}
+
+type StockCountRequest struct {
+	Counter string           `json:"counter"`
+	Items   []StockCountItem `json:"items"`
+}
+
+type ApproveStockTakeRequest struct {
+	ApprovedBy string `json:"approved_by"`
+}
+
+func (s *StartStockTakeRequest) Validate() error {
+	if s.CreatedBy == "" {
+		return errors.New("created_by is required")
+	}
+	return nil
+}
+
+func (s *StockCountRequest) Validate() error {
+	if s.Counter == "" {
+		return errors.New("Counter is required")
+	}
+	if len(s.Items) == 0 {
+		return errors.New("Items are required")
+	}
+	for _, item := range s.Items {
+		if item.ProductID == 0 || item.Quantity < 0 {
+			return errors.New("Each item needs product_id and a quantity of zero or more")
+		}
+		if err := validLot(item.BatchNumber, item.ExpiryDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *ApproveStockTakeRequest) Validate() error {
+	if a.ApprovedBy == "" {
+		return errors.New("approved_by is required")
+	}
+	return nil
+}
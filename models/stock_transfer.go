@@ -0,0 +1,154 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	TransferDraft     = "draft"
+	TransferInTransit = "in_transit"
+	TransferReceived  = "received"
+	TransferCancelled = "cancelled"
+)
+
+// Transfer moves stock from one outlet to another. Dispatching takes
+// the stock out of the source outlet, where it stays in transit until the
+// destination receives it.
+type Transfer struct {
+	ID             int            `json:"id"`
+	FromOutletID   int            `json:"from_outlet_id"`
+	FromOutletName string         `json:"from_outlet_name"`
+	ToOutletID     int            `json:"to_outlet_id"`
+	ToOutletName   string         `json:"to_outlet_name"`
+	Status         string         `json:"status"`
+	Notes          string         `json:"notes"`
+	CreatedBy      string         `json:"created_by"`
+	CreatedAt      time.Time      `json:"created_at"`
+	DispatchedBy   string         `json:"dispatched_by,omitempty"`
+	DispatchedAt   *time.Time     `json:"dispatched_at"`
+	ReceivedBy     string         `json:"received_by,omitempty"`
+	ReceivedAt     *time.Time     `json:"received_at"`
+	Items          []TransferItem `json:"items,omitempty"`
+}
+
+// TransferItem is a line of a transfer in the product's base unit.
+// DiscrepancyQty is received minus sent, negative when goods went missing
+// on the way, and DiscrepancyValue its value at cost.
+type TransferItem struct {
+	ID                int         `json:"id"`
+	ProductID         int         `json:"product_id"`
+	ProductName       string      `json:"product_name"`
+	Quantity          Quantity    `json:"quantity"`
+	ReceivedQty       *Quantity   `json:"received_qty"`
+	DiscrepancyQty    Quantity    `json:"discrepancy_qty"`
+	DiscrepancyValue  int         `json:"discrepancy_value"`
+	DiscrepancyReason string      `json:"discrepancy_reason,omitempty"`
+	Serials           []string    `json:"serials,omitempty"`
+	ReceivedSerials   []string    `json:"received_serials,omitempty"`
+	Lots              []DetailLot `json:"lots,omitempty"`
+}
+
+// InTransitStock is how much of a product is on its way between outlets.
+type InTransitStock struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	Quantity    Quantity `json:"quantity"`
+	Transfers   int      `json:"transfers"`
+}
+
+// TransferItemRequest is a line to transfer. A serialised product
+// names the units sent in Serials.
+type TransferItemRequest struct {
+	ProductID int      `json:"product_id"`
+	Quantity  Quantity `json:"quantity"`
+	Serials   []string `json:"serials,omitempty"`
+}
+
+type TransferRequest struct {
+	FromOutletID int                   `json:"from_outlet_id"`
+	ToOutletID   int                   `json:"to_outlet_id"`
+	Notes        string                `json:"notes"`
+	CreatedBy    string                `json:"created_by"`
+	Items        []TransferItemRequest `json:"items"`
+}
+
+type DispatchTransferRequest struct {
+	DispatchedBy string `json:"dispatched_by"`
+}
+
+// ReceiveTransferItem is what arrived of a transfer line when it differs
+// from what was sent. Reason explains the difference. A serialised line
+// names the units that arrived in Serials.
+type ReceiveTransferItem struct {
+	ItemID   int      `json:"item_id"`
+	Quantity Quantity `json:"quantity"`
+	Reason   string   `json:"reason"`
+	Serials  []string `json:"serials,omitempty"`
+}
+
+// ReceiveTransferRequest receives a transfer. Lines not listed in Items
+// arrived in full.
+type ReceiveTransferRequest struct {
+	ReceivedBy string                `json:"received_by"`
+	Items      []ReceiveTransferItem `json:"items"`
+}
+
+func (s *TransferRequest) Validate() error {
+	if s.FromOutletID == 0 || s.ToOutletID == 0 {
+		return errors.New("from_outlet_id and to_outlet_id are required")
+	}
+	if s.FromOutletID == s.ToOutletID {
+		return errors.New("Stock can only be transferred to another outlet")
+	}
+	if s.CreatedBy == "" {
+		return errors.New("created_by is required")
+	}
+	if len(s.Items) == 0 {
+		return errors.New("Items are required")
+	}
+	products := make(map[int]bool)
+	for _, item := range s.Items {
+		if item.ProductID == 0 || item.Quantity <= 0 {
+			return errors.New("Each item needs product_id and a positive quantity")
+		}
+		if products[item.ProductID] {
+			return errors.New("Each product can only be listed once")
+		}
+		products[item.ProductID] = true
+		if err := validSerials(item.Serials); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DispatchTransferRequest) Validate() error {
+	if d.DispatchedBy == "" {
+		return errors.New("dispatched_by is required")
+	}
+	return nil
+}
+
+func (r *ReceiveTransferRequest) Validate() error {
+	if r.ReceivedBy == "" {
+		return errors.New("received_by is required")
+	}
+	items := make(map[int]bool)
+	for _, item := range r.Items {
+		if item.ItemID == 0 || item.Quantity < 0 {
+			return errors.New("Each item needs item_id and a quantity of zero or more")
+		}
+		if items[item.ItemID] {
+			return errors.New("Each item can only be listed once")
+		}
+		items[item.ItemID] = true
+		if len(item.Reason) > 200 {
+			return errors.New("reason can be at most 200 characters")
+		}
+		if err := validSerials(item.Serials); err != nil {
+			return err
+		}
+	}
+	return nil
+}
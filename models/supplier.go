@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type Supplier struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	ContactName string `json:"contact_name"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	Address     string `json:"address"`
+	// Outstanding is what we owe the supplier for goods received and not yet paid.
+	Outstanding int       `json:"outstanding"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type SupplierRequest struct {
+	Name        string `json:"name"`
+	ContactName string `json:"contact_name"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	Address     string `json:"address"`
+}
+
+func (s *SupplierRequest) Validate() error {
+	if s.Name == "" {
+		return errors.New("Name is required")
+	}
+	return nil
+}
@@ -1,41 +1,280 @@
 package models
 
 import (
+	"errors"
 	"time"
 )
 
+const (
+	PaymentCash      = "cash"
+	PaymentDebitCard = "debit_card"
+	PaymentQRIS      = "qris"
+	PaymentEWallet   = "e_wallet"
+	PaymentTransfer  = "transfer"
+	// PaymentPoints is recorded by checkout for redeemed loyalty points and
+	// cannot be sent as a payment by the client.
+	PaymentPoints = "points"
+)
+
+const (
+	TransactionSale   = "sale"
+	TransactionVoid   = "void"
+	TransactionRefund = "refund"
+)
+
+var paymentMethods = map[string]bool{
+	PaymentCash:      true,
+	PaymentDebitCard: true,
+	PaymentQRIS:      true,
+	PaymentEWallet:   true,
+	PaymentTransfer:  true,
+}
+
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID            int    `json:"id"`
+	Type          string `json:"type"`
+	ReferenceID   *int   `json:"reference_id,omitempty"`
+	OutletID      *int   `json:"outlet_id,omitempty"`
+	Subtotal      int    `json:"subtotal"`
+	TaxBase       int    `json:"tax_base"`
+	TaxAmount     int    `json:"tax_amount"`
+	ServiceCharge int    `json:"service_charge"`
+	GrandTotal    int    `json:"grand_total"`
+	// TotalAmount equals GrandTotal and is kept for older clients.
+	TotalAmount    int                 `json:"total_amount"`
+	PaidAmount     int                 `json:"paid_amount"`
+	ChangeAmount   int                 `json:"change_amount"`
+	Outstanding    int                 `json:"outstanding"`
+	CustomerID     *int                `json:"customer_id,omitempty"`
+	ShiftID        *int                `json:"shift_id,omitempty"`
+	PointsEarned   int                 `json:"points_earned"`
+	PointsRedeemed int                 `json:"points_redeemed"`
+	Reason         string              `json:"reason,omitempty"`
+	CreatedBy      string              `json:"created_by,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Details        []TransactionDetail `json:"details"`
+	Payments       []Payment           `json:"payments"`
 }
 
 type TransactionDetail struct {
+	ID            int      `json:"id"`
+	TransactionID int      `json:"transaction_id"`
+	ProductID     int      `json:"product_id"`
+	ProductName   string   `json:"product_name"`
+	Quantity      Quantity `json:"quantity"`
+	// Unit and UnitQuantity are set when the line was sold in a unit other
+	// than the base unit Quantity is in, e.g. 2 packs for 12 pieces.
+	Unit         string   `json:"unit,omitempty"`
+	UnitQuantity Quantity `json:"unit_quantity,omitempty"`
+	// UnitPrice is the price per unit sold, PriceRule the rule that gave it
+	// and PriceListID the price list when that rule is price_list.
+	UnitPrice   int    `json:"unit_price"`
+	PriceRule   string `json:"price_rule,omitempty"`
+	PriceListID *int   `json:"price_list_id,omitempty"`
+	// Cost is the cost of goods sold of the line, taken at sale time.
+	Cost          int     `json:"cost"`
+	GrossAmount   int     `json:"gross_amount"`
+	Discount      int     `json:"discount"`
+	SubTotal      int     `json:"sub_total"`
+	TaxRate       float64 `json:"tax_rate"`
+	TaxBase       int     `json:"tax_base"`
+	TaxAmount     int     `json:"tax_amount"`
+	ServiceCharge int     `json:"service_charge"`
+	PromotionIDs  []int   `json:"promotion_ids,omitempty"`
+	// RefundOfDetailID points at the sale line a negative refund line reverses.
+	RefundOfDetailID *int `json:"refund_of_detail_id,omitempty"`
+	// Components is set on bundle lines, Ingredients on lines of menu items
+	// made from a recipe.
+	Components  []DetailComponent `json:"components,omitempty"`
+	Ingredients []RecipeItem      `json:"ingredients,omitempty"`
+	// Lots are the lots the line's stock came from or went back to.
+	Lots []DetailLot `json:"lots,omitempty"`
+	// Serials are the units of a serialised product sold or returned.
+	Serials []string `json:"serials,omitempty"`
+}
+
+type Payment struct {
 	ID            int    `json:"id"`
 	TransactionID int    `json:"transaction_id"`
-	ProductID     int    `json:"product_id"`
-	ProductName   string `json:"product_name"`
-	Quantity      int    `json:"quantity"`
-	SubTotal      int    `json:"sub_total"`
+	Method        string `json:"method"`
+	Amount        int    `json:"amount"`
+	Reference     string `json:"reference,omitempty"`
 }
 
+// CheckoutItem names the product either by product_id or by a scanned
+// barcode. Quantity defaults to 1 for a scanned barcode and is read from
+// the label for a scale barcode. Quantity is in Unit, the product's base
+// unit when left out. A serialised product needs one serial per unit in
+// Serials.
 type CheckoutItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID int      `json:"product_id"`
+	Barcode   string   `json:"barcode,omitempty"`
+	Quantity  Quantity `json:"quantity"`
+	Unit      string   `json:"unit,omitempty"`
+	Serials   []string `json:"serials,omitempty"`
+}
+
+type PaymentRequest struct {
+	Method    string `json:"method"`
+	Amount    int    `json:"amount"`
+	Reference string `json:"reference"`
 }
 
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	OutletID     *int             `json:"outlet_id"`
+	Cashier      string           `json:"cashier"`
+	Items        []CheckoutItem   `json:"items"`
+	Payments     []PaymentRequest `json:"payments"`
+	CustomerID   *int             `json:"customer_id"`
+	RedeemPoints int              `json:"redeem_points"`
+}
+
+func (c *CheckoutRequest) Validate() error {
+	if len(c.Items) == 0 {
+		return errors.New("Items are required")
+	}
+	for _, item := range c.Items {
+		if item.ProductID == 0 && item.Barcode == "" {
+			return errors.New("Each item needs product_id or barcode")
+		}
+		if item.Quantity < 0 || (item.Quantity == 0 && item.Barcode == "") {
+			return errors.New("Each item needs a positive quantity")
+		}
+		if err := validSerials(item.Serials); err != nil {
+			return err
+		}
+	}
+	if c.RedeemPoints < 0 {
+		return errors.New("redeem_points cannot be negative")
+	}
+	if c.RedeemPoints > 0 && c.CustomerID == nil {
+		return errors.New("customer_id is required to redeem points")
+	}
+	if len(c.Payments) == 0 && c.RedeemPoints == 0 {
+		return errors.New("Payments are required")
+	}
+	for _, p := range c.Payments {
+		if !paymentMethods[p.Method] {
+			return errors.New("Unknown payment method: " + p.Method)
+		}
+		if p.Amount <= 0 {
+			return errors.New("Payment amount must be positive")
+		}
+	}
+	return nil
+}
+
+// TenderedCash returns the total amount paid in cash, the only method
+// change can be given from.
+func (c *CheckoutRequest) TenderedCash() int {
+	cash := 0
+	for _, p := range c.Payments {
+		if p.Method == PaymentCash {
+			cash += p.Amount
+		}
+	}
+	return cash
 }
 
+// RefundItem returns Quantity of a sale line. A line of a serialised
+// product names the returned units in Serials.
+type RefundItem struct {
+	DetailID int      `json:"detail_id"`
+	Quantity Quantity `json:"quantity"`
+	Serials  []string `json:"serials,omitempty"`
+}
+
+// RefundRequest is used for both voids and partial refunds. A void ignores
+// Items and reverses everything not yet refunded.
+type RefundRequest struct {
+	Reason     string       `json:"reason"`
+	RefundedBy string       `json:"refunded_by"`
+	Method     string       `json:"method"`
+	Items      []RefundItem `json:"items"`
+}
+
+func (r *RefundRequest) Validate(void bool) error {
+	if r.Reason == "" || r.RefundedBy == "" {
+		return errors.New("Reason and refunded_by are required")
+	}
+	if r.Method == "" {
+		r.Method = PaymentCash
+	}
+	if !paymentMethods[r.Method] {
+		return errors.New("Unknown payment method: " + r.Method)
+	}
+	if void {
+		return nil
+	}
+	if len(r.Items) == 0 {
+		return errors.New("Items are required")
+	}
+	for _, item := range r.Items {
+		if item.DetailID == 0 || item.Quantity <= 0 {
+			return errors.New("Each item needs detail_id and a positive quantity")
+		}
+		if err := validSerials(item.Serials); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Report sums a period. NetSales is revenue before tax and service charge
+// and net of refunds; GrossProfit is NetSales less the cost of goods sold and
+// Margin its percentage of NetSales.
 type Report struct {
 	TotalRevenue     int         `json:"total_revenue"`
+	TotalRefund      int         `json:"total_refund"`
 	TotalTransaction int         `json:"total_transaction"`
+	NetSales         int         `json:"net_sales"`
+	COGS             int         `json:"cogs"`
+	GrossProfit      int         `json:"gross_profit"`
+	Margin           float64     `json:"margin"`
 	HighestSelling   ProductSold `json:"highest_selling"`
 }
 
+type TaxRateSummary struct {
+	Rate      float64 `json:"rate"`
+	TaxBase   int     `json:"tax_base"`
+	TaxAmount int     `json:"tax_amount"`
+}
+
+type TaxReport struct {
+	StartDate          string           `json:"start_date"`
+	EndDate            string           `json:"end_date"`
+	Rates              []TaxRateSummary `json:"rates"`
+	TotalTaxBase       int              `json:"total_tax_base"`
+	TotalTaxAmount     int              `json:"total_tax_amount"`
+	TotalServiceCharge int              `json:"total_service_charge"`
+}
+
 type ProductSold struct {
 	ProductName string
-	ProductQty  int
+	ProductQty  Quantity
+}
+
+// Groupings of the product sales report.
+const (
+	SalesByProduct   = ""
+	SalesByParent    = "parent"
+	SalesByComponent = "component"
+	SalesByCategory  = "category"
+)
+
+// ProductSales is the quantity sold and revenue of a product in a period,
+// net of refunds. With the parent grouping variants are added up under
+// their parent product, with the component grouping bundles are broken down
+// into their components, and with the category grouping ProductID and
+// ProductName are those of the category. NetRevenue excludes tax and is
+// what GrossProfit and Margin are taken from.
+type ProductSales struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	Quantity    Quantity `json:"quantity"`
+	Revenue     int      `json:"revenue"`
+	NetRevenue  int      `json:"net_revenue"`
+	Cost        int      `json:"cost"`
+	GrossProfit int      `json:"gross_profit"`
+	Margin      float64  `json:"margin"`
 }
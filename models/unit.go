@@ -0,0 +1,45 @@
+package models
+
+import "errors"
+
+// ProductUnit is a unit a product is bought, counted or sold in besides its
+// base unit, e.g. a pack of 6 or a carton of 48 pieces. Factor is the number
+// of base units in one of it. Price is what one of it sells for; without a
+// price it sells at Factor times the product's price.
+type ProductUnit struct {
+	Name   string   `json:"name"`
+	Factor Quantity `json:"factor"`
+	Price  *int     `json:"price"`
+}
+
+// ProductUnits lists the units of a product next to its base unit, in which
+// stock is always kept.
+type ProductUnits struct {
+	ProductID int           `json:"product_id"`
+	Name      string        `json:"name"`
+	BaseUnit  string        `json:"base_unit"`
+	Units     []ProductUnit `json:"units"`
+}
+
+// SetUnitsRequest replaces the units of a product. An empty list leaves only
+// the base unit.
+type SetUnitsRequest struct {
+	Units []ProductUnit `json:"units"`
+}
+
+func (s *SetUnitsRequest) Validate() error {
+	seen := make(map[string]bool)
+	for _, u := range s.Units {
+		if u.Name == "" || u.Factor <= 0 {
+			return errors.New("Each unit needs a name and a positive factor")
+		}
+		if u.Price != nil && *u.Price < 0 {
+			return errors.New("Unit price cannot be negative")
+		}
+		if seen[u.Name] {
+			return errors.New("Each unit can only be listed once")
+		}
+		seen[u.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+// TestUnitConversion checks the arithmetic checkout, receiving, counting and
+// writing off use to turn a quantity in a unit into base units and to price
+// a unit without a price of its own.
+func TestUnitConversion(t *testing.T) {
+	tests := []struct {
+		name      string
+		quantity  Quantity
+		factor    Quantity
+		basePrice int
+		base      Quantity
+		unitPrice int
+	}{
+		{"pack of 6", Qty(2), Qty(6), 3500, Qty(12), 21000},
+		{"carton of 48", Qty(1), Qty(48), 3500, Qty(48), 168000},
+		{"half a box", 500, Qty(24), 1000, Qty(12), 24000},
+		{"crate of 2.5 kg", Qty(3), 2500, 18000, 7500, 45000},
+		{"base unit", Qty(5), Qty(1), 1250, Qty(5), 1250},
+		{"refunded packs", -Qty(2), Qty(6), 3500, -Qty(12), 21000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quantity.Mul(tt.factor); got != tt.base {
+				t.Errorf("%s × %s = %s base units, want %s", tt.quantity, tt.factor, got, tt.base)
+			}
+			if got := tt.factor.Amount(tt.basePrice); got != tt.unitPrice {
+				t.Errorf("unit of %s at %d = %d, want %d", tt.factor, tt.basePrice, got, tt.unitPrice)
+			}
+		})
+	}
+}
+
+func TestSetUnitsRequestValidate(t *testing.T) {
+	price := 20000
+	negative := -1
+	tests := []struct {
+		name    string
+		units   []ProductUnit
+		wantErr bool
+	}{
+		{"no units", nil, false},
+		{"packs and cartons", []ProductUnit{{Name: "pack", Factor: Qty(6), Price: &price}, {Name: "carton", Factor: Qty(48)}}, false},
+		{"fractional factor", []ProductUnit{{Name: "crate", Factor: 2500}}, false},
+		{"missing name", []ProductUnit{{Factor: Qty(6)}}, true},
+		{"zero factor", []ProductUnit{{Name: "pack"}}, true},
+		{"negative factor", []ProductUnit{{Name: "pack", Factor: -Qty(6)}}, true},
+		{"negative price", []ProductUnit{{Name: "pack", Factor: Qty(6), Price: &negative}}, true},
+		{"listed twice", []ProductUnit{{Name: "pack", Factor: Qty(6)}, {Name: "pack", Factor: Qty(12)}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := SetUnitsRequest{Units: tt.units}
+			if err := req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Reasons stock is written off for.
+const (
+	WriteOffExpired   = "expired"
+	WriteOffDamaged   = "damaged"
+	WriteOffStaffMeal = "staff_meal"
+	WriteOffSample    = "sample"
+)
+
+var WriteOffReasons = []string{WriteOffExpired, WriteOffDamaged, WriteOffStaffMeal, WriteOffSample}
+
+const (
+	WriteOffPending   = "pending"
+	WriteOffPosted    = "posted"
+	WriteOffCancelled = "cancelled"
+)
+
+// WriteOff takes stock that will not be sold out of an outlet: goods thrown
+// away, broken, eaten by staff or given away. TotalValue is the stock at
+// cost. A write-off worth more than the approval limit stays pending until
+// a manager other than its creator approves it; the stock only leaves once
+// it is posted, when the value is fixed at the cost actually used up.
+type WriteOff struct {
+	ID         int            `json:"id"`
+	OutletID   *int           `json:"outlet_id"`
+	Reason     string         `json:"reason"`
+	Status     string         `json:"status"`
+	Notes      string         `json:"notes"`
+	TotalValue int            `json:"total_value"`
+	CreatedBy  string         `json:"created_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	ApprovedBy string         `json:"approved_by,omitempty"`
+	ApprovedAt *time.Time     `json:"approved_at"`
+	PostedAt   *time.Time     `json:"posted_at"`
+	Items      []WriteOffItem `json:"items,omitempty"`
+}
+
+// WriteOffItem is a line of a write-off in the product's base unit. With
+// BatchNumber set it comes out of that batch only.
+type WriteOffItem struct {
+	ID          int         `json:"id"`
+	ProductID   int         `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	Quantity    Quantity    `json:"quantity"`
+	BatchNumber string      `json:"batch_number,omitempty"`
+	Value       int         `json:"value"`
+	Serials     []string    `json:"serials,omitempty"`
+	Lots        []DetailLot `json:"lots,omitempty"`
+}
+
+// WriteOffItemRequest is a line to write off in Unit, which defaults to the
+// product's base unit. A serialised product names the units in Serials.
+type WriteOffItemRequest struct {
+	ProductID   int      `json:"product_id"`
+	Quantity    Quantity `json:"quantity"`
+	Unit        string   `json:"unit,omitempty"`
+	BatchNumber string   `json:"batch_number,omitempty"`
+	Serials     []string `json:"serials,omitempty"`
+}
+
+type WriteOffRequest struct {
+	OutletID  *int                  `json:"outlet_id"`
+	Reason    string                `json:"reason"`
+	Notes     string                `json:"notes"`
+	CreatedBy string                `json:"created_by"`
+	Items     []WriteOffItemRequest `json:"items"`
+}
+
+// ApproveWriteOffRequest approves a write-off. The request must carry the
+// manager key; ApprovedBy records the manager's name.
+type ApproveWriteOffRequest struct {
+	ApprovedBy string `json:"approved_by"`
+}
+
+// WriteOffReport sums posted write-offs per reason and per product.
+type WriteOffReport struct {
+	StartDate  string             `json:"start_date"`
+	EndDate    string             `json:"end_date"`
+	WriteOffs  int                `json:"write_offs"`
+	TotalValue int                `json:"total_value"`
+	Reasons    []WriteOffByReason `json:"reasons"`
+	Products   []WriteOffProduct  `json:"products"`
+}
+
+type WriteOffByReason struct {
+	Reason    string `json:"reason"`
+	WriteOffs int    `json:"write_offs"`
+	Value     int    `json:"value"`
+}
+
+type WriteOffProduct struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	Quantity    Quantity `json:"quantity"`
+	Value       int      `json:"value"`
+}
+
+func (w *WriteOffRequest) Validate() error {
+	if !slices.Contains(WriteOffReasons, w.Reason) {
+		return errors.New("Reason must be expired, damaged, staff_meal or sample")
+	}
+	if w.CreatedBy == "" {
+		return errors.New("created_by is required")
+	}
+	if len(w.Items) == 0 {
+		return errors.New("Items are required")
+	}
+	for _, item := range w.Items {
+		if item.ProductID == 0 || item.Quantity <= 0 {
+			return errors.New("Each item needs product_id and a positive quantity")
+		}
+		if err := validLot(item.BatchNumber, ""); err != nil {
+			return err
+		}
+		if err := validSerials(item.Serials); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *ApproveWriteOffRequest) Validate() error {
+	if a.ApprovedBy == "" {
+		return errors.New("approved_by is required")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package pricing
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tier is a membership level reached once a customer's lifetime spend hits MinSpent.
+type Tier struct {
+	Name           string
+	MinSpent       int
+	EarnMultiplier float64
+}
+
+// LoyaltyConfig holds the points earn and burn rates.
+type LoyaltyConfig struct {
+	EarnPer    int    // rupiah spent per point earned
+	PointValue int    // rupiah value of one redeemed point
+	Tiers      []Tier // sorted by MinSpent
+}
+
+// DefaultLoyaltyConfig earns 1 point per Rp 10.000 and redeems 1 point as Rp 100.
+func DefaultLoyaltyConfig() LoyaltyConfig {
+	return LoyaltyConfig{
+		EarnPer:    10000,
+		PointValue: 100,
+		Tiers: []Tier{
+			{Name: "regular", MinSpent: 0, EarnMultiplier: 1},
+			{Name: "silver", MinSpent: 1000000, EarnMultiplier: 1.25},
+			{Name: "gold", MinSpent: 5000000, EarnMultiplier: 1.5},
+		},
+	}
+}
+
+// ParseTiers reads tiers written as "name:min_spent:multiplier,...",
+// e.g. "regular:0:1,silver:1000000:1.25".
+func ParseTiers(s string) ([]Tier, error) {
+	var tiers []Tier
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) != 3 {
+			return nil, errors.New("Invalid tier: " + part)
+		}
+		minSpent, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, errors.New("Invalid tier min spent: " + part)
+		}
+		multiplier, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, errors.New("Invalid tier multiplier: " + part)
+		}
+		tiers = append(tiers, Tier{Name: fields[0], MinSpent: minSpent, EarnMultiplier: multiplier})
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinSpent < tiers[j].MinSpent })
+	return tiers, nil
+}
+
+// TierFor returns the highest tier reached with totalSpent.
+func (c LoyaltyConfig) TierFor(totalSpent int) Tier {
+	tier := Tier{Name: "regular", EarnMultiplier: 1}
+	for _, t := range c.Tiers {
+		if totalSpent >= t.MinSpent {
+			tier = t
+		}
+	}
+	return tier
+}
+
+// EarnedPoints returns the points earned on amount for a customer of the given tier.
+func (c LoyaltyConfig) EarnedPoints(amount int, tier Tier) int {
+	if c.EarnPer <= 0 || amount <= 0 {
+		return 0
+	}
+	return int(float64(amount/c.EarnPer) * tier.EarnMultiplier)
+}
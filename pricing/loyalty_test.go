@@ -0,0 +1,31 @@
+package pricing
+
+import "testing"
+
+func TestEarnedPoints(t *testing.T) {
+	config := DefaultLoyaltyConfig()
+	regular, silver, gold := config.Tiers[0], config.Tiers[1], config.Tiers[2]
+
+	tests := []struct {
+		name   string
+		config LoyaltyConfig
+		amount int
+		tier   Tier
+		want   int
+	}{
+		{"regular", config, 25000, regular, 2},
+		{"below one point", config, 9999, regular, 0},
+		{"silver rounds down", config, 100000, silver, 12},
+		{"gold counts whole steps only", config, 99999, gold, 13},
+		{"nothing spent", config, 0, gold, 0},
+		{"refund", config, -50000, regular, 0},
+		{"earning switched off", LoyaltyConfig{EarnPer: 0}, 50000, regular, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.EarnedPoints(tt.amount, tt.tier); got != tt.want {
+				t.Errorf("EarnedPoints(%d, %s) = %d, want %d", tt.amount, tt.tier.Name, got, tt.want)
+			}
+		})
+	}
+}
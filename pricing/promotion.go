@@ -0,0 +1,243 @@
+package pricing
+
+import (
+	"gokasir-api/models"
+	"sort"
+	"time"
+)
+
+// Line is one basket line being priced at checkout.
+type Line struct {
+	ProductID  int
+	CategoryID int
+	UnitPrice  int
+	Quantity   models.Quantity
+	// LabelPrice is the price printed on a scale label; when set it is the
+	// line's gross instead of UnitPrice × Quantity.
+	LabelPrice int
+	Discount   int
+	Applied    []Applied
+
+	// locked is set once a non-stackable promotion has been applied.
+	locked bool
+}
+
+// Applied records how much a single promotion took off a line.
+type Applied struct {
+	PromotionID int
+	Amount      int
+}
+
+// Gross is the line at its unit price, rounded to a whole rupiah for
+// fractional quantities.
+func (l *Line) Gross() int {
+	if l.LabelPrice != 0 {
+		return l.LabelPrice
+	}
+	return l.Quantity.Amount(l.UnitPrice)
+}
+
+func (l *Line) Net() int {
+	return l.Gross() - l.Discount
+}
+
+func (l *Line) addDiscount(promotionID, amount int) {
+	if amount > l.Net() {
+		amount = l.Net()
+	}
+	if amount <= 0 {
+		return
+	}
+	l.Discount += amount
+	for i := range l.Applied {
+		if l.Applied[i].PromotionID == promotionID {
+			l.Applied[i].Amount += amount
+			return
+		}
+	}
+	l.Applied = append(l.Applied, Applied{PromotionID: promotionID, Amount: amount})
+}
+
+// ApplyPromotions evaluates promotions against the basket in priority order.
+//
+// Stacking rules: a stackable promotion applies to any line that has not
+// been claimed by a non-stackable one. A non-stackable promotion only
+// applies to lines without any discount yet, and claims those lines so
+// nothing else is applied after it.
+func ApplyPromotions(lines []Line, promotions []models.Promotion, now time.Time) {
+	promos := make([]models.Promotion, 0, len(promotions))
+	for _, p := range promotions {
+		if p.Active && !now.Before(p.StartAt) && now.Before(p.EndAt) {
+			promos = append(promos, p)
+		}
+	}
+	sort.SliceStable(promos, func(i, j int) bool {
+		if promos[i].Priority != promos[j].Priority {
+			return promos[i].Priority > promos[j].Priority
+		}
+		return promos[i].ID < promos[j].ID
+	})
+
+	for _, p := range promos {
+		var eligible []*Line
+		for i := range lines {
+			l := &lines[i]
+			if l.locked || !matches(p, l) {
+				continue
+			}
+			if !p.Stackable && l.Discount > 0 {
+				continue
+			}
+			eligible = append(eligible, l)
+		}
+		if len(eligible) == 0 {
+			continue
+		}
+
+		var touched []*Line
+		switch p.Type {
+		case models.PromoPercentage:
+			for _, l := range eligible {
+				l.addDiscount(p.ID, l.Net()*p.Value/100)
+				touched = append(touched, l)
+			}
+		case models.PromoBuyXGetY:
+			for _, l := range eligible {
+				// Only whole units count towards buy X get Y.
+				free := l.Quantity.Whole() / (p.BuyQty + p.GetQty) * p.GetQty
+				if free > 0 {
+					l.addDiscount(p.ID, free*l.UnitPrice)
+					touched = append(touched, l)
+				}
+			}
+		case models.PromoFixedAmount:
+			net := 0
+			for _, l := range eligible {
+				net += l.Net()
+			}
+			if net >= p.MinPurchase && net > 0 {
+				allocate(p.ID, p.Value, eligible, func(l *Line) int { return l.Net() })
+				touched = eligible
+			}
+		case models.PromoBundlePrice:
+			touched = applyBundle(p, eligible)
+		}
+
+		if !p.Stackable {
+			for _, l := range touched {
+				l.locked = true
+			}
+		}
+	}
+}
+
+// matches reports whether a promotion targets the line's product.
+// Basket-wide promotions without a category or product list match every line.
+func matches(p models.Promotion, l *Line) bool {
+	if p.CategoryID == nil && len(p.ProductIDs) == 0 {
+		return true
+	}
+	if p.CategoryID != nil && *p.CategoryID == l.CategoryID {
+		return true
+	}
+	for _, id := range p.ProductIDs {
+		if id == l.ProductID {
+			return true
+		}
+	}
+	return false
+}
+
+func applyBundle(p models.Promotion, eligible []*Line) []*Line {
+	byProduct := make(map[int]*Line)
+	for _, l := range eligible {
+		byProduct[l.ProductID] = l
+	}
+	// A product listed more than once is needed that many times per bundle.
+	required := make(map[int]int)
+	normalPrice := 0
+	members := make([]*Line, 0, len(p.ProductIDs))
+	for _, id := range p.ProductIDs {
+		l, ok := byProduct[id]
+		if !ok {
+			return nil
+		}
+		if required[id] == 0 {
+			members = append(members, l)
+		}
+		required[id]++
+		normalPrice += l.UnitPrice
+	}
+	bundles := -1
+	for _, l := range members {
+		if n := l.Quantity.Whole() / required[l.ProductID]; bundles == -1 || n < bundles {
+			bundles = n
+		}
+	}
+	if bundles <= 0 || normalPrice <= p.Value {
+		return nil
+	}
+	allocate(p.ID, bundles*(normalPrice-p.Value), members, func(l *Line) int { return bundles * required[l.ProductID] * l.UnitPrice })
+	return members
+}
+
+// allocate spreads amount over lines proportionally to weight.
+func allocate(promotionID, amount int, lines []*Line, weight func(*Line) int) {
+	weights := make([]int, len(lines))
+	for i, l := range lines {
+		weights[i] = weight(l)
+	}
+	for i, share := range Split(amount, weights) {
+		lines[i].addDiscount(promotionID, share)
+	}
+}
+
+// Allocate divides amount proportionally to weights like Split, without
+// capping it, so an amount larger than the weights is spread out in full.
+func Allocate(amount int, weights []int) []int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 || amount <= total {
+		return Split(amount, weights)
+	}
+	parts := make([]int, len(weights))
+	remaining := amount
+	for i, w := range weights {
+		share := amount * w / total
+		if i == len(weights)-1 {
+			share = remaining
+		}
+		parts[i] = share
+		remaining -= share
+	}
+	return parts
+}
+
+// Split divides amount proportionally to weights, giving the rounding
+// remainder to the last part so the parts add up exactly. Amount is capped
+// at the sum of weights.
+func Split(amount int, weights []int) []int {
+	parts := make([]int, len(weights))
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return parts
+	}
+	if amount > total {
+		amount = total
+	}
+	remaining := amount
+	for i, w := range weights {
+		share := amount * w / total
+		if i == len(weights)-1 {
+			share = remaining
+		}
+		parts[i] = share
+		remaining -= share
+	}
+	return parts
+}
@@ -0,0 +1,204 @@
+package pricing
+
+import (
+	"gokasir-api/models"
+	"testing"
+	"time"
+)
+
+func TestApplyPromotions(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	promo := func(p models.Promotion) models.Promotion {
+		p.Active = true
+		p.StartAt = now.Add(-time.Hour)
+		p.EndAt = now.Add(time.Hour)
+		return p
+	}
+	category := 7
+
+	tests := []struct {
+		name       string
+		lines      []Line
+		promotions []models.Promotion
+		want       []int
+	}{
+		{
+			name: "percentage off a product",
+			lines: []Line{
+				{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(2)},
+				{ProductID: 2, UnitPrice: 5000, Quantity: models.Qty(1)},
+			},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoPercentage, Value: 10, ProductIDs: []int{1}})},
+			want:       []int{2000, 0},
+		},
+		{
+			name: "percentage off a category",
+			lines: []Line{
+				{ProductID: 1, CategoryID: category, UnitPrice: 10000, Quantity: models.Qty(1)},
+				{ProductID: 2, CategoryID: 3, UnitPrice: 10000, Quantity: models.Qty(1)},
+			},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoPercentage, Value: 25, CategoryID: &category})},
+			want:       []int{2500, 0},
+		},
+		{
+			name:       "percentage off a weighed quantity",
+			lines:      []Line{{ProductID: 1, UnitPrice: 12345, Quantity: models.Quantity(1500)}},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoPercentage, Value: 10})},
+			want:       []int{1851},
+		},
+		{
+			name:       "percentage off a scale label price",
+			lines:      []Line{{ProductID: 1, UnitPrice: 12000, Quantity: models.Quantity(640), LabelPrice: 7500}},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoPercentage, Value: 20})},
+			want:       []int{1500},
+		},
+		{
+			name:       "buy two get one free",
+			lines:      []Line{{ProductID: 1, UnitPrice: 5000, Quantity: models.Qty(7)}},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoBuyXGetY, BuyQty: 2, GetQty: 1, ProductIDs: []int{1}})},
+			want:       []int{10000},
+		},
+		{
+			name:       "buy x get y counts whole units only",
+			lines:      []Line{{ProductID: 1, UnitPrice: 5000, Quantity: models.Quantity(2900)}},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoBuyXGetY, BuyQty: 2, GetQty: 1, ProductIDs: []int{1}})},
+			want:       []int{0},
+		},
+		{
+			name: "fixed amount spread over the basket",
+			lines: []Line{
+				{ProductID: 1, UnitPrice: 20000, Quantity: models.Qty(1)},
+				{ProductID: 2, UnitPrice: 10000, Quantity: models.Qty(1)},
+			},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoFixedAmount, Value: 5000, MinPurchase: 30000})},
+			want:       []int{3333, 1667},
+		},
+		{
+			name:       "fixed amount below the minimum purchase",
+			lines:      []Line{{ProductID: 1, UnitPrice: 20000, Quantity: models.Qty(1)}},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoFixedAmount, Value: 5000, MinPurchase: 30000})},
+			want:       []int{0},
+		},
+		{
+			name: "bundle price",
+			lines: []Line{
+				{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(2)},
+				{ProductID: 2, UnitPrice: 5000, Quantity: models.Qty(3)},
+			},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoBundlePrice, Value: 12000, ProductIDs: []int{1, 2}})},
+			want:       []int{4000, 2000},
+		},
+		{
+			name: "bundle price with a product listed twice",
+			lines: []Line{
+				{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(3)},
+				{ProductID: 2, UnitPrice: 5000, Quantity: models.Qty(3)},
+			},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoBundlePrice, Value: 20000, ProductIDs: []int{1, 1, 2}})},
+			want:       []int{4000, 1000},
+		},
+		{
+			name: "bundle price needs enough of a product listed twice",
+			lines: []Line{
+				{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(1)},
+				{ProductID: 2, UnitPrice: 5000, Quantity: models.Qty(1)},
+			},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoBundlePrice, Value: 20000, ProductIDs: []int{1, 1, 2}})},
+			want:       []int{0, 0},
+		},
+		{
+			name:       "bundle price needs every product",
+			lines:      []Line{{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(2)}},
+			promotions: []models.Promotion{promo(models.Promotion{ID: 1, Type: models.PromoBundlePrice, Value: 12000, ProductIDs: []int{1, 2}})},
+			want:       []int{0},
+		},
+		{
+			name:  "non-stackable promotion claims the line",
+			lines: []Line{{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(1)}},
+			promotions: []models.Promotion{
+				promo(models.Promotion{ID: 1, Type: models.PromoPercentage, Value: 10, Priority: 2}),
+				promo(models.Promotion{ID: 2, Type: models.PromoPercentage, Value: 10, Priority: 1, Stackable: true}),
+			},
+			want: []int{1000},
+		},
+		{
+			name:  "non-stackable promotion skips discounted lines",
+			lines: []Line{{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(1)}},
+			promotions: []models.Promotion{
+				promo(models.Promotion{ID: 1, Type: models.PromoPercentage, Value: 10, Priority: 2, Stackable: true}),
+				promo(models.Promotion{ID: 2, Type: models.PromoPercentage, Value: 50, Priority: 1}),
+			},
+			want: []int{1000},
+		},
+		{
+			name:  "stackable promotions apply in priority order",
+			lines: []Line{{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(1)}},
+			promotions: []models.Promotion{
+				promo(models.Promotion{ID: 2, Type: models.PromoPercentage, Value: 10, Priority: 1, Stackable: true}),
+				promo(models.Promotion{ID: 1, Type: models.PromoFixedAmount, Value: 2000, Priority: 2, Stackable: true}),
+			},
+			want: []int{2800},
+		},
+		{
+			name:  "discount never exceeds the line",
+			lines: []Line{{ProductID: 1, UnitPrice: 3000, Quantity: models.Qty(1)}},
+			promotions: []models.Promotion{
+				promo(models.Promotion{ID: 1, Type: models.PromoFixedAmount, Value: 5000}),
+			},
+			want: []int{3000},
+		},
+		{
+			name:  "inactive and expired promotions are ignored",
+			lines: []Line{{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(1)}},
+			promotions: []models.Promotion{
+				{ID: 1, Type: models.PromoPercentage, Value: 10, StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour)},
+				{ID: 2, Type: models.PromoPercentage, Value: 10, Active: true, StartAt: now.Add(-2 * time.Hour), EndAt: now},
+			},
+			want: []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ApplyPromotions(tt.lines, tt.promotions, now)
+			for i, l := range tt.lines {
+				if l.Discount != tt.want[i] {
+					t.Errorf("line %d discount = %d, want %d", i, l.Discount, tt.want[i])
+				}
+				applied := 0
+				for _, a := range l.Applied {
+					applied += a.Amount
+				}
+				if applied != l.Discount {
+					t.Errorf("line %d applied %d, discount %d", i, applied, l.Discount)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPromotionsTimeZone(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	// Six in the morning in Jakarta is still the previous day in UTC.
+	now := time.Date(2026, 3, 1, 6, 0, 0, 0, jakarta)
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       int
+	}{
+		{"started at midnight in Jakarta", time.Date(2026, 3, 1, 0, 0, 0, 0, jakarta), time.Date(2026, 3, 2, 0, 0, 0, 0, jakarta), 1000},
+		{"starts at midnight UTC", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), 0},
+		{"ends at midnight UTC", time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), 1000},
+		{"ended at six in Jakarta", time.Date(2026, 2, 28, 0, 0, 0, 0, jakarta), time.Date(2026, 2, 28, 23, 0, 0, 0, time.UTC), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := []Line{{ProductID: 1, UnitPrice: 10000, Quantity: models.Qty(1)}}
+			promotions := []models.Promotion{{ID: 1, Type: models.PromoPercentage, Value: 10, Active: true, StartAt: tt.start, EndAt: tt.end}}
+			ApplyPromotions(lines, promotions, now)
+			if lines[0].Discount != tt.want {
+				t.Errorf("discount = %d, want %d", lines[0].Discount, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package pricing
+
+import "math"
+
+// TaxConfig holds the outlet wide tax policy. Rates are percentages.
+type TaxConfig struct {
+	Rate              float64 // default PPN rate, e.g. 11 or 12
+	Inclusive         bool    // selling prices already include PPN unless a product or category says otherwise
+	ServiceChargeRate float64 // e.g. 5; 0 disables the service charge
+}
+
+// DefaultTaxConfig charges no tax and no service charge, so totals stay as
+// they were until a rate is configured.
+func DefaultTaxConfig() TaxConfig {
+	return TaxConfig{
+		Rate:      0,
+		Inclusive: false,
+	}
+}
+
+// ResolveRate picks the tax rate for a product: a product setting wins over
+// its category, and the category wins over the default rate.
+func (c TaxConfig) ResolveRate(productRate *float64, productExempt bool, categoryRate *float64, categoryExempt bool) float64 {
+	switch {
+	case productExempt:
+		return 0
+	case productRate != nil:
+		return *productRate
+	case categoryExempt:
+		return 0
+	case categoryRate != nil:
+		return *categoryRate
+	}
+	return c.Rate
+}
+
+// ResolveInclusive says whether a product's price includes tax: a product
+// setting wins over its category, and the category over the default.
+func (c TaxConfig) ResolveInclusive(productInclusive, categoryInclusive *bool) bool {
+	switch {
+	case productInclusive != nil:
+		return *productInclusive
+	case categoryInclusive != nil:
+		return *categoryInclusive
+	}
+	return c.Inclusive
+}
+
+// LineTax splits a line amount into tax base and tax at the given rate.
+// With inclusive pricing the tax is carved out of amount, otherwise it is
+// added on top of it.
+func LineTax(amount int, rate float64, inclusive bool) (base, tax int) {
+	if rate <= 0 {
+		return amount, 0
+	}
+	if inclusive {
+		base = int(math.Round(float64(amount) * 100 / (100 + rate)))
+		return base, amount - base
+	}
+	return amount, int(math.Round(float64(amount) * rate / 100))
+}
+
+// ServiceCharge is charged on the tax base and is not itself taxed.
+func ServiceCharge(base int, rate float64) int {
+	if rate <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(base) * rate / 100))
+}
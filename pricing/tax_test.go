@@ -0,0 +1,33 @@
+package pricing
+
+import "testing"
+
+func TestLineTax(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int
+		rate      float64
+		inclusive bool
+		base, tax int
+	}{
+		{"no rate", 10000, 0, false, 10000, 0},
+		{"no rate inclusive", 10000, 0, true, 10000, 0},
+		{"exclusive", 10000, 11, false, 10000, 1100},
+		{"exclusive rounds half up", 50, 11, false, 50, 6},
+		{"exclusive rounds down", 1234, 12, false, 1234, 148},
+		{"inclusive", 11100, 11, true, 10000, 1100},
+		{"inclusive rounds the base", 10000, 11, true, 9009, 991},
+		{"inclusive zero", 0, 11, true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, tax := LineTax(tt.amount, tt.rate, tt.inclusive)
+			if base != tt.base || tax != tt.tax {
+				t.Errorf("LineTax(%d, %v, %v) = %d, %d, want %d, %d", tt.amount, tt.rate, tt.inclusive, base, tax, tt.base, tt.tax)
+			}
+			if tt.inclusive && base+tax != tt.amount {
+				t.Errorf("inclusive base %d and tax %d do not add up to %d", base, tax, tt.amount)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package receipt
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Printers and the built-in PDF fonts only know the Windows-1252 (WinAnsi)
+// characters. Anything else, and control characters that a printer would
+// take for a command, prints as unknown. It is one character either way,
+// so the layout keeps its widths.
+const unknown = '?'
+
+// printable replaces what a receipt cannot print with unknown, so the plain
+// text receipt shows what the printer and the PDF do.
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return unknown
+		}
+		if _, ok := charmap.Windows1252.EncodeRune(r); !ok {
+			return unknown
+		}
+		return r
+	}, s)
+}
+
+// winAnsi encodes s in Windows-1252 for the printer and the PDF fonts.
+func winAnsi(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range printable(s) {
+		c, _ := charmap.Windows1252.EncodeRune(r)
+		b = append(b, c)
+	}
+	return b
+}
@@ -0,0 +1,38 @@
+package receipt
+
+import "bytes"
+
+// ESC/POS command bytes understood by common thermal printers.
+var (
+	escInit        = []byte{0x1b, 0x40}
+	escCodePage    = []byte{0x1b, 0x74, 0x10} // WPC1252
+	escAlignLeft   = []byte{0x1b, 0x61, 0x00}
+	escAlignCenter = []byte{0x1b, 0x61, 0x01}
+	escBoldOn      = []byte{0x1b, 0x45, 0x01}
+	escBoldOff     = []byte{0x1b, 0x45, 0x00}
+	escFeedAndCut  = []byte{0x1b, 0x64, 0x04, 0x1d, 0x56, 0x00}
+)
+
+func escpos(lines []line) []byte {
+	var b bytes.Buffer
+	b.Write(escInit)
+	b.Write(escCodePage)
+	for _, l := range lines {
+		if l.align == alignCenter {
+			b.Write(escAlignCenter)
+		} else {
+			b.Write(escAlignLeft)
+		}
+		if l.bold {
+			b.Write(escBoldOn)
+		}
+		b.Write(winAnsi(l.text))
+		b.WriteByte('\n')
+		if l.bold {
+			b.Write(escBoldOff)
+		}
+	}
+	b.Write(escAlignLeft)
+	b.Write(escFeedAndCut)
+	return b.Bytes()
+}
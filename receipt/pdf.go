@@ -0,0 +1,81 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pointsPerMM = 72 / 25.4
+	pdfMargin   = 8.0
+	// Courier glyphs are 0.6 em wide.
+	courierWidth = 0.6
+)
+
+// pdf writes a single page PDF sized to the paper roll, using the built-in
+// Courier fonts with WinAnsi encoding so no font has to be embedded.
+func pdf(t Template, lines []line) []byte {
+	cols := t.Columns()
+	width := float64(t.PaperWidth) * pointsPerMM
+	fontSize := (width - 2*pdfMargin) / (float64(cols) * courierWidth)
+	leading := fontSize * 1.2
+	height := float64(len(lines))*leading + 2*pdfMargin
+
+	var content bytes.Buffer
+	y := height - pdfMargin - fontSize
+	for _, l := range lines {
+		text := l.text
+		if l.align == alignCenter {
+			text = center(text, cols)
+		}
+		font := "F1"
+		if l.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, fontSize, pdfMargin, y, pdfEscape(text))
+		y -= leading
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents 4 0 R /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>", width, height),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// pdfEscape writes s as the body of a PDF string in WinAnsi, escaping the
+// delimiters and writing bytes outside ASCII as octal.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, c := range winAnsi(s) {
+		switch {
+		case c == '\\' || c == '(' || c == ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c >= 0x80:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,229 @@
+package receipt
+
+import (
+	"bytes"
+	"gokasir-api/models"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRupiah(t *testing.T) {
+	tests := []struct {
+		amount int
+		want   string
+	}{
+		{0, "0"},
+		{500, "500"},
+		{12500, "12.500"},
+		{1000000, "1.000.000"},
+		{-12500, "-12.500"},
+	}
+	for _, tt := range tests {
+		if got := rupiah(tt.amount); got != tt.want {
+			t.Errorf("rupiah(%d) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right string
+		cols        int
+		want        string
+	}{
+		{"pads the gap", "Total", "12.500", 16, "Total     12.500"},
+		{"counts characters", "Café", "5.000", 12, "Café   5.000"},
+		{"cuts a long left side", "Nasi goreng spesial", "25.000", 16, "Nasi gore 25.000"},
+		{"keeps one space", "Es teh", "3.000", 12, "Es teh 3.000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := spread(tt.left, tt.right, tt.cols)
+			if got != tt.want {
+				t.Errorf("spread(%q, %q, %d) = %q, want %q", tt.left, tt.right, tt.cols, got, tt.want)
+			}
+			if n := utf8.RuneCountInString(got); n != tt.cols {
+				t.Errorf("spread(%q, %q, %d) is %d characters wide", tt.left, tt.right, tt.cols, n)
+			}
+		})
+	}
+}
+
+func TestCenter(t *testing.T) {
+	tests := []struct {
+		s    string
+		cols int
+		want string
+	}{
+		{"Kasir", 11, "   Kasir"},
+		{"Crème", 9, "  Crème"},
+		{"Toko Sembako Makmur", 8, "Toko Sem"},
+	}
+	for _, tt := range tests {
+		if got := center(tt.s, tt.cols); got != tt.want {
+			t.Errorf("center(%q, %d) = %q, want %q", tt.s, tt.cols, got, tt.want)
+		}
+	}
+}
+
+func TestPrintable(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"ascii", "Es teh manis", "Es teh manis"},
+		{"latin", "Café Ñoño €5", "Café Ñoño €5"},
+		{"emoji", "Mie 🍜 pedas", "Mie ? pedas"},
+		{"other scripts", "拉面", "??"},
+		{"printer commands", "A\x1b@B", "A?@B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := printable(tt.s)
+			if got != tt.want {
+				t.Errorf("printable(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+			if utf8.RuneCountInString(got) != utf8.RuneCountInString(tt.s) {
+				t.Errorf("printable(%q) changed the width", tt.s)
+			}
+		})
+	}
+}
+
+func TestWinAnsi(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []byte
+	}{
+		{"Kopi", []byte("Kopi")},
+		{"Café", []byte{'C', 'a', 'f', 0xe9}},
+		{"€", []byte{0x80}},
+		{"🍜", []byte{'?'}},
+	}
+	for _, tt := range tests {
+		if got := winAnsi(tt.s); !bytes.Equal(got, tt.want) {
+			t.Errorf("winAnsi(%q) = %x, want %x", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Total", "Total"},
+		{`(1) \ 2`, `\(1\) \\ 2`},
+		{"Café", `Caf\351`},
+	}
+	for _, tt := range tests {
+		if got := pdfEscape(tt.s); got != tt.want {
+			t.Errorf("pdfEscape(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+// sale is a receipt with a long name, a line sold in packs, a serial and a
+// name the printer cannot show in full.
+func sale() *models.Transaction {
+	return &models.Transaction{
+		ID:        42,
+		Type:      models.TransactionSale,
+		CreatedAt: time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+		Details: []models.TransactionDetail{
+			{ProductName: "Indomie Goreng Rendang Jumbo Pedas Extra Level 5", Quantity: models.Qty(12), Unit: "pack", UnitQuantity: models.Qty(2), GrossAmount: 24000, Discount: 2400},
+			{ProductName: "Crème brûlée 🍮", Quantity: models.Quantity(1500), GrossAmount: 18518},
+			{ProductName: "Headset", Quantity: models.Qty(1), GrossAmount: 150000, Serials: []string{"SN-001"}},
+		},
+		Subtotal:     190118,
+		GrandTotal:   190118,
+		Payments:     []models.Payment{{Method: models.PaymentCash, Amount: 200000}},
+		ChangeAmount: 9882,
+		PointsEarned: 19,
+	}
+}
+
+func TestLayout(t *testing.T) {
+	for _, width := range []int{58, 80} {
+		tmpl := Template{StoreName: "Toko Makmur", Address: "Jl. Merdeka 1", Footer: "Terima kasih\nSampai jumpa", PaperWidth: width}
+		lines := layout(tmpl, sale())
+		var text []string
+		for _, l := range lines {
+			if n := utf8.RuneCountInString(l.text); n > tmpl.Columns() {
+				t.Errorf("%dmm: line %q is %d characters wide, more than %d", width, l.text, n, tmpl.Columns())
+			}
+			text = append(text, l.text)
+		}
+		receipt := strings.Join(text, "\n")
+		for _, want := range []string{
+			"Toko Makmur",
+			"No: 42",
+			"01/03/2026 09:30",
+			"2 pack x 12.000",
+			"1.5 x 12.345",
+			"Diskon",
+			"SN: SN-001",
+			"190.118",
+			"Tunai",
+			"Kembali",
+			"Poin didapat",
+			"Sampai jumpa",
+		} {
+			if !strings.Contains(receipt, want) {
+				t.Errorf("%dmm receipt is missing %q:\n%s", width, want, receipt)
+			}
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tmpl := DefaultTemplate()
+	tmpl.StoreName = "Kafé"
+
+	text, contentType, err := Render(FormatText, tmpl, sale())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "text/plain; charset=utf-8" || !strings.Contains(string(text), "Crème brûlée ?") {
+		t.Errorf("text receipt %q:\n%s", contentType, text)
+	}
+
+	escpos, _, err := Render(FormatESCPOS, tmpl, sale())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(escpos, append(append([]byte{}, escInit...), escCodePage...)) {
+		t.Errorf("ESC/POS receipt does not select the code page first: %x", escpos[:8])
+	}
+	if !bytes.Contains(escpos, []byte{'K', 'a', 'f', 0xe9}) || bytes.Contains(escpos, []byte("Kafé")) {
+		t.Error("ESC/POS receipt is not in Windows-1252")
+	}
+
+	pdf, contentType, err := Render(FormatPDF, tmpl, sale())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/pdf" || !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) {
+		t.Errorf("not a PDF: %q", contentType)
+	}
+	if !bytes.Contains(pdf, []byte("/WinAnsiEncoding")) || !bytes.Contains(pdf, []byte(`Kaf\351`)) {
+		t.Error("PDF receipt is not in WinAnsi")
+	}
+
+	if _, _, err := Render("html", tmpl, sale()); err == nil {
+		t.Error("Render accepted an unknown format")
+	}
+}
+
+func TestTemplateValidate(t *testing.T) {
+	for width, valid := range map[int]bool{58: true, 80: true, 0: false, 76: false} {
+		err := Template{PaperWidth: width}.Validate()
+		if (err == nil) != valid {
+			t.Errorf("Validate() of a %dmm template = %v", width, err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package receipt
+
+import (
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	FormatText   = "text"
+	FormatESCPOS = "escpos"
+	FormatPDF    = "pdf"
+)
+
+// Template holds the per outlet receipt layout.
+type Template struct {
+	StoreName  string
+	Address    string
+	Phone      string
+	Footer     string
+	PaperWidth int // paper width in mm, 58 or 80
+}
+
+// DefaultTemplate returns an 80mm layout without store details.
+func DefaultTemplate() Template {
+	return Template{
+		StoreName:  "Kasir",
+		Footer:     "Terima kasih",
+		PaperWidth: 80,
+	}
+}
+
+// Validate checks the paper width is one the layout knows.
+func (t Template) Validate() error {
+	if t.PaperWidth != 58 && t.PaperWidth != 80 {
+		return fmt.Errorf("Paper width must be 58 or 80, got %d", t.PaperWidth)
+	}
+	return nil
+}
+
+// Columns is the number of monospace characters that fit on one line.
+func (t Template) Columns() int {
+	if t.PaperWidth == 58 {
+		return 32
+	}
+	return 48
+}
+
+type align int
+
+const (
+	alignLeft align = iota
+	alignCenter
+)
+
+type line struct {
+	text  string
+	align align
+	bold  bool
+}
+
+// Render builds the receipt in the requested format and returns the body
+// along with its content type.
+func Render(format string, t Template, tr *models.Transaction) ([]byte, string, error) {
+	lines := layout(t, tr)
+	switch format {
+	case "", FormatText:
+		return []byte(plainText(t, lines)), "text/plain; charset=utf-8", nil
+	case FormatESCPOS:
+		return escpos(lines), "application/octet-stream", nil
+	case FormatPDF:
+		return pdf(t, lines), "application/pdf", nil
+	}
+	return nil, "", errors.New("Unknown receipt format: " + format)
+}
+
+// layout turns a transaction into receipt lines no wider than t.Columns().
+func layout(t Template, tr *models.Transaction) []line {
+	cols := t.Columns()
+	sep := line{text: strings.Repeat("-", cols)}
+	var lines []line
+
+	lines = append(lines, line{text: t.StoreName, align: alignCenter, bold: true})
+	for _, s := range []string{t.Address, t.Phone} {
+		if s != "" {
+			lines = append(lines, line{text: s, align: alignCenter})
+		}
+	}
+	lines = append(lines, sep)
+	lines = append(lines, line{text: spread(fmt.Sprintf("No: %d", tr.ID), tr.CreatedAt.Format("02/01/2006 15:04"), cols)})
+	if tr.Type != "" && tr.Type != models.TransactionSale {
+		lines = append(lines, line{text: strings.ToUpper(tr.Type), align: alignCenter, bold: true})
+	}
+	lines = append(lines, sep)
+
+	for _, d := range tr.Details {
+		lines = append(lines, line{text: truncate(d.ProductName, cols)})
+		quantity, unit := d.Quantity, ""
+		if d.Unit != "" {
+			quantity, unit = d.UnitQuantity, " "+d.Unit
+		}
+		unitPrice := 0
+		if quantity != 0 {
+			unitPrice = int(int64(d.GrossAmount) * models.QuantityScale / int64(quantity))
+		}
+		lines = append(lines, line{text: spread(fmt.Sprintf("  %s%s x %s", quantity, unit, rupiah(unitPrice)), rupiah(d.GrossAmount), cols)})
+		if d.Discount != 0 {
+			lines = append(lines, line{text: spread("  Diskon", rupiah(-d.Discount), cols)})
+		}
+		for _, s := range d.Serials {
+			lines = append(lines, line{text: truncate("  SN: "+s, cols)})
+		}
+	}
+	lines = append(lines, sep)
+
+	lines = append(lines, line{text: spread("Subtotal", rupiah(tr.Subtotal), cols)})
+	if tr.TaxAmount != 0 {
+		lines = append(lines, line{text: spread("DPP", rupiah(tr.TaxBase), cols)})
+		lines = append(lines, line{text: spread("PPN", rupiah(tr.TaxAmount), cols)})
+	}
+	if tr.ServiceCharge != 0 {
+		lines = append(lines, line{text: spread("Service", rupiah(tr.ServiceCharge), cols)})
+	}
+	lines = append(lines, line{text: spread("TOTAL", rupiah(tr.GrandTotal), cols), bold: true})
+	for _, p := range tr.Payments {
+		lines = append(lines, line{text: spread(paymentLabel(p.Method), rupiah(p.Amount), cols)})
+	}
+	if tr.ChangeAmount != 0 {
+		lines = append(lines, line{text: spread("Kembali", rupiah(tr.ChangeAmount), cols)})
+	}
+	if tr.Outstanding != 0 {
+		lines = append(lines, line{text: spread("Sisa", rupiah(tr.Outstanding), cols)})
+	}
+	if tr.PointsEarned != 0 {
+		lines = append(lines, line{text: spread("Poin didapat", strconv.Itoa(tr.PointsEarned), cols)})
+	}
+
+	if t.Footer != "" {
+		lines = append(lines, sep)
+		for _, f := range strings.Split(t.Footer, "\n") {
+			lines = append(lines, line{text: truncate(f, cols), align: alignCenter})
+		}
+	}
+	return lines
+}
+
+func paymentLabel(method string) string {
+	switch method {
+	case models.PaymentCash:
+		return "Tunai"
+	case models.PaymentDebitCard:
+		return "Kartu Debit"
+	case models.PaymentQRIS:
+		return "QRIS"
+	case models.PaymentEWallet:
+		return "E-Wallet"
+	case models.PaymentTransfer:
+		return "Transfer"
+	case models.PaymentPoints:
+		return "Poin"
+	}
+	return method
+}
+
+// spread puts left and right on one line, padding the gap with spaces.
+// Widths are counted in characters, not bytes, so accented names line up.
+func spread(left, right string, cols int) string {
+	width := utf8.RuneCountInString(right)
+	gap := cols - utf8.RuneCountInString(left) - width
+	if gap < 1 {
+		left = truncate(left, cols-width-1)
+		gap = 1
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+// truncate cuts s to at most n characters without splitting one.
+func truncate(s string, n int) string {
+	if n < 0 {
+		n = 0
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+func center(s string, cols int) string {
+	s = truncate(s, cols)
+	return strings.Repeat(" ", (cols-utf8.RuneCountInString(s))/2) + s
+}
+
+// rupiah formats an amount with dot thousand separators, e.g. 12.500.
+func rupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,16 @@
+package receipt
+
+import "strings"
+
+func plainText(t Template, lines []line) string {
+	var b strings.Builder
+	for _, l := range lines {
+		if l.align == alignCenter {
+			b.WriteString(printable(center(l.text, t.Columns())))
+		} else {
+			b.WriteString(printable(l.text))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"gokasir-api/models"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	db := testDB(t)
+	repo := NewProductRepository(db)
+	outlet := newOutlet(t, db)
+	coffee := newProduct(t, db, models.Product{Price: 10000, Cost: 4000})
+	cookie := newProduct(t, db, models.Product{Price: 5000, Cost: 1000})
+	stockUp(t, db, coffee, outlet, models.Qty(10))
+	stockUp(t, db, cookie, outlet, models.Qty(10))
+
+	bundle := newProduct(t, db, models.Product{Price: 12000})
+	err := repo.SetBundleComponents(bundle, &models.SetBundleRequest{Components: []models.BundleComponent{
+		{ProductID: coffee, Quantity: models.Qty(1)},
+		{ProductID: cookie, Quantity: models.Qty(2)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := repo.FindBundle(bundle, &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Available != 5 {
+		t.Errorf("%d bundles available, want the 5 the cookies are enough for", b.Available)
+	}
+
+	sale, err := sell(db, outlet, models.CheckoutItem{ProductID: bundle, Quantity: models.Qty(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stock := stockOf(t, db, coffee, outlet); stock != models.Qty(8) {
+		t.Errorf("coffee stock %s, want 8", stock)
+	}
+	if stock := stockOf(t, db, cookie, outlet); stock != models.Qty(6) {
+		t.Errorf("cookie stock %s, want 6", stock)
+	}
+	d := sale.Details[0]
+	if d.Cost != 12000 {
+		t.Errorf("bundle cost %d, want 12000 from its components", d.Cost)
+	}
+	revenue := 0
+	for _, c := range d.Components {
+		revenue += c.Revenue
+	}
+	if len(d.Components) != 2 || revenue != d.SubTotal {
+		t.Errorf("%d components share %d of the line's %d", len(d.Components), revenue, d.SubTotal)
+	}
+
+	if _, err := sell(db, outlet, models.CheckoutItem{ProductID: bundle, Quantity: models.Qty(4)}); err == nil {
+		t.Error("sold more bundles than the cookies are enough for")
+	}
+
+	void(t, db, sale.ID)
+	if stock := stockOf(t, db, coffee, outlet); stock != models.Qty(10) {
+		t.Errorf("coffee stock %s after the void, want 10", stock)
+	}
+	if stock := stockOf(t, db, cookie, outlet); stock != models.Qty(10) {
+		t.Errorf("cookie stock %s after the void, want 10", stock)
+	}
+	if cost := costOf(t, db, coffee); cost != 4000 {
+		t.Errorf("coffee cost %d after the void, want 4000", cost)
+	}
+}
+
+func TestSetBundleComponentsRefuses(t *testing.T) {
+	db := testDB(t)
+	repo := NewProductRepository(db)
+	outlet := newOutlet(t, db)
+	component := newProduct(t, db, models.Product{})
+	bundle := newProduct(t, db, models.Product{})
+	err := repo.SetBundleComponents(bundle, &models.SetBundleRequest{Components: []models.BundleComponent{{ProductID: component, Quantity: models.Qty(1)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stocked := newProduct(t, db, models.Product{})
+	stockUp(t, db, stocked, outlet, models.Qty(1))
+	empty := newProduct(t, db, models.Product{})
+
+	tests := []struct {
+		name       string
+		id         int
+		components []models.BundleComponent
+	}{
+		{"itself", empty, []models.BundleComponent{{ProductID: empty, Quantity: models.Qty(1)}}},
+		{"holds stock", stocked, []models.BundleComponent{{ProductID: component, Quantity: models.Qty(1)}}},
+		{"a component", component, []models.BundleComponent{{ProductID: stocked, Quantity: models.Qty(1)}}},
+		{"a bundle inside", empty, []models.BundleComponent{{ProductID: bundle, Quantity: models.Qty(1)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.SetBundleComponents(tt.id, &models.SetBundleRequest{Components: tt.components}); err == nil {
+				t.Error("SetBundleComponents accepted the components")
+			}
+		})
+	}
+}
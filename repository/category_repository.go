@@ -7,7 +7,7 @@ type CategoryRepository interface {
 	CreateCategory(req *models.Category) error
 	FindCategoryByID(id int) (*models.Category, error)
 	UpdateCategory(id int, req *models.Category) error
-	PatchCategory(id int, name, description *string) (*models.Category, error)
+	PatchCategory(id int, req *models.PatchCategoryRequest) (*models.Category, error)
 	DeleteCategory(id int) error
 	ExistCategoryID(id int) (bool, error)
 }
@@ -23,7 +23,7 @@ func (r *CategoryRepositoryImpl) ExistCategoryID(id int) (bool, error) {
 }
 
 func (r *CategoryRepositoryImpl) FindAllCategory() ([]models.Category, error) {
-	rows, err := r.db.Query("SELECT id, name, description FROM category ORDER BY id")
+	rows, err := r.db.Query("SELECT id, name, description, tax_rate, tax_exempt, tax_inclusive FROM category ORDER BY id")
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +31,7 @@ func (r *CategoryRepositoryImpl) FindAllCategory() ([]models.Category, error) {
 	var category []models.Category
 	for rows.Next() {
 		var cat models.Category
-		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Description); err != nil {
+		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Description, &cat.TaxRate, &cat.TaxExempt, &cat.TaxInclusive); err != nil {
 			return nil, err
 		}
 		category = append(category, cat)
@@ -40,7 +40,7 @@ func (r *CategoryRepositoryImpl) FindAllCategory() ([]models.Category, error) {
 }
 
 func (r *CategoryRepositoryImpl) CreateCategory(req *models.Category) error {
-	err := r.db.QueryRow("INSERT INTO category(name, description) VALUES($1, $2) RETURNING id", req.Name, req.Description).Scan(&req.ID)
+	err := r.db.QueryRow("INSERT INTO category(name, description, tax_rate, tax_exempt, tax_inclusive) VALUES($1, $2, $3, $4, $5) RETURNING id", req.Name, req.Description, req.TaxRate, req.TaxExempt, req.TaxInclusive).Scan(&req.ID)
 	return err
 }
 
@@ -53,7 +53,7 @@ func (r *CategoryRepositoryImpl) FindCategoryByID(id int) (*models.Category, err
 		return nil, errors.New("Category ID not found")
 	}
 	var category models.Category
-	if err := r.db.QueryRow("SELECT id, name, description FROM category WHERE id = $1", id).Scan(&category.ID, &category.Name, &category.Description); err != nil {
+	if err := r.db.QueryRow("SELECT id, name, description, tax_rate, tax_exempt, tax_inclusive FROM category WHERE id = $1", id).Scan(&category.ID, &category.Name, &category.Description, &category.TaxRate, &category.TaxExempt, &category.TaxInclusive); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("Category not found")
 		}
@@ -70,7 +70,7 @@ func (r *CategoryRepositoryImpl) UpdateCategory(id int, req *models.Category) er
 	if !exist {
 		return errors.New("Category ID not found")
 	}
-	result, err := r.db.Exec("UPDATE category SET name = $1, description = $2 WHERE id = $3", req.Name, req.Description, id)
+	result, err := r.db.Exec("UPDATE category SET name = $1, description = $2, tax_rate = $3, tax_exempt = $4, tax_inclusive = $5 WHERE id = $6", req.Name, req.Description, req.TaxRate, req.TaxExempt, req.TaxInclusive, id)
 	if err != nil {
 		return err
 	}
@@ -84,7 +84,7 @@ func (r *CategoryRepositoryImpl) UpdateCategory(id int, req *models.Category) er
 	return nil
 }
 
-func (r *CategoryRepositoryImpl) PatchCategory(id int, name, description *string) (*models.Category, error) {
+func (r *CategoryRepositoryImpl) PatchCategory(id int, req *models.PatchCategoryRequest) (*models.Category, error) {
 	exist, err := r.ExistCategoryID(id)
 	if err != nil {
 		return nil, err
@@ -99,14 +99,29 @@ func (r *CategoryRepositoryImpl) PatchCategory(id int, name, description *string
 	var updates []string
 	argCount := 1
 
-	if name != nil {
+	if req.Name != nil {
 		updates = append(updates, fmt.Sprintf("name = $%d", argCount))
-		args = append(args, name)
+		args = append(args, req.Name)
 		argCount++
 	}
-	if description != nil {
+	if req.Description != nil {
 		updates = append(updates, fmt.Sprintf("description = $%d", argCount))
-		args = append(args, description)
+		args = append(args, req.Description)
+		argCount++
+	}
+	if req.TaxRate != nil {
+		updates = append(updates, fmt.Sprintf("tax_rate = $%d", argCount))
+		args = append(args, req.TaxRate)
+		argCount++
+	}
+	if req.TaxExempt != nil {
+		updates = append(updates, fmt.Sprintf("tax_exempt = $%d", argCount))
+		args = append(args, req.TaxExempt)
+		argCount++
+	}
+	if req.TaxInclusive != nil {
+		updates = append(updates, fmt.Sprintf("tax_inclusive = $%d", argCount))
+		args = append(args, req.TaxInclusive)
 		argCount++
 	}
 	if len(updates) == 0 {
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+)
+
+// receiveCost values a delivery of quantity base units at unitCost each.
+// A moving average product folds it into its average cost, a FIFO product
+// gets a new cost layer. receiptID is nil for stock that comes back rather
+// than from a supplier. Call it before the delivery is added to stock.
+func receiveCost(q queryer, productID int, quantity models.Quantity, unitCost int, receiptID *int) error {
+	var method string
+	var stock models.Quantity
+	var cost int
+	err := q.QueryRow("SELECT cost_method, stock, cost FROM product WHERE id = $1 FOR UPDATE", productID).Scan(&method, &stock, &cost)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("Product %d not found", productID)
+		}
+		return err
+	}
+	if method == models.CostFIFO {
+		_, err := q.Exec("INSERT INTO cost_layers (product_id, goods_receipt_id, quantity, remaining, unit_cost) VALUES ($1, $2, $3, $3, $4)", productID, receiptID, quantity, unitCost)
+		if err != nil {
+			return err
+		}
+		return refreshFIFOCost(q, productID)
+	}
+	if stock < 0 {
+		stock = 0
+	}
+	value := int64(stock.Amount(cost)) + int64(quantity.Amount(unitCost))
+	total := int64(stock + quantity)
+	if total <= 0 {
+		return nil
+	}
+	average := (value*models.QuantityScale + total/2) / total
+	_, err = q.Exec("UPDATE product SET cost = $1 WHERE id = $2", average, productID)
+	return err
+}
+
+// consumeCost returns the cost of goods sold for quantity base units going
+// out of stock. A FIFO product uses up its oldest layers first; stock that
+// no delivery accounts for, such as opening stock, is valued at the
+// product's cost.
+func consumeCost(q queryer, productID int, quantity models.Quantity) (int, error) {
+	var method string
+	var cost int
+	err := q.QueryRow("SELECT cost_method, cost FROM product WHERE id = $1 FOR UPDATE", productID).Scan(&method, &cost)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("Product %d not found", productID)
+		}
+		return 0, err
+	}
+	if method != models.CostFIFO || quantity <= 0 {
+		return quantity.Amount(cost), nil
+	}
+
+	type layer struct {
+		id        int
+		remaining models.Quantity
+		unitCost  int
+	}
+	rows, err := q.Query("SELECT id, remaining, unit_cost FROM cost_layers WHERE product_id = $1 AND remaining > 0 ORDER BY id FOR UPDATE", productID)
+	if err != nil {
+		return 0, err
+	}
+	var layers []layer
+	for rows.Next() {
+		var l layer
+		if err := rows.Scan(&l.id, &l.remaining, &l.unitCost); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		layers = append(layers, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	total, left := 0, quantity
+	for _, l := range layers {
+		if left == 0 {
+			break
+		}
+		take := min(l.remaining, left)
+		if _, err := q.Exec("UPDATE cost_layers SET remaining = remaining - $1 WHERE id = $2", take, l.id); err != nil {
+			return 0, err
+		}
+		total += take.Amount(l.unitCost)
+		left -= take
+	}
+	total += left.Amount(cost)
+	return total, refreshFIFOCost(q, productID)
+}
+
+// adjustCost keeps a product's cost in step with stock that moves by delta
+// without being bought or sold, such as a count or a correction. Stock that
+// goes out uses up cost layers, stock that turns up comes in at the
+// product's current cost. It returns the value of the change; call it
+// before the stock itself is adjusted.
+func adjustCost(q queryer, productID int, delta models.Quantity) (int, error) {
+	if delta < 0 {
+		cost, err := consumeCost(q, productID, -delta)
+		return -cost, err
+	}
+	var cost int
+	if err := q.QueryRow("SELECT cost FROM product WHERE id = $1 FOR UPDATE", productID).Scan(&cost); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("Product %d not found", productID)
+		}
+		return 0, err
+	}
+	if delta == 0 {
+		return 0, nil
+	}
+	return delta.Amount(cost), receiveCost(q, productID, delta, cost, nil)
+}
+
+// estimateCost returns what consumeCost would charge for quantity base
+// units without using up any layers, so a value can be shown before the
+// stock goes out.
+func estimateCost(q queryer, productID int, quantity models.Quantity) (int, error) {
+	var method string
+	var cost int
+	err := q.QueryRow("SELECT cost_method, cost FROM product WHERE id = $1 FOR UPDATE", productID).Scan(&method, &cost)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("Product %d not found", productID)
+		}
+		return 0, err
+	}
+	if method != models.CostFIFO || quantity <= 0 {
+		return quantity.Amount(cost), nil
+	}
+
+	rows, err := q.Query("SELECT remaining, unit_cost FROM cost_layers WHERE product_id = $1 AND remaining > 0 ORDER BY id", productID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	total, left := 0, quantity
+	for left > 0 && rows.Next() {
+		var remaining models.Quantity
+		var unitCost int
+		if err := rows.Scan(&remaining, &unitCost); err != nil {
+			return 0, err
+		}
+		take := min(remaining, left)
+		total += take.Amount(unitCost)
+		left -= take
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return total + left.Amount(cost), nil
+}
+
+// checkCostMethod refuses to switch a product to another cost method while
+// FIFO cost layers still hold stock, as the new method would ignore them.
+func checkCostMethod(q queryer, productID int, method string) error {
+	var current string
+	var layered bool
+	err := q.QueryRow("SELECT cost_method, EXISTS(SELECT 1 FROM cost_layers WHERE product_id = $1 AND remaining > 0) FROM product WHERE id = $1 FOR UPDATE", productID).Scan(&current, &layered)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("Product %d not found", productID)
+		}
+		return err
+	}
+	if method != current && layered {
+		return errors.New("Cost method cannot change while cost layers still hold stock")
+	}
+	return nil
+}
+
+// refreshFIFOCost sets the cost of a FIFO product to the average of its
+// remaining layers, keeping the last cost once they are used up.
+func refreshFIFOCost(q queryer, productID int) error {
+	_, err := q.Exec(`UPDATE product SET cost = COALESCE((SELECT ROUND(SUM(remaining * unit_cost) / NULLIF(SUM(remaining), 0))
+		FROM cost_layers WHERE product_id = $1 AND remaining > 0), cost) WHERE id = $1`, productID)
+	return err
+}
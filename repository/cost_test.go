@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+	"gokasir-api/models"
+	"testing"
+)
+
+// receive orders and receives quantity of a product at unitCost.
+func receive(t *testing.T, db *sql.DB, productID, outletID int, quantity models.Quantity, unitCost int) {
+	t.Helper()
+	po := newPurchaseOrder(t, db, outletID, models.PurchaseOrderItem{ProductID: productID, Quantity: quantity, UnitCost: unitCost})
+	_, err := NewPurchaseOrderRepository(db).ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+		ReceivedBy: "test",
+		Items:      []models.ReceiveItem{{ItemID: po.Items[0].ID, Quantity: quantity}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFIFOCost(t *testing.T) {
+	db := testDB(t)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{CostMethod: models.CostFIFO})
+	receive(t, db, product, outlet, models.Qty(10), 1000)
+	receive(t, db, product, outlet, models.Qty(10), 1600)
+	if cost := costOf(t, db, product); cost != 1300 {
+		t.Errorf("cost %d, want 1300 over both layers", cost)
+	}
+
+	sale, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(12)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := sale.Details[0]; d.Cost != 13200 {
+		t.Errorf("cost of goods sold %d, want the oldest layer first: 13200", d.Cost)
+	}
+	if cost := costOf(t, db, product); cost != 1600 {
+		t.Errorf("cost %d, want the 1600 of the layer left", cost)
+	}
+
+	// Two come back at the 1100 they were sold at.
+	_, err = checkout(db).RefundTransaction(sale.ID, &models.RefundRequest{
+		Reason:     "test",
+		RefundedBy: "test",
+		Method:     models.PaymentCash,
+		Items:      []models.RefundItem{{DetailID: sale.Details[0].ID, Quantity: models.Qty(2)}},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost := costOf(t, db, product); cost != 1500 {
+		t.Errorf("cost %d after the refund, want 1500", cost)
+	}
+
+	// A shortfall found on the shelf uses up the oldest layer.
+	stockUp(t, db, product, outlet, models.Qty(7))
+	if cost := costOf(t, db, product); cost != 1457 {
+		t.Errorf("cost %d after the correction, want 1457", cost)
+	}
+	var layered models.Quantity
+	if err := db.QueryRow("SELECT SUM(remaining) FROM cost_layers WHERE product_id = $1", product).Scan(&layered); err != nil {
+		t.Fatal(err)
+	}
+	if layered != stockOf(t, db, product, outlet) {
+		t.Errorf("layers hold %s, stock is %s", layered, stockOf(t, db, product, outlet))
+	}
+
+	average := models.CostAverage
+	if _, err := NewProductRepository(db).PatchProduct(product, &models.PatchProductRequest{CostMethod: &average}); err == nil {
+		t.Error("switched to average cost while layers hold stock")
+	}
+}
+
+func TestAverageCostCorrections(t *testing.T) {
+	db := testDB(t)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{Cost: 2000})
+	stockUp(t, db, product, outlet, models.Qty(10))
+	receive(t, db, product, outlet, models.Qty(10), 3000)
+	if cost := costOf(t, db, product); cost != 2500 {
+		t.Errorf("cost %d, want 2500", cost)
+	}
+	// Stock that turns up comes in at the current cost.
+	stockUp(t, db, product, outlet, models.Qty(25))
+	if cost := costOf(t, db, product); cost != 2500 {
+		t.Errorf("cost %d after the correction, want 2500", cost)
+	}
+	sale, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(4)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := sale.Details[0]; d.Cost != 10000 {
+		t.Errorf("cost of goods sold %d, want 10000", d.Cost)
+	}
+}
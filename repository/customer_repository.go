@@ -0,0 +1,14 @@
+package repository
+
+import "gokasir-api/models"
+
+type CustomerRepository interface {
+	FindAllCustomer(search string) ([]models.Customer, error)
+	CreateCustomer(req *models.Customer) error
+	FindCustomerByID(id int) (*models.Customer, error)
+	UpdateCustomer(id int, req *models.Customer) error
+	PatchCustomer(id int, req *models.PatchCustomerRequest) (*models.Customer, error)
+	DeleteCustomer(id int) error
+	FindCustomerTransactions(id int) ([]models.Transaction, error)
+	FindPointEntries(id int) ([]models.PointEntry, error)
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"log"
+	"strings"
+)
+
+type CustomerRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) CustomerRepository {
+	return &CustomerRepositoryImpl{db: db}
+}
+
+const customerColumns = "id, name, phone, email, tier, customer_group, points, total_spent, created_at"
+
+func customerFields(c *models.Customer) []any {
+	return []any{&c.ID, &c.Name, &c.Phone, &c.Email, &c.Tier, &c.Group, &c.Points, &c.TotalSpent, &c.CreatedAt}
+}
+
+func (r *CustomerRepositoryImpl) FindAllCustomer(search string) ([]models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customers"
+	var args []any
+	if search != "" {
+		query += " WHERE name ILIKE $1 OR phone ILIKE $1 OR email ILIKE $1"
+		args = append(args, "%"+search+"%")
+	}
+	rows, err := r.db.Query(query+" ORDER BY id", args...)
+	if err != nil {
+		log.Printf("Error getting all customer: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var customers []models.Customer
+	for rows.Next() {
+		var c models.Customer
+		if err := rows.Scan(customerFields(&c)...); err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+	return customers, nil
+}
+
+func (r *CustomerRepositoryImpl) CreateCustomer(req *models.Customer) error {
+	err := r.db.QueryRow("INSERT INTO customers(name, phone, email, tier, customer_group) VALUES($1, $2, $3, $4, $5) RETURNING id, created_at", req.Name, req.Phone, req.Email, req.Tier, req.Group).Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating customer: %v", err)
+	}
+	return err
+}
+
+func (r *CustomerRepositoryImpl) FindCustomerByID(id int) (*models.Customer, error) {
+	var c models.Customer
+	if err := r.db.QueryRow("SELECT "+customerColumns+" FROM customers WHERE id = $1", id).Scan(customerFields(&c)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Customer not found")
+		}
+		log.Printf("Error getting single customer: %v", err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CustomerRepositoryImpl) UpdateCustomer(id int, req *models.Customer) error {
+	result, err := r.db.Exec("UPDATE customers SET name = $1, phone = $2, email = $3, customer_group = $4 WHERE id = $5", req.Name, req.Phone, req.Email, req.Group, id)
+	if err != nil {
+		log.Printf("Error update customer: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Customer not found")
+	}
+	return nil
+}
+
+func (r *CustomerRepositoryImpl) PatchCustomer(id int, req *models.PatchCustomerRequest) (*models.Customer, error) {
+	// Dynamic query
+	query := "UPDATE customers SET "
+	var args []any
+	var updates []string
+	argCount := 1
+
+	if req.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argCount))
+		args = append(args, req.Name)
+		argCount++
+	}
+	if req.Phone != nil {
+		updates = append(updates, fmt.Sprintf("phone = $%d", argCount))
+		args = append(args, req.Phone)
+		argCount++
+	}
+	if req.Email != nil {
+		updates = append(updates, fmt.Sprintf("email = $%d", argCount))
+		args = append(args, req.Email)
+		argCount++
+	}
+	if req.Group != nil {
+		updates = append(updates, fmt.Sprintf("customer_group = $%d", argCount))
+		args = append(args, req.Group)
+		argCount++
+	}
+	if len(updates) == 0 {
+		return r.FindCustomerByID(id)
+	}
+	query += strings.Join(updates, ", ") + fmt.Sprintf(" WHERE id = $%d", argCount)
+	args = append(args, id)
+	if _, err := r.db.Exec(query, args...); err != nil {
+		log.Printf("Error patch customer: %v", err)
+		return nil, err
+	}
+	return r.FindCustomerByID(id)
+}
+
+func (r *CustomerRepositoryImpl) DeleteCustomer(id int) error {
+	var used bool
+	if err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM transactions WHERE customer_id = $1)", id).Scan(&used); err != nil {
+		return err
+	}
+	if used {
+		return models.ErrCustomerInUse
+	}
+	result, err := r.db.Exec("DELETE FROM customers WHERE id = $1", id)
+	if err != nil {
+		log.Printf("Error delete customer: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Customer not found")
+	}
+	return nil
+}
+
+// FindCustomerTransactions returns the customer's sales, voids and refunds
+// without line details, newest first.
+func (r *CustomerRepositoryImpl) FindCustomerTransactions(id int) ([]models.Transaction, error) {
+	rows, err := r.db.Query("SELECT id, type, total_amount, paid_amount, change_amount, points_earned, points_redeemed, created_at FROM transactions WHERE customer_id = $1 ORDER BY created_at DESC", id)
+	if err != nil {
+		log.Printf("Error getting customer transactions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	transactions := make([]models.Transaction, 0)
+	for rows.Next() {
+		t := models.Transaction{CustomerID: &id}
+		if err := rows.Scan(&t.ID, &t.Type, &t.TotalAmount, &t.PaidAmount, &t.ChangeAmount, &t.PointsEarned, &t.PointsRedeemed, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		t.GrandTotal = t.TotalAmount
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+func (r *CustomerRepositoryImpl) FindPointEntries(id int) ([]models.PointEntry, error) {
+	rows, err := r.db.Query("SELECT id, customer_id, transaction_id, points, reason, created_at FROM point_ledger WHERE customer_id = $1 ORDER BY id", id)
+	if err != nil {
+		log.Printf("Error getting point ledger: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]models.PointEntry, 0)
+	for rows.Next() {
+		var e models.PointEntry
+		if err := rows.Scan(&e.ID, &e.CustomerID, &e.TransactionID, &e.Points, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "gokasir-api/models"
+
+type InventoryRepository interface {
+	FindLowStock(outletID *int, productIDs []int) ([]models.LowStockItem, error)
+	IngredientUsage(start, end string, outletID *int) ([]models.IngredientUsage, error)
+	FindExpiringLots(days int, outletID *int) ([]models.ExpiringLot, error)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"gokasir-api/models"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+type InventoryRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) InventoryRepository {
+	return &InventoryRepositoryImpl{db: db}
+}
+
+// FindLowStock lists products with a minimum stock that are at or below it,
+// at one outlet or over the total stock. productIDs narrows the search when
+// not empty.
+func (r *InventoryRepositoryImpl) FindLowStock(outletID *int, productIDs []int) ([]models.LowStockItem, error) {
+	rows, err := r.db.Query(`SELECT p.id, p.name, CASE WHEN $1::int IS NULL THEN p.stock ELSE COALESCE(s.stock, 0) END AS current, p.min_stock, p.reorder_qty
+		FROM product p
+		LEFT JOIN product_stock s ON s.product_id = p.id AND s.outlet_id = $1
+		WHERE p.min_stock > 0
+		AND CASE WHEN $1::int IS NULL THEN p.stock ELSE COALESCE(s.stock, 0) END <= p.min_stock
+		AND (cardinality($2::int[]) = 0 OR p.id = ANY($2))
+		ORDER BY current, p.id`, outletID, pq.Array(productIDs))
+	if err != nil {
+		log.Printf("Error getting low stock: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]models.LowStockItem, 0)
+	for rows.Next() {
+		item := models.LowStockItem{OutletID: outletID}
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Stock, &item.MinStock, &item.ReorderQty); err != nil {
+			return nil, err
+		}
+		item.SuggestedOrder = item.ReorderQty
+		if item.SuggestedOrder == 0 {
+			item.SuggestedOrder = item.MinStock - item.Stock + models.Qty(1)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// IngredientUsage adds up per ingredient what recipes used in the range and
+// what stock takes approved in the range adjusted.
+func (r *InventoryRepositoryImpl) IngredientUsage(start, end string, outletID *int) ([]models.IngredientUsage, error) {
+	rows, err := r.db.Query(`SELECT p.id, p.name, p.unit,
+			COALESCE((SELECT SUM(di.quantity) FROM transaction_detail_ingredients di
+				INNER JOIN transaction_details d ON d.id = di.detail_id
+				INNER JOIN transactions t ON t.id = d.transaction_id
+				WHERE di.ingredient_id = p.id AND t.created_at >= $1::date AND t.created_at < $2::date + 1 AND ($3::int IS NULL OR t.outlet_id = $3)), 0),
+			COALESCE((SELECT SUM(m.delta) FROM stock_movements m
+				WHERE m.product_id = p.id AND m.reference_type = $4 AND m.created_at >= $1::date AND m.created_at < $2::date + 1 AND ($3::int IS NULL OR m.outlet_id = $3)), 0)
+		FROM product p WHERE p.is_ingredient ORDER BY p.id`, start, end, outletID, models.RefStockTake)
+	if err != nil {
+		log.Printf("Error getting ingredient usage: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	usage := make([]models.IngredientUsage, 0)
+	for rows.Next() {
+		var u models.IngredientUsage
+		if err := rows.Scan(&u.IngredientID, &u.Name, &u.Unit, &u.TheoreticalUsage, &u.StockTakeVariance); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// FindExpiringLots lists the lots still in stock that expire within days
+// from today, including those already past their expiry date, soonest
+// first.
+func (r *InventoryRepositoryImpl) FindExpiringLots(days int, outletID *int) ([]models.ExpiringLot, error) {
+	rows, err := r.db.Query("SELECT "+lotColumns+`, l.expiry_date - CURRENT_DATE, p.cost
+		WHERE l.quantity > 0 AND l.expiry_date <= CURRENT_DATE + $1::int AND ($2::int IS NULL OR l.outlet_id = $2)
+		ORDER BY l.expiry_date, l.id`, days, outletID)
+	if err != nil {
+		log.Printf("Error getting expiring lots: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	lots := make([]models.ExpiringLot, 0)
+	for rows.Next() {
+		var l models.ExpiringLot
+		var cost int
+		if err := rows.Scan(append(lotFields(&l.StockLot), &l.DaysLeft, &cost)...); err != nil {
+			return nil, err
+		}
+		l.Expired = l.DaysLeft < 0
+		l.Value = l.Quantity.Amount(cost)
+		lots = append(lots, l)
+	}
+	return lots, rows.Err()
+}
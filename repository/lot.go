@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"fmt"
+	"gokasir-api/models"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+const lotColumns = `l.id, l.product_id, p.name, l.outlet_id, l.batch_number, COALESCE(to_char(l.expiry_date, 'YYYY-MM-DD'), ''), l.quantity, l.received_at
+	FROM stock_lots l INNER JOIN product p ON p.id = l.product_id`
+
+func lotFields(l *models.StockLot) []any {
+	return []any{&l.ID, &l.ProductID, &l.ProductName, &l.OutletID, &l.BatchNumber, &l.ExpiryDate, &l.Quantity, &l.ReceivedAt}
+}
+
+// addToLot puts quantity into the lot of a batch of a product at an outlet
+// and returns the lot's ID and expiry date. The lot is created the first
+// time the batch arrives; its expiry date cannot change afterwards.
+func addToLot(q queryer, productID int, outletID *int, batch, expiry string, quantity models.Quantity) (int, string, error) {
+	var lotID int
+	var current string
+	err := q.QueryRow(`INSERT INTO stock_lots (product_id, outlet_id, batch_number, expiry_date, quantity)
+		VALUES ($1, $2, $3, NULLIF($4, '')::date, $5)
+		ON CONFLICT (product_id, COALESCE(outlet_id, 0), batch_number) DO UPDATE
+		SET quantity = stock_lots.quantity + EXCLUDED.quantity, expiry_date = COALESCE(stock_lots.expiry_date, EXCLUDED.expiry_date)
+		RETURNING id, COALESCE(to_char(expiry_date, 'YYYY-MM-DD'), '')`, productID, outletID, batch, expiry, quantity).Scan(&lotID, &current)
+	if err != nil {
+		log.Printf("Error adding to stock lot: %v", err)
+		return 0, "", err
+	}
+	if expiry != "" && expiry != current {
+		return 0, "", fmt.Errorf("Batch %s already expires on %s", batch, current)
+	}
+	return lotID, current, nil
+}
+
+// takeFromLots takes quantity of a product out of its lots at an outlet,
+// first expiring first out, and returns what it took from each lot.
+// Expired lots are never taken from. What the lots do not cover comes from
+// stock outside any lot. Call it before the stock itself is adjusted.
+func takeFromLots(q queryer, productID int, outletID *int, quantity models.Quantity) ([]models.DetailLot, error) {
+	type lot struct {
+		models.DetailLot
+		expired bool
+	}
+	rows, err := q.Query(`SELECT id, batch_number, COALESCE(to_char(expiry_date, 'YYYY-MM-DD'), ''), quantity, COALESCE(expiry_date < CURRENT_DATE, false)
+		FROM stock_lots WHERE product_id = $1 AND outlet_id IS NOT DISTINCT FROM $2 AND quantity > 0
+		ORDER BY expiry_date NULLS LAST, id FOR UPDATE`, productID, outletID)
+	if err != nil {
+		return nil, err
+	}
+	var lots []lot
+	for rows.Next() {
+		l := lot{DetailLot: models.DetailLot{ProductID: productID}}
+		if err := rows.Scan(&l.LotID, &l.BatchNumber, &l.ExpiryDate, &l.Quantity, &l.expired); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(lots) == 0 {
+		return nil, nil
+	}
+
+	var taken []models.DetailLot
+	var lotted, expired models.Quantity
+	left := quantity
+	for _, l := range lots {
+		lotted += l.Quantity
+		if l.expired {
+			expired += l.Quantity
+			continue
+		}
+		if left == 0 {
+			continue
+		}
+		take := min(l.Quantity, left)
+		if _, err := q.Exec("UPDATE stock_lots SET quantity = quantity - $1 WHERE id = $2", take, l.LotID); err != nil {
+			return nil, err
+		}
+		l.Quantity = take
+		taken = append(taken, l.DetailLot)
+		left -= take
+	}
+	if left > 0 && expired > 0 {
+		stock, err := stockAt(q, productID, outletID)
+		if err != nil {
+			return nil, err
+		}
+		if left > stock-lotted {
+			var name string
+			if err := q.QueryRow("SELECT name FROM product WHERE id = $1", productID).Scan(&name); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("Only %s of %s is not past its expiry date", quantity-left+max(stock-lotted, 0), name)
+		}
+	}
+	return taken, nil
+}
+
+// returnToLots puts quantity of a product refunded on a line back into the
+// lots the sale line took it from, latest expiring first, and records them
+// against the refund line as negative quantities. What the lots cannot
+// take back goes to stock outside any lot.
+func returnToLots(q queryer, detailID, saleDetailID, productID int, quantity models.Quantity) ([]models.DetailLot, error) {
+	rows, err := q.Query(`SELECT dl.lot_id, l.batch_number, COALESCE(to_char(l.expiry_date, 'YYYY-MM-DD'), ''), SUM(dl.quantity)
+		FROM transaction_detail_lots dl
+		INNER JOIN transaction_details d ON d.id = dl.detail_id
+		INNER JOIN stock_lots l ON l.id = dl.lot_id
+		WHERE l.product_id = $2 AND (d.id = $1 OR d.refund_of_detail_id = $1)
+		GROUP BY dl.lot_id, l.batch_number, l.expiry_date
+		HAVING SUM(dl.quantity) > 0
+		ORDER BY l.expiry_date DESC NULLS FIRST, dl.lot_id DESC`, saleDetailID, productID)
+	if err != nil {
+		return nil, err
+	}
+	var lots []models.DetailLot
+	for rows.Next() {
+		l := models.DetailLot{ProductID: productID}
+		if err := rows.Scan(&l.LotID, &l.BatchNumber, &l.ExpiryDate, &l.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var returned []models.DetailLot
+	left := quantity
+	for _, l := range lots {
+		if left == 0 {
+			break
+		}
+		give := min(l.Quantity, left)
+		if _, err := q.Exec("UPDATE stock_lots SET quantity = quantity + $1 WHERE id = $2", give, l.LotID); err != nil {
+			return nil, err
+		}
+		if err := recordDetailLot(q, detailID, l.LotID, -give); err != nil {
+			return nil, err
+		}
+		l.Quantity = -give
+		returned = append(returned, l)
+		left -= give
+	}
+	return returned, nil
+}
+
+func recordDetailLot(q queryer, detailID, lotID int, quantity models.Quantity) error {
+	_, err := q.Exec(`INSERT INTO transaction_detail_lots (detail_id, lot_id, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (detail_id, lot_id) DO UPDATE SET quantity = transaction_detail_lots.quantity + EXCLUDED.quantity`, detailID, lotID, quantity)
+	return err
+}
+
+// sellFromLots takes the stock of a transaction line out of the product's
+// lots and records the lots against the line.
+func sellFromLots(q queryer, detailID, productID int, outletID *int, quantity models.Quantity) ([]models.DetailLot, error) {
+	lots, err := takeFromLots(q, productID, outletID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lots {
+		if err := recordDetailLot(q, detailID, l.LotID, l.Quantity); err != nil {
+			return nil, err
+		}
+	}
+	return lots, nil
+}
+
+// discardFromLots takes quantity of a product out of its lots at an outlet
+// for a write-off and returns what it took from each lot. Unlike a sale it
+// takes from expired lots too, first expiring first. With a batch only that
+// batch's lot is taken from; otherwise what the lots do not cover comes
+// from stock outside any lot.
+func discardFromLots(q queryer, productID int, outletID *int, batch string, quantity models.Quantity) ([]models.DetailLot, error) {
+	rows, err := q.Query(`SELECT id, batch_number, COALESCE(to_char(expiry_date, 'YYYY-MM-DD'), ''), quantity
+		FROM stock_lots WHERE product_id = $1 AND outlet_id IS NOT DISTINCT FROM $2 AND quantity > 0 AND ($3 = '' OR batch_number = $3)
+		ORDER BY expiry_date NULLS LAST, id FOR UPDATE`, productID, outletID, batch)
+	if err != nil {
+		return nil, err
+	}
+	var lots []models.DetailLot
+	for rows.Next() {
+		l := models.DetailLot{ProductID: productID}
+		if err := rows.Scan(&l.LotID, &l.BatchNumber, &l.ExpiryDate, &l.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var taken []models.DetailLot
+	left := quantity
+	for _, l := range lots {
+		if left == 0 {
+			break
+		}
+		take := min(l.Quantity, left)
+		if _, err := q.Exec("UPDATE stock_lots SET quantity = quantity - $1 WHERE id = $2", take, l.LotID); err != nil {
+			return nil, err
+		}
+		l.Quantity = take
+		taken = append(taken, l)
+		left -= take
+	}
+	if batch != "" && left > 0 {
+		return nil, fmt.Errorf("Batch %s only has %s in stock", batch, quantity-left)
+	}
+	return taken, nil
+}
+
+// trimLots shrinks the lots of a product at an outlet, earliest expiring
+// first, until they hold no more than stock. It keeps the lots in line with
+// stock that went out without naming a batch, such as a manual adjustment.
+func trimLots(q queryer, productID int, outletID *int, stock models.Quantity) error {
+	type lot struct {
+		id       int
+		quantity models.Quantity
+	}
+	rows, err := q.Query(`SELECT id, quantity FROM stock_lots WHERE product_id = $1 AND outlet_id IS NOT DISTINCT FROM $2 AND quantity > 0
+		ORDER BY expiry_date NULLS LAST, id FOR UPDATE`, productID, outletID)
+	if err != nil {
+		return err
+	}
+	var lots []lot
+	var lotted models.Quantity
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		lots = append(lots, l)
+		lotted += l.quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	excess := lotted - max(stock, 0)
+	for _, l := range lots {
+		if excess <= 0 {
+			break
+		}
+		take := min(l.quantity, excess)
+		if _, err := q.Exec("UPDATE stock_lots SET quantity = quantity - $1 WHERE id = $2", take, l.id); err != nil {
+			return err
+		}
+		excess -= take
+	}
+	return nil
+}
+
+// detailLots loads the lots recorded for transaction lines, keyed by
+// detail ID.
+func detailLots(q queryer, detailIDs []int) (map[int][]models.DetailLot, error) {
+	lots := make(map[int][]models.DetailLot)
+	if len(detailIDs) == 0 {
+		return lots, nil
+	}
+	rows, err := q.Query(`SELECT dl.detail_id, dl.lot_id, l.product_id, l.batch_number, COALESCE(to_char(l.expiry_date, 'YYYY-MM-DD'), ''), dl.quantity
+		FROM transaction_detail_lots dl INNER JOIN stock_lots l ON l.id = dl.lot_id
+		WHERE dl.detail_id = ANY($1) ORDER BY dl.detail_id, l.expiry_date NULLS LAST, dl.lot_id`, pq.Array(detailIDs))
+	if err != nil {
+		log.Printf("Error getting detail lots: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var detailID int
+		var l models.DetailLot
+		if err := rows.Scan(&detailID, &l.LotID, &l.ProductID, &l.BatchNumber, &l.ExpiryDate, &l.Quantity); err != nil {
+			return nil, err
+		}
+		lots[detailID] = append(lots[detailID], l)
+	}
+	return lots, rows.Err()
+}
+
+// productLots lists the lots of a product still in stock, first expiring
+// first, at an outlet or over all outlets when outletID is nil.
+func productLots(q queryer, productID int, outletID *int) ([]models.StockLot, error) {
+	rows, err := q.Query("SELECT "+lotColumns+` WHERE l.product_id = $1 AND ($2::int IS NULL OR l.outlet_id = $2) AND l.quantity > 0
+		ORDER BY l.expiry_date NULLS LAST, l.id`, productID, outletID)
+	if err != nil {
+		log.Printf("Error getting stock lots: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	lots := make([]models.StockLot, 0)
+	for rows.Next() {
+		var l models.StockLot
+		if err := rows.Scan(lotFields(&l)...); err != nil {
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	return lots, rows.Err()
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"gokasir-api/models"
+	"testing"
+	"time"
+)
+
+// inDays is the date days from today.
+func inDays(days int) string {
+	return time.Now().AddDate(0, 0, days).Format(models.DateLayout)
+}
+
+func TestLotsFirstExpiringFirstOut(t *testing.T) {
+	db := testDB(t)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{})
+	po := newPurchaseOrder(t, db, outlet, models.PurchaseOrderItem{ProductID: product, Quantity: models.Qty(15), UnitCost: 1000})
+	receiveLot := func(quantity models.Quantity, batch, expiry string) error {
+		_, err := NewPurchaseOrderRepository(db).ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+			ReceivedBy: "test",
+			Items:      []models.ReceiveItem{{ItemID: po.Items[0].ID, Quantity: quantity, BatchNumber: batch, ExpiryDate: expiry}},
+		})
+		return err
+	}
+	late, soon := inDays(60), inDays(30)
+	if err := receiveLot(models.Qty(5), "LATE", late); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiveLot(models.Qty(5), "SOON", soon); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiveLot(models.Qty(1), "SOON", inDays(31)); err == nil {
+		t.Error("received a batch again with another expiry date")
+	}
+
+	sale, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(7)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.DetailLot{{BatchNumber: "SOON", ExpiryDate: soon, Quantity: models.Qty(5)}, {BatchNumber: "LATE", ExpiryDate: late, Quantity: models.Qty(2)}}
+	lots := sale.Details[0].Lots
+	if len(lots) != len(want) {
+		t.Fatalf("sold from %d lots, want %d", len(lots), len(want))
+	}
+	for i, l := range lots {
+		if l.BatchNumber != want[i].BatchNumber || l.ExpiryDate != want[i].ExpiryDate || l.Quantity != want[i].Quantity {
+			t.Errorf("took %s of %s expiring %s, want %s of %s", l.Quantity, l.BatchNumber, l.ExpiryDate, want[i].Quantity, want[i].BatchNumber)
+		}
+	}
+
+	void(t, db, sale.ID)
+	got, err := NewProductRepository(db).FindLots(product, &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Lots) != 2 || got.Lots[0].Quantity != models.Qty(5) || got.Lots[1].Quantity != models.Qty(5) || got.Unlotted != 0 {
+		t.Errorf("lots after the void: %+v, %s outside any lot", got.Lots, got.Unlotted)
+	}
+}
+
+func TestExpiredLots(t *testing.T) {
+	db := testDB(t)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{Cost: 1000})
+	po := newPurchaseOrder(t, db, outlet, models.PurchaseOrderItem{ProductID: product, Quantity: models.Qty(3), UnitCost: 1000})
+	_, err := NewPurchaseOrderRepository(db).ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+		ReceivedBy: "test",
+		Items:      []models.ReceiveItem{{ItemID: po.Items[0].ID, Quantity: models.Qty(3), BatchNumber: "OLD", ExpiryDate: inDays(-10)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(1)}); err == nil {
+		t.Error("sold from an expired lot")
+	}
+	// Stock outside any lot still sells.
+	stockUp(t, db, product, outlet, models.Qty(5))
+	if _, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(2)}); err != nil {
+		t.Fatal(err)
+	}
+
+	expiring, err := NewInventoryRepository(db).FindExpiringLots(0, &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expiring) != 1 || !expiring[0].Expired || expiring[0].DaysLeft != -10 || expiring[0].Value != 3000 {
+		t.Errorf("expiring lots %+v", expiring)
+	}
+
+	// Stock that goes without naming a batch leaves the lots no larger than the stock.
+	stockUp(t, db, product, outlet, models.Qty(1))
+	got, err := NewProductRepository(db).FindLots(product, &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Lots) != 1 || got.Lots[0].Quantity != models.Qty(1) || got.Unlotted != 0 {
+		t.Errorf("lots after the correction: %+v, %s outside any lot", got.Lots, got.Unlotted)
+	}
+}
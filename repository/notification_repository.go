@@ -0,0 +1,9 @@
+package repository
+
+import "gokasir-api/models"
+
+type NotificationRepository interface {
+	CreateNotification(req *models.Notification) error
+	FindAllNotification(unreadOnly bool) ([]models.Notification, error)
+	MarkRead(id int) error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"gokasir-api/models"
+	"log"
+)
+
+type NotificationRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &NotificationRepositoryImpl{db: db}
+}
+
+func (r *NotificationRepositoryImpl) CreateNotification(req *models.Notification) error {
+	var data any
+	if len(req.Data) > 0 {
+		data = string(req.Data)
+	}
+	err := r.db.QueryRow("INSERT INTO notifications(type, title, message, data) VALUES($1, $2, $3, $4) RETURNING id, created_at", req.Type, req.Title, req.Message, data).Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating notification: %v", err)
+	}
+	return err
+}
+
+func (r *NotificationRepositoryImpl) FindAllNotification(unreadOnly bool) ([]models.Notification, error) {
+	rows, err := r.db.Query("SELECT id, type, title, message, data, read_at, created_at FROM notifications WHERE NOT $1 OR read_at IS NULL ORDER BY id DESC LIMIT 100", unreadOnly)
+	if err != nil {
+		log.Printf("Error getting notifications: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	notifications := make([]models.Notification, 0)
+	for rows.Next() {
+		var n models.Notification
+		var data []byte
+		if err := rows.Scan(&n.ID, &n.Type, &n.Title, &n.Message, &data, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		n.Data = data
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+func (r *NotificationRepositoryImpl) MarkRead(id int) error {
+	result, err := r.db.Exec("UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP) WHERE id = $1", id)
+	if err != nil {
+		log.Printf("Error marking notification read: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Notification not found")
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "gokasir-api/models"
+
+type OutletRepository interface {
+	FindAllOutlet() ([]models.Outlet, error)
+	CreateOutlet(req *models.Outlet) error
+	FindOutletByID(id int) (*models.Outlet, error)
+	UpdateOutlet(id int, req *models.Outlet) error
+	DeleteOutlet(id int) error
+	FindOutletStock(id int) ([]models.OutletStock, error)
+	SetOutletStock(id, productID int, req *models.SetOutletStockRequest) error
+	SetOutletPrice(id, productID, price int) error
+	DeleteOutletPrice(id, productID int) error
+}
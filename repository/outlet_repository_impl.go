@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"gokasir-api/models"
+	"log"
+)
+
+type OutletRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewOutletRepository(db *sql.DB) OutletRepository {
+	return &OutletRepositoryImpl{db: db}
+}
+
+const outletColumns = "id, code, name, address, phone, receipt_footer, paper_width, is_default"
+
+func outletFields(o *models.Outlet) []any {
+	return []any{&o.ID, &o.Code, &o.Name, &o.Address, &o.Phone, &o.ReceiptFooter, &o.PaperWidth, &o.IsDefault}
+}
+
+func (r *OutletRepositoryImpl) FindAllOutlet() ([]models.Outlet, error) {
+	rows, err := r.db.Query("SELECT " + outletColumns + " FROM outlets ORDER BY id")
+	if err != nil {
+		log.Printf("Error getting all outlet: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var outlets []models.Outlet
+	for rows.Next() {
+		var o models.Outlet
+		if err := rows.Scan(outletFields(&o)...); err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, o)
+	}
+	return outlets, nil
+}
+
+func (r *OutletRepositoryImpl) CreateOutlet(req *models.Outlet) error {
+	err := r.db.QueryRow("INSERT INTO outlets(code, name, address, phone, receipt_footer, paper_width) VALUES($1, $2, $3, $4, $5, $6) RETURNING id", req.Code, req.Name, req.Address, req.Phone, req.ReceiptFooter, req.PaperWidth).Scan(&req.ID)
+	if err != nil {
+		log.Printf("Error creating outlet: %v", err)
+	}
+	return err
+}
+
+func (r *OutletRepositoryImpl) FindOutletByID(id int) (*models.Outlet, error) {
+	var o models.Outlet
+	if err := r.db.QueryRow("SELECT "+outletColumns+" FROM outlets WHERE id = $1", id).Scan(outletFields(&o)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Outlet not found")
+		}
+		log.Printf("Error getting single outlet: %v", err)
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *OutletRepositoryImpl) UpdateOutlet(id int, req *models.Outlet) error {
+	result, err := r.db.Exec("UPDATE outlets SET code = $1, name = $2, address = $3, phone = $4, receipt_footer = $5, paper_width = $6 WHERE id = $7", req.Code, req.Name, req.Address, req.Phone, req.ReceiptFooter, req.PaperWidth, id)
+	if err != nil {
+		log.Printf("Error update outlet: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Outlet not found")
+	}
+	return nil
+}
+
+func (r *OutletRepositoryImpl) DeleteOutlet(id int) error {
+	var used bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM outlets WHERE id = $1 AND is_default)
+		OR EXISTS(SELECT 1 FROM product_stock WHERE outlet_id = $1 AND stock <> 0)
+		OR EXISTS(SELECT 1 FROM transactions WHERE outlet_id = $1)`, id).Scan(&used)
+	if err != nil {
+		return err
+	}
+	if used {
+		return models.ErrOutletInUse
+	}
+	result, err := r.db.Exec("DELETE FROM outlets WHERE id = $1", id)
+	if err != nil {
+		log.Printf("Error delete outlet: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Outlet not found")
+	}
+	return nil
+}
+
+// FindOutletStock lists every product with its stock and effective price at the outlet.
+func (r *OutletRepositoryImpl) FindOutletStock(id int) ([]models.OutletStock, error) {
+	rows, err := r.db.Query(`SELECT p.id, p.name, COALESCE(s.stock, 0), COALESCE(op.price, p.price), op.price
+		FROM product p
+		LEFT JOIN product_stock s ON s.product_id = p.id AND s.outlet_id = $1
+		LEFT JOIN product_outlet_prices op ON op.product_id = p.id AND op.outlet_id = $1
+		ORDER BY p.id`, id)
+	if err != nil {
+		log.Printf("Error getting outlet stock: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	stocks := make([]models.OutletStock, 0)
+	for rows.Next() {
+		var s models.OutletStock
+		if err := rows.Scan(&s.ProductID, &s.ProductName, &s.Stock, &s.Price, &s.PriceOverride); err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, s)
+	}
+	return stocks, nil
+}
+
+// SetOutletStock overwrites the outlet's stock of a product, keeping the
+// product's total stock in line. The difference is booked as an adjustment.
+func (r *OutletRepositoryImpl) SetOutletStock(id, productID int, req *models.SetOutletStockRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := stockAt(tx, productID, &id)
+	if err != nil {
+		return err
+	}
+	if req.Stock == current {
+		return nil
+	}
+	if _, err := adjustCost(tx, productID, req.Stock-current); err != nil {
+		return err
+	}
+	_, err = adjustStock(tx, &models.StockMovement{
+		ProductID:     productID,
+		OutletID:      &id,
+		Delta:         req.Stock - current,
+		Reason:        models.StockAdjustment,
+		ReferenceType: models.RefOutlet,
+		ReferenceID:   &id,
+		CreatedBy:     req.UpdatedBy,
+	})
+	if err != nil {
+		log.Printf("Error set outlet stock: %v", err)
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *OutletRepositoryImpl) SetOutletPrice(id, productID, price int) error {
+	_, err := r.db.Exec(`INSERT INTO product_outlet_prices (product_id, outlet_id, price) VALUES ($1, $2, $3)
+		ON CONFLICT (product_id, outlet_id) DO UPDATE SET price = EXCLUDED.price`, productID, id, price)
+	if err != nil {
+		log.Printf("Error set outlet price: %v", err)
+	}
+	return err
+}
+
+func (r *OutletRepositoryImpl) DeleteOutletPrice(id, productID int) error {
+	_, err := r.db.Exec("DELETE FROM product_outlet_prices WHERE product_id = $1 AND outlet_id = $2", productID, id)
+	if err != nil {
+		log.Printf("Error delete outlet price: %v", err)
+	}
+	return err
+}
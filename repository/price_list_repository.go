@@ -0,0 +1,11 @@
+package repository
+
+import "gokasir-api/models"
+
+type PriceListRepository interface {
+	FindAllPriceList() ([]models.PriceList, error)
+	CreatePriceList(req *models.PriceList) error
+	FindPriceListByID(id int) (*models.PriceList, error)
+	UpdatePriceList(id int, req *models.PriceList) error
+	DeletePriceList(id int) error
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+type PriceListRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPriceListRepository(db *sql.DB) PriceListRepository {
+	return &PriceListRepositoryImpl{db: db}
+}
+
+func (r *PriceListRepositoryImpl) FindAllPriceList() ([]models.PriceList, error) {
+	rows, err := r.db.Query("SELECT id, name, customer_groups, created_at FROM price_lists ORDER BY id")
+	if err != nil {
+		log.Printf("Error getting all price list: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	lists := make([]models.PriceList, 0)
+	for rows.Next() {
+		var pl models.PriceList
+		if err := rows.Scan(&pl.ID, &pl.Name, pq.Array(&pl.CustomerGroups), &pl.CreatedAt); err != nil {
+			return nil, err
+		}
+		lists = append(lists, pl)
+	}
+	return lists, rows.Err()
+}
+
+func (r *PriceListRepositoryImpl) CreatePriceList(req *models.PriceList) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkCustomerGroups(tx, 0, req.CustomerGroups); err != nil {
+		return err
+	}
+	err = tx.QueryRow("INSERT INTO price_lists(name, customer_groups) VALUES($1, $2) RETURNING id, created_at", req.Name, pq.Array(req.CustomerGroups)).Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating price list: %v", err)
+		return err
+	}
+	if err := savePriceListItems(tx, req.ID, req.Items); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PriceListRepositoryImpl) FindPriceListByID(id int) (*models.PriceList, error) {
+	var pl models.PriceList
+	err := r.db.QueryRow("SELECT id, name, customer_groups, created_at FROM price_lists WHERE id = $1", id).Scan(&pl.ID, &pl.Name, pq.Array(&pl.CustomerGroups), &pl.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Price list not found")
+		}
+		log.Printf("Error getting single price list: %v", err)
+		return nil, err
+	}
+	rows, err := r.db.Query(`SELECT i.product_id, p.name, i.price FROM price_list_items i
+		INNER JOIN product p ON p.id = i.product_id WHERE i.price_list_id = $1 ORDER BY p.name`, id)
+	if err != nil {
+		log.Printf("Error getting price list items: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	pl.Items = make([]models.PriceListItem, 0)
+	for rows.Next() {
+		var item models.PriceListItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Price); err != nil {
+			return nil, err
+		}
+		pl.Items = append(pl.Items, item)
+	}
+	return &pl, rows.Err()
+}
+
+func (r *PriceListRepositoryImpl) UpdatePriceList(id int, req *models.PriceList) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkCustomerGroups(tx, id, req.CustomerGroups); err != nil {
+		return err
+	}
+	result, err := tx.Exec("UPDATE price_lists SET name = $1, customer_groups = $2 WHERE id = $3", req.Name, pq.Array(req.CustomerGroups), id)
+	if err != nil {
+		log.Printf("Error update price list: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Price list not found")
+	}
+	if _, err := tx.Exec("DELETE FROM price_list_items WHERE price_list_id = $1", id); err != nil {
+		return err
+	}
+	if err := savePriceListItems(tx, id, req.Items); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PriceListRepositoryImpl) DeletePriceList(id int) error {
+	result, err := r.db.Exec("DELETE FROM price_lists WHERE id = $1", id)
+	if err != nil {
+		log.Printf("Error delete price list: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Price list not found")
+	}
+	return nil
+}
+
+// checkCustomerGroups makes sure none of groups already has a price list
+// other than id, so a customer's price is never ambiguous.
+func checkCustomerGroups(tx *sql.Tx, id int, groups []string) error {
+	if _, err := tx.Exec("LOCK TABLE price_lists IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+	var taken bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM price_lists WHERE id <> $1 AND customer_groups && $2)", id, pq.Array(groups)).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return models.ErrDuplicateCustomerGroup
+	}
+	return nil
+}
+
+func savePriceListItems(tx *sql.Tx, id int, items []models.PriceListItem) error {
+	for _, item := range items {
+		_, err := tx.Exec("INSERT INTO price_list_items(price_list_id, product_id, price) VALUES($1, $2, $3)", id, item.ProductID, item.Price)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+				return fmt.Errorf("Product %d not found", item.ProductID)
+			}
+			log.Printf("Error saving price list item: %v", err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"errors"
+	"gokasir-api/models"
+	"testing"
+)
+
+func TestPriceTiers(t *testing.T) {
+	db := testDB(t)
+	repo := NewProductRepository(db)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{Price: 5000})
+	stockUp(t, db, product, outlet, models.Qty(200))
+	err := repo.SetPriceTiers(product, &models.SetPriceTiersRequest{Tiers: []models.PriceTier{
+		{MinQty: models.Qty(50), Price: 4000},
+		{MinQty: models.Qty(10), Price: 4500},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		quantities []models.Quantity
+		unitPrice  int
+		rule       string
+	}{
+		{"below the first break", []models.Quantity{models.Qty(9)}, 5000, models.PriceRuleStandard},
+		{"counted over the basket", []models.Quantity{models.Qty(6), models.Qty(6)}, 4500, models.PriceRuleTier},
+		{"the highest break reached", []models.Quantity{models.Qty(50)}, 4000, models.PriceRuleTier},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []models.CheckoutItem
+			for _, q := range tt.quantities {
+				items = append(items, models.CheckoutItem{ProductID: product, Quantity: q})
+			}
+			sale, err := sell(db, outlet, items...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range sale.Details {
+				if d.UnitPrice != tt.unitPrice || d.PriceRule != tt.rule {
+					t.Errorf("sold at %d by %s rule, want %d by %s", d.UnitPrice, d.PriceRule, tt.unitPrice, tt.rule)
+				}
+			}
+		})
+	}
+
+	// An outlet price below the tier wins.
+	if err := NewOutletRepository(db).SetOutletPrice(outlet, product, 4200); err != nil {
+		t.Fatal(err)
+	}
+	sale, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(12)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := sale.Details[0]; d.UnitPrice != 4200 || d.PriceRule != models.PriceRuleOutlet {
+		t.Errorf("sold at %d by %s rule, want the outlet's 4200", d.UnitPrice, d.PriceRule)
+	}
+}
+
+func TestPriceList(t *testing.T) {
+	db := testDB(t)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{Price: 5000})
+	stockUp(t, db, product, outlet, models.Qty(10))
+
+	group := unique("reseller-")
+	list := models.PriceList{Name: unique("Resellers "), CustomerGroups: []string{group}, Items: []models.PriceListItem{{ProductID: product, Price: 3800}}}
+	if err := NewPriceListRepository(db).CreatePriceList(&list); err != nil {
+		t.Fatal(err)
+	}
+	other := models.PriceList{Name: unique("Resellers "), CustomerGroups: []string{group}}
+	if err := NewPriceListRepository(db).CreatePriceList(&other); !errors.Is(err, models.ErrDuplicateCustomerGroup) {
+		t.Errorf("second price list for the group: %v", err)
+	}
+
+	customer := models.Customer{Name: "Reseller", Tier: "regular", Group: group}
+	if err := NewCustomerRepository(db).CreateCustomer(&customer); err != nil {
+		t.Fatal(err)
+	}
+	sale, err := checkout(db).CreateTransaction(&models.CheckoutRequest{
+		OutletID:   &outlet,
+		Cashier:    "test",
+		CustomerID: &customer.ID,
+		Items:      []models.CheckoutItem{{ProductID: product, Quantity: models.Qty(2)}},
+		Payments:   []models.PaymentRequest{{Method: models.PaymentCash, Amount: 10000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := sale.Details[0]
+	if d.UnitPrice != 3800 || d.PriceRule != models.PriceRulePriceList || d.PriceListID == nil || *d.PriceListID != list.ID {
+		t.Errorf("sold at %d by %s rule from list %v, want 3800 from list %d", d.UnitPrice, d.PriceRule, d.PriceListID, list.ID)
+	}
+
+	walkIn, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := walkIn.Details[0]; d.UnitPrice != 5000 || d.PriceListID != nil {
+		t.Errorf("walk-in customer sold at %d", d.UnitPrice)
+	}
+}
@@ -3,11 +3,24 @@ package repository
 import "gokasir-api/models"
 
 type ProductRepository interface {
-	FindAllProduct(name string) ([]models.Product, error)
+	FindAllProduct(name string, grouped bool) ([]models.Product, error)
 	CreateProduct(req *models.Product) error
 	FindProductByID(id int) (*models.Product, error)
+	FindProductByBarcode(code string) (*models.Product, error)
 	UpdateProduct(id int, req *models.Product) error
-	PatchProduct(id int, name *string, price, stock, category_id *int) (*models.Product, error)
+	PatchProduct(id int, req *models.PatchProductRequest) (*models.Product, error)
 	DeleteProduct(id int) error
+	FindStockHistory(id int, outletID *int) ([]models.StockMovement, error)
+	FindVariants(id int) ([]models.Product, error)
+	GenerateVariants(id int, req *models.GenerateVariantsRequest) ([]models.Product, error)
+	FindBundle(id int, outletID *int) (*models.Bundle, error)
+	SetBundleComponents(id int, req *models.SetBundleRequest) error
+	FindRecipe(id int) (*models.Recipe, error)
+	SetRecipe(id int, req *models.SetRecipeRequest) error
+	FindUnits(id int) (*models.ProductUnits, error)
+	SetUnits(id int, req *models.SetUnitsRequest) error
+	FindPriceTiers(id int) (*models.ProductPriceTiers, error)
+	SetPriceTiers(id int, req *models.SetPriceTiersRequest) error
+	FindLots(id int, outletID *int) (*models.ProductLots, error)
 	ExistID(id int) (bool, error)
 }
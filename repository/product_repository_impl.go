@@ -2,11 +2,15 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"gokasir-api/barcode"
 	"gokasir-api/models"
 	"log"
 	"strings"
+
+	"github.com/lib/pq"
 )
 
 type ProductRepositoryImpl struct {
@@ -17,41 +21,237 @@ func NewProductRepository(db *sql.DB) ProductRepository {
 	return &ProductRepositoryImpl{db: db}
 }
 
+// productColumns shows a bundle's availability as its stock.
+const productColumns = `p.id, p.name, p.price,
+	CASE WHEN p.is_bundle THEN (SELECT COALESCE(MIN(FLOOR(cp.stock / bc.quantity)), 0) FROM bundle_components bc INNER JOIN product cp ON cp.id = bc.component_id WHERE bc.bundle_id = p.id) ELSE p.stock END,
+	p.category_id, c.name, p.tax_rate, p.tax_exempt, p.tax_inclusive, p.min_stock, p.reorder_qty, p.sku, p.plu, p.is_bundle, p.is_ingredient, p.unit, p.precision, p.cost, p.cost_method, p.serialized, p.warranty_months, p.parent_id, p.retired, p.options, p.option_values`
+
+// scanProduct scans a row selected with productColumns.
+func scanProduct(scan func(dest ...any) error) (*models.Product, error) {
+	var p models.Product
+	var options, optionValues []byte
+	if err := scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.Category_ID, &p.Category_Name, &p.TaxRate, &p.TaxExempt, &p.TaxInclusive, &p.MinStock, &p.ReorderQty, &p.SKU, &p.PLU, &p.IsBundle, &p.IsIngredient, &p.Unit, &p.Precision, &p.Cost, &p.CostMethod, &p.Serialized, &p.WarrantyMonths, &p.ParentID, &p.Retired, &options, &optionValues); err != nil {
+		return nil, err
+	}
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &p.Options); err != nil {
+			return nil, err
+		}
+	}
+	if len(optionValues) > 0 {
+		if err := json.Unmarshal(optionValues, &p.OptionValues); err != nil {
+			return nil, err
+		}
+	}
+	return &p, nil
+}
+
 func (r *ProductRepositoryImpl) ExistID(id int) (bool, error) {
 	var exist bool
 	err := r.db.QueryRow("SELECT COUNT(*) FROM product WHERE id = $1", id).Scan(&exist)
 	return exist, err
 }
 
-func (r *ProductRepositoryImpl) FindAllProduct(name string) ([]models.Product, error) {
-	query := "SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name FROM product p INNER JOIN category c ON p.category_id = c.id"
-	var args []any
-	if name != "" {
-		query += " WHERE p.name ILIKE $1 ORDER BY p.id"
-		args = append(args, "%"+name+"%")
-	} else {
-		query += " ORDER BY p.id"
+// FindAllProduct lists products by name. With grouped only parent and
+// stand-alone products are listed, each with its variants nested inside.
+func (r *ProductRepositoryImpl) FindAllProduct(name string, grouped bool) ([]models.Product, error) {
+	query := "SELECT " + productColumns + " FROM product p INNER JOIN category c ON p.category_id = c.id WHERE ($1 = '' OR p.name ILIKE '%' || $1 || '%')"
+	if grouped {
+		query += " AND p.parent_id IS NULL"
 	}
-	rows, err := r.db.Query(query, args...)
+	products, err := r.queryProducts(query+" ORDER BY p.id", name)
 	if err != nil {
 		log.Printf("Error getting all product: %v", err)
 		return nil, err
 	}
+	if !grouped || len(products) == 0 {
+		return products, nil
+	}
+
+	parentIDs := make([]int, len(products))
+	byID := make(map[int]int)
+	for i, p := range products {
+		parentIDs[i] = p.ID
+		byID[p.ID] = i
+	}
+	variants, err := r.queryProducts("SELECT "+productColumns+" FROM product p INNER JOIN category c ON p.category_id = c.id WHERE p.parent_id = ANY($1) AND NOT p.retired ORDER BY p.id", pq.Array(parentIDs))
+	if err != nil {
+		log.Printf("Error getting variants: %v", err)
+		return nil, err
+	}
+	for _, v := range variants {
+		parent := &products[byID[*v.ParentID]]
+		parent.Variants = append(parent.Variants, v)
+	}
+	return products, nil
+}
+
+func (r *ProductRepositoryImpl) queryProducts(query string, args ...any) ([]models.Product, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 	var products []models.Product
 	for rows.Next() {
-		var product models.Product
-		if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.Category_ID, &product.Category_Name); err != nil {
+		product, err := scanProduct(rows.Scan)
+		if err != nil {
 			return nil, err
 		}
-		products = append(products, product)
+		products = append(products, *product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.loadBarcodes(products); err != nil {
+		return nil, err
 	}
 	return products, nil
 }
 
+// loadBarcodes fills in the barcodes of every product in one query.
+func (r *ProductRepositoryImpl) loadBarcodes(products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int, len(products))
+	byID := make(map[int]int)
+	for i := range products {
+		ids[i] = products[i].ID
+		byID[products[i].ID] = i
+		products[i].Barcodes = make([]models.ProductBarcode, 0)
+	}
+	rows, err := r.db.Query("SELECT product_id, code, type FROM product_barcodes WHERE product_id = ANY($1) ORDER BY code", pq.Array(ids))
+	if err != nil {
+		log.Printf("Error getting barcodes: %v", err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var productID int
+		var b models.ProductBarcode
+		if err := rows.Scan(&productID, &b.Code, &b.Type); err != nil {
+			return err
+		}
+		p := &products[byID[productID]]
+		p.Barcodes = append(p.Barcodes, b)
+	}
+	return rows.Err()
+}
+
+// saveBarcodes replaces the barcodes of a product.
+func saveBarcodes(tx *sql.Tx, id int, codes []models.ProductBarcode) error {
+	if _, err := tx.Exec("DELETE FROM product_barcodes WHERE product_id = $1", id); err != nil {
+		return err
+	}
+	for _, b := range codes {
+		if _, err := tx.Exec("INSERT INTO product_barcodes(code, product_id, type) VALUES($1, $2, $3)", b.Code, id, b.Type); err != nil {
+			log.Printf("Error saving barcode %s: %v", b.Code, err)
+			return conflictError(err)
+		}
+	}
+	return nil
+}
+
+// conflictError turns a unique violation on SKU or barcode into the
+// matching models error so handlers can answer with a conflict.
+func conflictError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		switch pqErr.Constraint {
+		case "idx_product_sku":
+			return models.ErrDuplicateSKU
+		case "product_barcodes_pkey":
+			return models.ErrDuplicateBarcode
+		case "idx_product_plu":
+			return models.ErrDuplicatePLU
+		}
+	}
+	return err
+}
+
+// productByCode looks a scanned code up among the barcodes, preferring the
+// canonical form, and failing that the SKUs. It returns sql.ErrNoRows when
+// nothing matches.
+func productByCode(q queryer, code string) (int, error) {
+	var id int
+	err := q.QueryRow(`SELECT id FROM (
+			SELECT product_id AS id, array_position($1::text[], code::text) AS rank FROM product_barcodes WHERE code = ANY($1)
+			UNION ALL
+			SELECT id, cardinality($1) + 1 FROM product WHERE sku <> '' AND sku = $2
+		) m ORDER BY rank LIMIT 1`, pq.Array(barcode.Equivalents(code)), code).Scan(&id)
+	return id, err
+}
+
+// FindProductByBarcode looks a scanned code up among the barcodes and,
+// failing that, the SKUs.
+func (r *ProductRepositoryImpl) FindProductByBarcode(code string) (*models.Product, error) {
+	id, err := productByCode(r.db, code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Product not found")
+		}
+		log.Printf("Error finding product by barcode: %v", err)
+		return nil, err
+	}
+	return r.FindProductByID(id)
+}
+
 func (r *ProductRepositoryImpl) CreateProduct(req *models.Product) error {
-	err := r.db.QueryRow("INSERT INTO product(name, price, stock, category_id) VALUES($1, $2, $3, $4) RETURNING id", req.Name, req.Price, req.Stock, req.Category_ID).Scan(&req.ID)
-	log.Printf("Error creating product: %v", err)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow("INSERT INTO product(name, price, stock, category_id, tax_rate, tax_exempt, min_stock, reorder_qty, sku, plu, is_ingredient, unit, precision, cost, cost_method, serialized, warranty_months, tax_inclusive) VALUES($1, $2, 0, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17) RETURNING id", req.Name, req.Price, req.Category_ID, req.TaxRate, req.TaxExempt, req.MinStock, req.ReorderQty, req.SKU, req.PLU, req.IsIngredient, req.Unit, req.Precision, req.Cost, req.CostMethod, req.Serialized, req.WarrantyMonths, req.TaxInclusive).Scan(&req.ID)
+	if err != nil {
+		log.Printf("Error creating product: %v", err)
+		return conflictError(err)
+	}
+	if err := saveBarcodes(tx, req.ID, req.Barcodes); err != nil {
+		return err
+	}
+	if err := setStock(tx, req.ID, req.Stock, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setStock books the difference between stock and the product's current
+// total as an adjustment at the default outlet. Bundles hold no stock, so the
+// stock sent for them is ignored.
+func setStock(tx *sql.Tx, id int, stock models.Quantity, user string) error {
+	var bundle bool
+	if err := tx.QueryRow("SELECT is_bundle FROM product WHERE id = $1", id).Scan(&bundle); err != nil {
+		return err
+	}
+	if bundle {
+		return nil
+	}
+	current, err := stockAt(tx, id, nil)
+	if err != nil {
+		return err
+	}
+	if stock == current {
+		return nil
+	}
+	outletID, err := stockOutlet(tx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := adjustCost(tx, id, stock-current); err != nil {
+		return err
+	}
+	_, err = adjustStock(tx, &models.StockMovement{
+		ProductID:     id,
+		OutletID:      outletID,
+		Delta:         stock - current,
+		Reason:        models.StockAdjustment,
+		ReferenceType: models.RefProduct,
+		ReferenceID:   &id,
+		CreatedBy:     user,
+	})
 	return err
 }
 
@@ -63,15 +263,19 @@ func (r *ProductRepositoryImpl) FindProductByID(id int) (*models.Product, error)
 	if !exist {
 		return nil, errors.New("Product ID not found")
 	}
-	var product models.Product
-	if err := r.db.QueryRow("SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name FROM product p INNER JOIN category c ON p.category_id = c.id WHERE p.id = $1", id).Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.Category_ID, &product.Category_Name); err != nil {
+	product, err := scanProduct(r.db.QueryRow("SELECT "+productColumns+" FROM product p INNER JOIN category c ON p.category_id = c.id WHERE p.id = $1", id).Scan)
+	if err != nil {
 		log.Printf("Error getting single product: %v", err)
 		if err == sql.ErrNoRows {
 			return nil, errors.New("Product not found")
 		}
 		return nil, err
 	}
-	return &product, nil
+	products := []models.Product{*product}
+	if err := r.loadBarcodes(products); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
 }
 
 func (r *ProductRepositoryImpl) UpdateProduct(id int, req *models.Product) error {
@@ -82,11 +286,20 @@ func (r *ProductRepositoryImpl) UpdateProduct(id int, req *models.Product) error
 	if !exist {
 		return errors.New("Product ID not found")
 	}
-	result, err := r.db.Exec("UPDATE product SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5", req.Name, req.Price, req.Stock, req.Category_ID, id)
+	tx, err := r.db.Begin()
 	if err != nil {
-		log.Printf("Error update product: %v", err)
 		return err
 	}
+	defer tx.Rollback()
+
+	if err := checkCostMethod(tx, id, req.CostMethod); err != nil {
+		return err
+	}
+	result, err := tx.Exec("UPDATE product SET name = $1, price = $2, category_id = $3, tax_rate = $4, tax_exempt = $5, min_stock = $6, reorder_qty = $7, sku = $8, plu = $9, is_ingredient = $10, unit = $11, precision = $12, cost = $13, cost_method = $14, serialized = $15, warranty_months = $16, tax_inclusive = $17 WHERE id = $18", req.Name, req.Price, req.Category_ID, req.TaxRate, req.TaxExempt, req.MinStock, req.ReorderQty, req.SKU, req.PLU, req.IsIngredient, req.Unit, req.Precision, req.Cost, req.CostMethod, req.Serialized, req.WarrantyMonths, req.TaxInclusive, id)
+	if err != nil {
+		log.Printf("Error update product: %v", err)
+		return conflictError(err)
+	}
 	rows, err := result.RowsAffected()
 	if err != nil {
 		if rows == 0 {
@@ -94,10 +307,20 @@ func (r *ProductRepositoryImpl) UpdateProduct(id int, req *models.Product) error
 		}
 		return err
 	}
-	return nil
+	if err := setStock(tx, id, req.Stock, ""); err != nil {
+		log.Printf("Error update product stock: %v", err)
+		return err
+	}
+	// Barcodes left out of the request are kept, an empty list removes them.
+	if req.Barcodes != nil {
+		if err := saveBarcodes(tx, id, req.Barcodes); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func (r *ProductRepositoryImpl) PatchProduct(id int, name *string, price, stock, category_id *int) (*models.Product, error) {
+func (r *ProductRepositoryImpl) PatchProduct(id int, req *models.PatchProductRequest) (*models.Product, error) {
 	exist, err := r.ExistID(id)
 	if err != nil {
 		return nil, err
@@ -112,39 +335,140 @@ func (r *ProductRepositoryImpl) PatchProduct(id int, name *string, price, stock,
 	var updates []string
 	argCount := 1
 
-	if name != nil {
+	if req.Name != nil {
 		updates = append(updates, fmt.Sprintf("name = $%d", argCount))
-		args = append(args, name)
+		args = append(args, req.Name)
 		argCount++
 	}
-	if price != nil {
+	if req.Price != nil {
 		updates = append(updates, fmt.Sprintf("price = $%d", argCount))
-		args = append(args, price)
+		args = append(args, req.Price)
 		argCount++
 	}
-	if stock != nil {
-		updates = append(updates, fmt.Sprintf("stock = $%d", argCount))
-		args = append(args, stock)
+	if req.Category_ID != nil {
+		updates = append(updates, fmt.Sprintf("category_id = $%d", argCount))
+		args = append(args, req.Category_ID)
 		argCount++
 	}
-	if category_id != nil {
-		updates = append(updates, fmt.Sprintf("category_id = $%d", argCount))
-		args = append(args, category_id)
+	if req.TaxRate != nil {
+		updates = append(updates, fmt.Sprintf("tax_rate = $%d", argCount))
+		args = append(args, req.TaxRate)
+		argCount++
+	}
+	if req.TaxExempt != nil {
+		updates = append(updates, fmt.Sprintf("tax_exempt = $%d", argCount))
+		args = append(args, req.TaxExempt)
+		argCount++
+	}
+	if req.TaxInclusive != nil {
+		updates = append(updates, fmt.Sprintf("tax_inclusive = $%d", argCount))
+		args = append(args, req.TaxInclusive)
+		argCount++
+	}
+	if req.MinStock != nil {
+		updates = append(updates, fmt.Sprintf("min_stock = $%d", argCount))
+		args = append(args, req.MinStock)
+		argCount++
+	}
+	if req.ReorderQty != nil {
+		updates = append(updates, fmt.Sprintf("reorder_qty = $%d", argCount))
+		args = append(args, req.ReorderQty)
+		argCount++
+	}
+	if req.SKU != nil {
+		updates = append(updates, fmt.Sprintf("sku = $%d", argCount))
+		args = append(args, req.SKU)
+		argCount++
+	}
+	if req.PLU != nil {
+		updates = append(updates, fmt.Sprintf("plu = $%d", argCount))
+		args = append(args, req.PLU)
+		argCount++
+	}
+	if req.IsIngredient != nil {
+		updates = append(updates, fmt.Sprintf("is_ingredient = $%d", argCount))
+		args = append(args, req.IsIngredient)
 		argCount++
 	}
-	if len(updates) == 0 {
-		return r.FindProductByID(id)
+	if req.Unit != nil {
+		updates = append(updates, fmt.Sprintf("unit = $%d", argCount))
+		args = append(args, req.Unit)
+		argCount++
+	}
+	if req.Precision != nil {
+		updates = append(updates, fmt.Sprintf("precision = $%d", argCount))
+		args = append(args, req.Precision)
+		argCount++
+	}
+	if req.Cost != nil {
+		updates = append(updates, fmt.Sprintf("cost = $%d", argCount))
+		args = append(args, req.Cost)
+		argCount++
+	}
+	if req.CostMethod != nil {
+		updates = append(updates, fmt.Sprintf("cost_method = $%d", argCount))
+		args = append(args, req.CostMethod)
+		argCount++
+	}
+	if req.Serialized != nil {
+		updates = append(updates, fmt.Sprintf("serialized = $%d", argCount))
+		args = append(args, req.Serialized)
+		argCount++
+	}
+	if req.WarrantyMonths != nil {
+		updates = append(updates, fmt.Sprintf("warranty_months = $%d", argCount))
+		args = append(args, req.WarrantyMonths)
+		argCount++
 	}
-	query += strings.Join(updates, ", ") + fmt.Sprintf(" WHERE id = $%d", argCount)
-	args = append(args, id)
-	_, err = r.db.Exec(query, args...)
+
+	tx, err := r.db.Begin()
 	if err != nil {
-		log.Printf("Error patch product: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	if req.CostMethod != nil {
+		if err := checkCostMethod(tx, id, *req.CostMethod); err != nil {
+			return nil, err
+		}
+	}
+	if len(updates) > 0 {
+		query += strings.Join(updates, ", ") + fmt.Sprintf(" WHERE id = $%d", argCount)
+		args = append(args, id)
+		_, err = tx.Exec(query, args...)
+		if err != nil {
+			log.Printf("Error patch product: %v", err)
+			return nil, conflictError(err)
+		}
+	}
+	if req.Barcodes != nil {
+		if err := saveBarcodes(tx, id, *req.Barcodes); err != nil {
+			return nil, err
+		}
+	}
+	// Stock is never written directly, the change goes through the ledger.
+	if req.Stock != nil {
+		if err := setStock(tx, id, *req.Stock, req.UpdatedBy); err != nil {
+			log.Printf("Error patch product stock: %v", err)
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 	return r.FindProductByID(id)
 }
 
+func (r *ProductRepositoryImpl) FindStockHistory(id int, outletID *int) ([]models.StockMovement, error) {
+	exist, err := r.ExistID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errors.New("Product ID not found")
+	}
+	return stockMovements(r.db, id, outletID)
+}
+
 func (r *ProductRepositoryImpl) DeleteProduct(id int) error {
 	exist, err := r.ExistID(id)
 	if err != nil {
@@ -167,3 +491,439 @@ func (r *ProductRepositoryImpl) DeleteProduct(id int) error {
 	}
 	return nil
 }
+
+func (r *ProductRepositoryImpl) FindVariants(id int) ([]models.Product, error) {
+	variants, err := r.queryProducts("SELECT "+productColumns+" FROM product p INNER JOIN category c ON p.category_id = c.id WHERE p.parent_id = $1 AND NOT p.retired ORDER BY p.id", id)
+	if err != nil {
+		log.Printf("Error getting variants: %v", err)
+		return nil, err
+	}
+	if variants == nil {
+		variants = make([]models.Product, 0)
+	}
+	return variants, nil
+}
+
+// GenerateVariants stores the option dimensions on the parent and creates a
+// variant for every combination that does not exist yet. Variants take the
+// parent's category, tax and reorder settings and start without stock.
+// Variants of combinations no longer generated are retired, not deleted, so
+// their sales history stays; they come back if their combination does.
+func (r *ProductRepositoryImpl) GenerateVariants(id int, req *models.GenerateVariantsRequest) ([]models.Product, error) {
+	parent, err := r.FindProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if parent.ParentID != nil {
+		return nil, errors.New("A variant cannot have variants of its own")
+	}
+	price := req.Price
+	if price == 0 {
+		price = parent.Price
+	}
+	prefix := req.SKUPrefix
+	if prefix == "" {
+		prefix = parent.SKU
+	}
+	if prefix == "" {
+		prefix = fmt.Sprintf("P%d", parent.ID)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	options, err := json.Marshal(req.Options)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE product SET options = $1 WHERE id = $2", string(options), id); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query("SELECT id, option_values FROM product WHERE parent_id = $1 FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]int)
+	for rows.Next() {
+		var variantID int
+		var optionValues []byte
+		if err := rows.Scan(&variantID, &optionValues); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var values map[string]string
+		if len(optionValues) > 0 {
+			if err := json.Unmarshal(optionValues, &values); err != nil {
+				rows.Close()
+				return nil, err
+			}
+		}
+		existing[variantKey(req.Options, values)] = variantID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keep := make([]int, 0, len(existing))
+	for _, values := range combinations(req.Options) {
+		if variantID, ok := existing[variantKey(req.Options, values)]; ok {
+			keep = append(keep, variantID)
+			continue
+		}
+		labels := make([]string, len(req.Options))
+		for i, o := range req.Options {
+			labels[i] = values[o.Name]
+		}
+		optionValues, err := json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+		sku := strings.ToUpper(strings.ReplaceAll(prefix+"-"+strings.Join(labels, "-"), " ", ""))
+		_, err = tx.Exec(`INSERT INTO product(name, price, stock, category_id, tax_rate, tax_exempt, min_stock, reorder_qty, sku, parent_id, option_values, cost, cost_method, serialized, warranty_months, tax_inclusive)
+			VALUES($1, $2, 0, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+			parent.Name+" - "+strings.Join(labels, " / "), price, parent.Category_ID, parent.TaxRate, parent.TaxExempt, parent.MinStock, parent.ReorderQty, sku, id, string(optionValues), parent.Cost, parent.CostMethod, parent.Serialized, parent.WarrantyMonths, parent.TaxInclusive)
+		if err != nil {
+			log.Printf("Error creating variant: %v", err)
+			return nil, conflictError(err)
+		}
+	}
+	if _, err := tx.Exec("UPDATE product SET retired = NOT (id = ANY($2)) WHERE parent_id = $1", id, pq.Array(keep)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindVariants(id)
+}
+
+// combinations returns every way of picking one value per option.
+func combinations(options []models.ProductOption) []map[string]string {
+	result := []map[string]string{{}}
+	for _, o := range options {
+		var next []map[string]string
+		for _, partial := range result {
+			for _, value := range o.Values {
+				combo := make(map[string]string, len(partial)+1)
+				for k, v := range partial {
+					combo[k] = v
+				}
+				combo[o.Name] = value
+				next = append(next, combo)
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+func variantKey(options []models.ProductOption, values map[string]string) string {
+	parts := make([]string, len(options))
+	for i, o := range options {
+		parts[i] = strings.ToLower(values[o.Name])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func (r *ProductRepositoryImpl) FindBundle(id int, outletID *int) (*models.Bundle, error) {
+	product, err := r.FindProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	components, err := bundleComponents(r.db, id, outletID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Bundle{ProductID: id, Name: product.Name, Available: bundleAvailable(components), Components: components}, nil
+}
+
+// SetBundleComponents replaces the component list of a bundle. Bundles do
+// not nest, and a product that still holds stock of its own cannot become
+// a bundle.
+func (r *ProductRepositoryImpl) SetBundleComponents(id int, req *models.SetBundleRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stock models.Quantity
+	var isComponent, hasVariants, hasRecipe bool
+	err = tx.QueryRow(`SELECT stock, EXISTS(SELECT 1 FROM bundle_components WHERE component_id = p.id), EXISTS(SELECT 1 FROM product v WHERE v.parent_id = p.id),
+			EXISTS(SELECT 1 FROM recipe_items ri WHERE ri.product_id = p.id)
+		FROM product p WHERE id = $1 FOR UPDATE`, id).Scan(&stock, &isComponent, &hasVariants, &hasRecipe)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("Product not found")
+		}
+		return err
+	}
+	if len(req.Components) > 0 {
+		switch {
+		case isComponent:
+			return errors.New("A component of another bundle cannot be a bundle")
+		case hasVariants:
+			return errors.New("A product with variants cannot be a bundle")
+		case hasRecipe:
+			return errors.New("A product with a recipe cannot be a bundle")
+		case stock != 0:
+			return errors.New("Bring the product's stock to zero before making it a bundle")
+		}
+	}
+	for _, c := range req.Components {
+		if c.ProductID == id {
+			return errors.New("A bundle cannot contain itself")
+		}
+		var name string
+		var bundle, variants, recipe bool
+		err := tx.QueryRow(`SELECT name, is_bundle, EXISTS(SELECT 1 FROM product v WHERE v.parent_id = p.id), EXISTS(SELECT 1 FROM recipe_items ri WHERE ri.product_id = p.id)
+			FROM product p WHERE id = $1`, c.ProductID).Scan(&name, &bundle, &variants, &recipe)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("Product %d not found", c.ProductID)
+			}
+			return err
+		}
+		if bundle {
+			return fmt.Errorf("%s is a bundle and cannot be a component", name)
+		}
+		if variants {
+			return fmt.Errorf("Choose a variant of %s as component", name)
+		}
+		if recipe {
+			return fmt.Errorf("%s is made from a recipe and cannot be a component", name)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM bundle_components WHERE bundle_id = $1", id); err != nil {
+		return err
+	}
+	for _, c := range req.Components {
+		if _, err := tx.Exec("INSERT INTO bundle_components (bundle_id, component_id, quantity) VALUES ($1, $2, $3)", id, c.ProductID, c.Quantity); err != nil {
+			log.Printf("Error saving bundle component: %v", err)
+			return err
+		}
+	}
+	if _, err := tx.Exec("UPDATE product SET is_bundle = $1 WHERE id = $2", len(req.Components) > 0, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ProductRepositoryImpl) FindRecipe(id int) (*models.Recipe, error) {
+	product, err := r.FindProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`SELECT p.id, p.name, p.unit, ri.quantity
+		FROM recipe_items ri INNER JOIN product p ON p.id = ri.ingredient_id
+		WHERE ri.product_id = $1 ORDER BY p.id`, id)
+	if err != nil {
+		log.Printf("Error getting recipe: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	recipe := models.Recipe{ProductID: id, Name: product.Name, Items: make([]models.RecipeItem, 0)}
+	for rows.Next() {
+		var item models.RecipeItem
+		if err := rows.Scan(&item.IngredientID, &item.IngredientName, &item.Unit, &item.Quantity); err != nil {
+			return nil, err
+		}
+		recipe.Items = append(recipe.Items, item)
+	}
+	return &recipe, rows.Err()
+}
+
+// SetRecipe replaces the recipe of a sellable product. Only ingredients go
+// into a recipe, and bundles sell their components instead.
+func (r *ProductRepositoryImpl) SetRecipe(id int, req *models.SetRecipeRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var ingredient, bundle, isComponent bool
+	err = tx.QueryRow("SELECT is_ingredient, is_bundle, EXISTS(SELECT 1 FROM bundle_components WHERE component_id = p.id) FROM product p WHERE id = $1 FOR UPDATE", id).Scan(&ingredient, &bundle, &isComponent)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("Product not found")
+		}
+		return err
+	}
+	if len(req.Items) > 0 {
+		switch {
+		case ingredient:
+			return errors.New("An ingredient cannot have a recipe")
+		case bundle:
+			return errors.New("A bundle cannot have a recipe")
+		case isComponent:
+			return errors.New("A component of a bundle cannot have a recipe")
+		}
+	}
+	for _, item := range req.Items {
+		var name string
+		var isIngredient bool
+		err := tx.QueryRow("SELECT name, is_ingredient FROM product WHERE id = $1", item.IngredientID).Scan(&name, &isIngredient)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("Product %d not found", item.IngredientID)
+			}
+			return err
+		}
+		if !isIngredient {
+			return fmt.Errorf("%s is not an ingredient", name)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM recipe_items WHERE product_id = $1", id); err != nil {
+		return err
+	}
+	for _, item := range req.Items {
+		if _, err := tx.Exec("INSERT INTO recipe_items (product_id, ingredient_id, quantity) VALUES ($1, $2, $3)", id, item.IngredientID, item.Quantity); err != nil {
+			log.Printf("Error saving recipe item: %v", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *ProductRepositoryImpl) FindUnits(id int) (*models.ProductUnits, error) {
+	product, err := r.FindProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query("SELECT name, factor, price FROM product_units WHERE product_id = $1 ORDER BY factor, name", id)
+	if err != nil {
+		log.Printf("Error getting product units: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	units := models.ProductUnits{ProductID: id, Name: product.Name, BaseUnit: product.Unit, Units: make([]models.ProductUnit, 0)}
+	for rows.Next() {
+		var u models.ProductUnit
+		if err := rows.Scan(&u.Name, &u.Factor, &u.Price); err != nil {
+			return nil, err
+		}
+		units.Units = append(units.Units, u)
+	}
+	return &units, rows.Err()
+}
+
+// SetUnits replaces the units a product is bought, counted and sold in
+// besides its base unit.
+func (r *ProductRepositoryImpl) SetUnits(id int, req *models.SetUnitsRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var baseUnit string
+	var bundle bool
+	err = tx.QueryRow("SELECT unit, is_bundle FROM product WHERE id = $1 FOR UPDATE", id).Scan(&baseUnit, &bundle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("Product not found")
+		}
+		return err
+	}
+	if bundle && len(req.Units) > 0 {
+		return errors.New("A bundle is sold as one, it cannot have units")
+	}
+	for _, u := range req.Units {
+		if u.Name == baseUnit {
+			return fmt.Errorf("%s is already the base unit", u.Name)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM product_units WHERE product_id = $1", id); err != nil {
+		return err
+	}
+	for _, u := range req.Units {
+		if _, err := tx.Exec("INSERT INTO product_units (product_id, name, factor, price) VALUES ($1, $2, $3, $4)", id, u.Name, u.Factor, u.Price); err != nil {
+			log.Printf("Error saving product unit: %v", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *ProductRepositoryImpl) FindPriceTiers(id int) (*models.ProductPriceTiers, error) {
+	product, err := r.FindProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query("SELECT min_qty, price FROM price_tiers WHERE product_id = $1 ORDER BY min_qty", id)
+	if err != nil {
+		log.Printf("Error getting price tiers: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	tiers := models.ProductPriceTiers{ProductID: id, Name: product.Name, Price: product.Price, Tiers: make([]models.PriceTier, 0)}
+	for rows.Next() {
+		var t models.PriceTier
+		if err := rows.Scan(&t.MinQty, &t.Price); err != nil {
+			return nil, err
+		}
+		tiers.Tiers = append(tiers.Tiers, t)
+	}
+	return &tiers, rows.Err()
+}
+
+// SetPriceTiers replaces the wholesale quantity breaks of a product.
+func (r *ProductRepositoryImpl) SetPriceTiers(id int, req *models.SetPriceTiersRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var bundle bool
+	if err := tx.QueryRow("SELECT is_bundle FROM product WHERE id = $1 FOR UPDATE", id).Scan(&bundle); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("Product not found")
+		}
+		return err
+	}
+	if bundle && len(req.Tiers) > 0 {
+		return errors.New("A bundle cannot have price tiers")
+	}
+	if _, err := tx.Exec("DELETE FROM price_tiers WHERE product_id = $1", id); err != nil {
+		return err
+	}
+	for _, t := range req.Tiers {
+		if _, err := tx.Exec("INSERT INTO price_tiers (product_id, min_qty, price) VALUES ($1, $2, $3)", id, t.MinQty, t.Price); err != nil {
+			log.Printf("Error saving price tier: %v", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FindLots lists the lots of a product in stock at an outlet, or at all
+// outlets when outletID is nil.
+func (r *ProductRepositoryImpl) FindLots(id int, outletID *int) (*models.ProductLots, error) {
+	product, err := r.FindProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	lots := models.ProductLots{ProductID: id, Name: product.Name, Stock: product.Stock}
+	if outletID != nil {
+		err := r.db.QueryRow("SELECT COALESCE((SELECT stock FROM product_stock WHERE product_id = $1 AND outlet_id = $2), 0)", id, *outletID).Scan(&lots.Stock)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if lots.Lots, err = productLots(r.db, id, outletID); err != nil {
+		return nil, err
+	}
+	lots.Unlotted = lots.Stock
+	for _, l := range lots.Lots {
+		lots.Unlotted -= l.Quantity
+	}
+	return &lots, nil
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "gokasir-api/models"
+
+type PromotionRepository interface {
+	FindAllPromotion() ([]models.Promotion, error)
+	CreatePromotion(req *models.Promotion) error
+	FindPromotionByID(id int) (*models.Promotion, error)
+	UpdatePromotion(id int, req *models.Promotion) error
+	DeletePromotion(id int) error
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"gokasir-api/models"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PromotionRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) PromotionRepository {
+	return &PromotionRepositoryImpl{db: db}
+}
+
+const promotionColumns = "id, name, type, value, category_id, product_ids, buy_qty, get_qty, min_purchase, start_at, end_at, stackable, priority, active"
+
+func scanPromotion(scan func(dest ...any) error) (*models.Promotion, error) {
+	var p models.Promotion
+	var categoryID sql.NullInt64
+	var productIDs pq.Int64Array
+	if err := scan(&p.ID, &p.Name, &p.Type, &p.Value, &categoryID, &productIDs, &p.BuyQty, &p.GetQty, &p.MinPurchase, &p.StartAt, &p.EndAt, &p.Stackable, &p.Priority, &p.Active); err != nil {
+		return nil, err
+	}
+	if categoryID.Valid {
+		id := int(categoryID.Int64)
+		p.CategoryID = &id
+	}
+	p.ProductIDs = make([]int, len(productIDs))
+	for i, id := range productIDs {
+		p.ProductIDs[i] = int(id)
+	}
+	return &p, nil
+}
+
+// activePromotions returns promotions that are switched on and valid at now.
+func activePromotions(q queryer, now time.Time) ([]models.Promotion, error) {
+	rows, err := q.Query("SELECT "+promotionColumns+" FROM promotions WHERE active AND start_at <= $1 AND end_at > $1 ORDER BY priority DESC, id", now)
+	if err != nil {
+		log.Printf("Error getting active promotions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var promotions []models.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, *p)
+	}
+	return promotions, nil
+}
+
+func (r *PromotionRepositoryImpl) FindAllPromotion() ([]models.Promotion, error) {
+	rows, err := r.db.Query("SELECT " + promotionColumns + " FROM promotions ORDER BY id")
+	if err != nil {
+		log.Printf("Error getting all promotion: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var promotions []models.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, *p)
+	}
+	return promotions, nil
+}
+
+func (r *PromotionRepositoryImpl) CreatePromotion(req *models.Promotion) error {
+	err := r.db.QueryRow("INSERT INTO promotions(name, type, value, category_id, product_ids, buy_qty, get_qty, min_purchase, start_at, end_at, stackable, priority, active) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id",
+		req.Name, req.Type, req.Value, req.CategoryID, pq.Array(req.ProductIDs), req.BuyQty, req.GetQty, req.MinPurchase, req.StartAt, req.EndAt, req.Stackable, req.Priority, req.Active).Scan(&req.ID)
+	if err != nil {
+		log.Printf("Error creating promotion: %v", err)
+	}
+	return err
+}
+
+func (r *PromotionRepositoryImpl) FindPromotionByID(id int) (*models.Promotion, error) {
+	p, err := scanPromotion(r.db.QueryRow("SELECT "+promotionColumns+" FROM promotions WHERE id = $1", id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Promotion not found")
+		}
+		log.Printf("Error getting single promotion: %v", err)
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PromotionRepositoryImpl) UpdatePromotion(id int, req *models.Promotion) error {
+	result, err := r.db.Exec("UPDATE promotions SET name = $1, type = $2, value = $3, category_id = $4, product_ids = $5, buy_qty = $6, get_qty = $7, min_purchase = $8, start_at = $9, end_at = $10, stackable = $11, priority = $12, active = $13 WHERE id = $14",
+		req.Name, req.Type, req.Value, req.CategoryID, pq.Array(req.ProductIDs), req.BuyQty, req.GetQty, req.MinPurchase, req.StartAt, req.EndAt, req.Stackable, req.Priority, req.Active, id)
+	if err != nil {
+		log.Printf("Error update promotion: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Promotion not found")
+	}
+	return nil
+}
+
+func (r *PromotionRepositoryImpl) DeletePromotion(id int) error {
+	var used bool
+	if err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM transaction_detail_promotions WHERE promotion_id = $1)", id).Scan(&used); err != nil {
+		return err
+	}
+	if used {
+		return models.ErrPromotionInUse
+	}
+	result, err := r.db.Exec("DELETE FROM promotions WHERE id = $1", id)
+	if err != nil {
+		log.Printf("Error delete promotion: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Promotion not found")
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package repository
+
+import "gokasir-api/models"
+
+type PurchaseOrderRepository interface {
+	FindAllPurchaseOrder(status string, supplierID *int) ([]models.PurchaseOrder, error)
+	CreatePurchaseOrder(req *models.PurchaseOrder) error
+	FindPurchaseOrderByID(id int) (*models.PurchaseOrder, error)
+	UpdatePurchaseOrder(id int, req *models.PurchaseOrder) error
+	OrderPurchaseOrder(id int) error
+	CancelPurchaseOrder(id int) error
+	ReceivePurchaseOrder(id int, req *models.ReceiveRequest) (*models.GoodsReceipt, error)
+	PaySupplier(id int, req *models.SupplierPaymentRequest) (*models.SupplierPayment, error)
+}
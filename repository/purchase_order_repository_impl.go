@@ -0,0 +1,438 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"log"
+)
+
+type PurchaseOrderRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPurchaseOrderRepository(db *sql.DB) PurchaseOrderRepository {
+	return &PurchaseOrderRepositoryImpl{db: db}
+}
+
+const purchaseOrderColumns = `po.id, po.supplier_id, s.name, po.outlet_id, po.status, po.notes, po.total_amount,
+	po.received_amount, po.paid_amount, po.created_by, po.created_at, po.ordered_at`
+
+func purchaseOrderFields(po *models.PurchaseOrder) []any {
+	return []any{&po.ID, &po.SupplierID, &po.SupplierName, &po.OutletID, &po.Status, &po.Notes, &po.TotalAmount,
+		&po.ReceivedAmount, &po.PaidAmount, &po.CreatedBy, &po.CreatedAt, &po.OrderedAt}
+}
+
+func (r *PurchaseOrderRepositoryImpl) FindAllPurchaseOrder(status string, supplierID *int) ([]models.PurchaseOrder, error) {
+	rows, err := r.db.Query(`SELECT `+purchaseOrderColumns+`
+		FROM purchase_orders po INNER JOIN suppliers s ON po.supplier_id = s.id
+		WHERE ($1 = '' OR po.status = $1) AND ($2::int IS NULL OR po.supplier_id = $2)
+		ORDER BY po.id DESC`, status, supplierID)
+	if err != nil {
+		log.Printf("Error getting all purchase order: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	orders := make([]models.PurchaseOrder, 0)
+	for rows.Next() {
+		var po models.PurchaseOrder
+		if err := rows.Scan(purchaseOrderFields(&po)...); err != nil {
+			return nil, err
+		}
+		po.Outstanding = po.ReceivedAmount - po.PaidAmount
+		orders = append(orders, po)
+	}
+	return orders, nil
+}
+
+func (r *PurchaseOrderRepositoryImpl) CreatePurchaseOrder(req *models.PurchaseOrder) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if req.OutletID, err = stockOutlet(tx, req.OutletID); err != nil {
+		return err
+	}
+	err = tx.QueryRow("INSERT INTO purchase_orders(supplier_id, outlet_id, status, notes, created_by) VALUES($1, $2, $3, $4, $5) RETURNING id, created_at", req.SupplierID, req.OutletID, models.POStatusDraft, req.Notes, req.CreatedBy).Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating purchase order: %v", err)
+		return err
+	}
+	if err := insertPurchaseOrderItems(tx, req); err != nil {
+		return err
+	}
+	req.Status = models.POStatusDraft
+	return tx.Commit()
+}
+
+// insertPurchaseOrderItems writes the order lines and the order total.
+func insertPurchaseOrderItems(tx *sql.Tx, po *models.PurchaseOrder) error {
+	po.TotalAmount = 0
+	for i := range po.Items {
+		item := &po.Items[i]
+		item.PurchaseOrderID = po.ID
+		var baseUnit string
+		if err := tx.QueryRow("SELECT name, unit FROM product WHERE id = $1", item.ProductID).Scan(&item.ProductName, &baseUnit); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("Product %d not found", item.ProductID)
+			}
+			return err
+		}
+		if item.Unit == "" {
+			item.Unit = baseUnit
+		}
+		factor, _, err := productUnit(tx, item.ProductID, item.Unit)
+		if err != nil {
+			return err
+		}
+		item.Factor = factor
+		err = tx.QueryRow("INSERT INTO purchase_order_items(purchase_order_id, product_id, quantity, unit_cost, unit, factor) VALUES($1, $2, $3, $4, $5, $6) RETURNING id", po.ID, item.ProductID, item.Quantity, item.UnitCost, item.Unit, item.Factor).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+		po.TotalAmount += item.Quantity.Amount(item.UnitCost)
+	}
+	_, err := tx.Exec("UPDATE purchase_orders SET total_amount = $1 WHERE id = $2", po.TotalAmount, po.ID)
+	return err
+}
+
+func (r *PurchaseOrderRepositoryImpl) FindPurchaseOrderByID(id int) (*models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := r.db.QueryRow("SELECT "+purchaseOrderColumns+" FROM purchase_orders po INNER JOIN suppliers s ON po.supplier_id = s.id WHERE po.id = $1", id).Scan(purchaseOrderFields(&po)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Purchase order not found")
+		}
+		log.Printf("Error getting single purchase order: %v", err)
+		return nil, err
+	}
+	po.Outstanding = po.ReceivedAmount - po.PaidAmount
+
+	rows, err := r.db.Query(`SELECT i.id, i.purchase_order_id, i.product_id, p.name, i.quantity, i.unit_cost, i.received_qty, i.unit, i.factor
+		FROM purchase_order_items i INNER JOIN product p ON i.product_id = p.id
+		WHERE i.purchase_order_id = $1 ORDER BY i.id`, id)
+	if err != nil {
+		log.Printf("Error getting purchase order items: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	po.Items = make([]models.PurchaseOrderItem, 0)
+	for rows.Next() {
+		var item models.PurchaseOrderItem
+		if err := rows.Scan(&item.ID, &item.PurchaseOrderID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitCost, &item.ReceivedQty, &item.Unit, &item.Factor); err != nil {
+			return nil, err
+		}
+		po.Items = append(po.Items, item)
+	}
+
+	receipts, err := r.db.Query("SELECT id, purchase_order_id, received_by, notes, received_at FROM goods_receipts WHERE purchase_order_id = $1 ORDER BY id", id)
+	if err != nil {
+		log.Printf("Error getting goods receipts: %v", err)
+		return nil, err
+	}
+	defer receipts.Close()
+	byID := make(map[int]int)
+	for receipts.Next() {
+		var gr models.GoodsReceipt
+		if err := receipts.Scan(&gr.ID, &gr.PurchaseOrderID, &gr.ReceivedBy, &gr.Notes, &gr.ReceivedAt); err != nil {
+			return nil, err
+		}
+		gr.Items = make([]models.GoodsReceiptItem, 0)
+		byID[gr.ID] = len(po.Receipts)
+		po.Receipts = append(po.Receipts, gr)
+	}
+	receiptItems, err := r.db.Query(`SELECT gi.id, gi.receipt_id, gi.item_id, gi.product_id, gi.quantity, gi.unit_cost,
+			gi.lot_id, COALESCE(l.batch_number, ''), COALESCE(to_char(l.expiry_date, 'YYYY-MM-DD'), '')
+		FROM goods_receipt_items gi INNER JOIN goods_receipts gr ON gi.receipt_id = gr.id
+		LEFT JOIN stock_lots l ON l.id = gi.lot_id
+		WHERE gr.purchase_order_id = $1 ORDER BY gi.id`, id)
+	if err != nil {
+		log.Printf("Error getting goods receipt items: %v", err)
+		return nil, err
+	}
+	defer receiptItems.Close()
+	for receiptItems.Next() {
+		var gi models.GoodsReceiptItem
+		var receiptID int
+		if err := receiptItems.Scan(&gi.ID, &receiptID, &gi.ItemID, &gi.ProductID, &gi.Quantity, &gi.UnitCost, &gi.LotID, &gi.BatchNumber, &gi.ExpiryDate); err != nil {
+			return nil, err
+		}
+		gr := &po.Receipts[byID[receiptID]]
+		gr.Items = append(gr.Items, gi)
+	}
+
+	serials, err := r.db.Query(`SELECT s.goods_receipt_id, s.product_id, s.serial FROM serial_numbers s
+		INNER JOIN goods_receipts gr ON s.goods_receipt_id = gr.id
+		WHERE gr.purchase_order_id = $1 ORDER BY s.id`, id)
+	if err != nil {
+		log.Printf("Error getting received serials: %v", err)
+		return nil, err
+	}
+	defer serials.Close()
+	for serials.Next() {
+		var receiptID, productID int
+		var serial string
+		if err := serials.Scan(&receiptID, &productID, &serial); err != nil {
+			return nil, err
+		}
+		gr := &po.Receipts[byID[receiptID]]
+		for i := range gr.Items {
+			if gr.Items[i].ProductID == productID {
+				gr.Items[i].Serials = append(gr.Items[i].Serials, serial)
+				break
+			}
+		}
+	}
+
+	payments, err := r.db.Query("SELECT id, purchase_order_id, amount, method, reference, paid_by, paid_at FROM supplier_payments WHERE purchase_order_id = $1 ORDER BY id", id)
+	if err != nil {
+		log.Printf("Error getting supplier payments: %v", err)
+		return nil, err
+	}
+	defer payments.Close()
+	for payments.Next() {
+		var p models.SupplierPayment
+		if err := payments.Scan(&p.ID, &p.PurchaseOrderID, &p.Amount, &p.Method, &p.Reference, &p.PaidBy, &p.PaidAt); err != nil {
+			return nil, err
+		}
+		po.Payments = append(po.Payments, p)
+	}
+	return &po, nil
+}
+
+// lockPurchaseOrder returns the status of an order and locks it until the
+// transaction ends.
+func lockPurchaseOrder(tx *sql.Tx, id int) (string, error) {
+	var status string
+	err := tx.QueryRow("SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", errors.New("Purchase order not found")
+	}
+	return status, err
+}
+
+// UpdatePurchaseOrder replaces supplier, notes and lines of a draft order.
+func (r *PurchaseOrderRepositoryImpl) UpdatePurchaseOrder(id int, req *models.PurchaseOrder) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return err
+	}
+	if status != models.POStatusDraft {
+		return errors.New("Only draft purchase orders can be changed")
+	}
+	if req.OutletID, err = stockOutlet(tx, req.OutletID); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE purchase_orders SET supplier_id = $1, outlet_id = $2, notes = $3 WHERE id = $4", req.SupplierID, req.OutletID, req.Notes, id)
+	if err != nil {
+		log.Printf("Error update purchase order: %v", err)
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM purchase_order_items WHERE purchase_order_id = $1", id); err != nil {
+		return err
+	}
+	req.ID = id
+	if err := insertPurchaseOrderItems(tx, req); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PurchaseOrderRepositoryImpl) OrderPurchaseOrder(id int) error {
+	result, err := r.db.Exec("UPDATE purchase_orders SET status = $1, ordered_at = CURRENT_TIMESTAMP WHERE id = $2 AND status = $3", models.POStatusOrdered, id, models.POStatusDraft)
+	if err != nil {
+		log.Printf("Error ordering purchase order: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Only draft purchase orders can be ordered")
+	}
+	return nil
+}
+
+// CancelPurchaseOrder cancels an order nothing has been received for yet.
+func (r *PurchaseOrderRepositoryImpl) CancelPurchaseOrder(id int) error {
+	result, err := r.db.Exec("UPDATE purchase_orders SET status = $1 WHERE id = $2 AND status IN ($3, $4)", models.POStatusCancelled, id, models.POStatusDraft, models.POStatusOrdered)
+	if err != nil {
+		log.Printf("Error cancelling purchase order: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Only draft or ordered purchase orders can be cancelled")
+	}
+	return nil
+}
+
+// ReceivePurchaseOrder books a delivery: stock goes up at the order's outlet
+// at the received unit cost and the order moves to partially received or
+// received.
+func (r *PurchaseOrderRepositoryImpl) ReceivePurchaseOrder(id int, req *models.ReceiveRequest) (*models.GoodsReceipt, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status != models.POStatusOrdered && status != models.POStatusPartiallyReceived {
+		return nil, errors.New("Only ordered purchase orders can be received")
+	}
+	var outletID *int
+	if err := tx.QueryRow("SELECT outlet_id FROM purchase_orders WHERE id = $1", id).Scan(&outletID); err != nil {
+		return nil, err
+	}
+
+	receipt := models.GoodsReceipt{
+		PurchaseOrderID: id,
+		ReceivedBy:      req.ReceivedBy,
+		Notes:           req.Notes,
+		Items:           make([]models.GoodsReceiptItem, 0, len(req.Items)),
+	}
+	err = tx.QueryRow("INSERT INTO goods_receipts(purchase_order_id, received_by, notes) VALUES($1, $2, $3) RETURNING id, received_at", id, req.ReceivedBy, req.Notes).Scan(&receipt.ID, &receipt.ReceivedAt)
+	if err != nil {
+		log.Printf("Error creating goods receipt: %v", err)
+		return nil, err
+	}
+
+	receivedAmount := 0
+	for _, item := range req.Items {
+		var productID, unitCost int
+		var ordered, received, factor models.Quantity
+		var serialized bool
+		err := tx.QueryRow(`SELECT i.product_id, i.quantity, i.received_qty, i.unit_cost, i.factor, p.serialized
+			FROM purchase_order_items i INNER JOIN product p ON p.id = i.product_id
+			WHERE i.id = $1 AND i.purchase_order_id = $2`, item.ItemID, id).Scan(&productID, &ordered, &received, &unitCost, &factor, &serialized)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("Item %d does not belong to purchase order %d", item.ItemID, id)
+			}
+			return nil, err
+		}
+		if item.Quantity > ordered-received {
+			return nil, fmt.Errorf("Only %s of item %d are still expected", ordered-received, item.ItemID)
+		}
+		if item.UnitCost != nil {
+			unitCost = *item.UnitCost
+		}
+		// Cost is kept per base unit, a carton's cost is spread over its pieces.
+		quantity := item.Quantity.Mul(factor)
+		if serialized && quantity != models.Qty(len(item.Serials)) {
+			return nil, fmt.Errorf("Item %d needs %s serial numbers, one per unit", item.ItemID, quantity)
+		}
+		if !serialized && len(item.Serials) > 0 {
+			return nil, fmt.Errorf("The product of item %d has no serial numbers", item.ItemID)
+		}
+		gi := models.GoodsReceiptItem{ItemID: item.ItemID, ProductID: productID, Quantity: item.Quantity, UnitCost: unitCost, BatchNumber: item.BatchNumber, Serials: item.Serials}
+		if item.BatchNumber != "" {
+			lotID, expiry, err := addToLot(tx, productID, outletID, item.BatchNumber, item.ExpiryDate, quantity)
+			if err != nil {
+				return nil, err
+			}
+			gi.LotID, gi.ExpiryDate = &lotID, expiry
+		}
+		err = tx.QueryRow("INSERT INTO goods_receipt_items(receipt_id, item_id, product_id, quantity, unit_cost, lot_id) VALUES($1, $2, $3, $4, $5, $6) RETURNING id", receipt.ID, item.ItemID, productID, item.Quantity, unitCost, gi.LotID).Scan(&gi.ID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE purchase_order_items SET received_qty = received_qty + $1 WHERE id = $2", item.Quantity, item.ItemID); err != nil {
+			return nil, err
+		}
+		if err := registerSerials(tx, productID, outletID, &receipt.ID, item.Serials); err != nil {
+			return nil, err
+		}
+		baseCost := int((int64(unitCost)*models.QuantityScale + int64(factor)/2) / int64(factor))
+		if err := receiveCost(tx, productID, quantity, baseCost, &receipt.ID); err != nil {
+			return nil, err
+		}
+		_, err = adjustStock(tx, &models.StockMovement{
+			ProductID:     productID,
+			OutletID:      outletID,
+			Delta:         quantity,
+			Reason:        models.StockReceiving,
+			ReferenceType: models.RefGoodsReceipt,
+			ReferenceID:   &receipt.ID,
+			CreatedBy:     req.ReceivedBy,
+		})
+		if err != nil {
+			return nil, err
+		}
+		receivedAmount += item.Quantity.Amount(unitCost)
+		receipt.Items = append(receipt.Items, gi)
+	}
+
+	var outstanding models.Quantity
+	if err := tx.QueryRow("SELECT COALESCE(SUM(quantity - received_qty), 0) FROM purchase_order_items WHERE purchase_order_id = $1", id).Scan(&outstanding); err != nil {
+		return nil, err
+	}
+	status = models.POStatusPartiallyReceived
+	if outstanding == 0 {
+		status = models.POStatusReceived
+	}
+	_, err = tx.Exec("UPDATE purchase_orders SET status = $1, received_amount = received_amount + $2 WHERE id = $3", status, receivedAmount, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// PaySupplier records a payment against what has been received on the order.
+func (r *PurchaseOrderRepositoryImpl) PaySupplier(id int, req *models.SupplierPaymentRequest) (*models.SupplierPayment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPurchaseOrder(tx, id); err != nil {
+		return nil, err
+	}
+	var receivedAmount, paidAmount int
+	if err := tx.QueryRow("SELECT received_amount, paid_amount FROM purchase_orders WHERE id = $1", id).Scan(&receivedAmount, &paidAmount); err != nil {
+		return nil, err
+	}
+	if req.Amount > receivedAmount-paidAmount {
+		return nil, fmt.Errorf("Only %d is owed on purchase order %d", receivedAmount-paidAmount, id)
+	}
+	payment := models.SupplierPayment{
+		PurchaseOrderID: id,
+		Amount:          req.Amount,
+		Method:          req.Method,
+		Reference:       req.Reference,
+		PaidBy:          req.PaidBy,
+	}
+	err = tx.QueryRow("INSERT INTO supplier_payments(purchase_order_id, amount, method, reference, paid_by) VALUES($1, $2, $3, $4, $5) RETURNING id, paid_at", id, req.Amount, req.Method, req.Reference, req.PaidBy).Scan(&payment.ID, &payment.PaidAt)
+	if err != nil {
+		log.Printf("Error creating supplier payment: %v", err)
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE purchase_orders SET paid_amount = paid_amount + $1 WHERE id = $2", req.Amount, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
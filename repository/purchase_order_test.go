@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"gokasir-api/models"
+	"testing"
+)
+
+// newPurchaseOrder orders items for an outlet from a new supplier.
+func newPurchaseOrder(t *testing.T, db *sql.DB, outletID int, items ...models.PurchaseOrderItem) *models.PurchaseOrder {
+	t.Helper()
+	s := models.Supplier{Name: unique("Supplier ")}
+	if err := NewSupplierRepository(db).CreateSupplier(&s); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewPurchaseOrderRepository(db)
+	po := models.PurchaseOrder{SupplierID: s.ID, OutletID: &outletID, CreatedBy: "test", Items: items}
+	if err := repo.CreatePurchaseOrder(&po); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.OrderPurchaseOrder(po.ID); err != nil {
+		t.Fatal(err)
+	}
+	return &po
+}
+
+func TestReceivePurchaseOrder(t *testing.T) {
+	db := testDB(t)
+	repo := NewPurchaseOrderRepository(db)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{})
+	po := newPurchaseOrder(t, db, outlet, models.PurchaseOrderItem{ProductID: product, Quantity: models.Qty(10), UnitCost: 5000})
+	if po.TotalAmount != 50000 {
+		t.Errorf("total %d, want 50000", po.TotalAmount)
+	}
+	item := po.Items[0].ID
+
+	receive := func(quantity models.Quantity, unitCost *int) error {
+		_, err := repo.ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+			ReceivedBy: "test",
+			Items:      []models.ReceiveItem{{ItemID: item, Quantity: quantity, UnitCost: unitCost}},
+		})
+		return err
+	}
+	if err := receive(models.Qty(4), nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.FindPurchaseOrderByID(po.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.POStatusPartiallyReceived || got.Items[0].ReceivedQty != models.Qty(4) || got.ReceivedAmount != 20000 {
+		t.Errorf("after the first delivery: %s, %s received worth %d", got.Status, got.Items[0].ReceivedQty, got.ReceivedAmount)
+	}
+	if stock := stockOf(t, db, product, outlet); stock != models.Qty(4) {
+		t.Errorf("stock %s, want 4", stock)
+	}
+
+	if err := receive(models.Qty(7), nil); err == nil {
+		t.Error("received more than is still expected")
+	}
+	dearer := 6000
+	if err := receive(models.Qty(6), &dearer); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repo.FindPurchaseOrderByID(po.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.POStatusReceived || got.ReceivedAmount != 56000 || len(got.Receipts) != 2 {
+		t.Errorf("after the last delivery: %s, worth %d in %d receipts", got.Status, got.ReceivedAmount, len(got.Receipts))
+	}
+	if stock := stockOf(t, db, product, outlet); stock != models.Qty(10) {
+		t.Errorf("stock %s, want 10", stock)
+	}
+	if cost := costOf(t, db, product); cost != 5600 {
+		t.Errorf("average cost %d, want 5600", cost)
+	}
+	history, err := NewProductRepository(db).FindStockHistory(product, &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range history {
+		if m.Reason != models.StockReceiving || m.ReferenceType != models.RefGoodsReceipt {
+			t.Errorf("delivery booked as %s of %s", m.Reason, m.ReferenceType)
+		}
+	}
+	if err := receive(models.Qty(1), nil); err == nil {
+		t.Error("received against a fully received order")
+	}
+	if err := repo.CancelPurchaseOrder(po.ID); err == nil {
+		t.Error("cancelled a received order")
+	}
+
+	if _, err := repo.PaySupplier(po.ID, &models.SupplierPaymentRequest{Amount: 60000, Method: models.PaymentTransfer}); err == nil {
+		t.Error("paid more than is owed")
+	}
+	if _, err := repo.PaySupplier(po.ID, &models.SupplierPaymentRequest{Amount: 50000, Method: models.PaymentTransfer}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repo.FindPurchaseOrderByID(po.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Outstanding != 6000 {
+		t.Errorf("outstanding %d, want 6000", got.Outstanding)
+	}
+}
+
+func TestPurchaseOrderStatus(t *testing.T) {
+	db := testDB(t)
+	repo := NewPurchaseOrderRepository(db)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{})
+	po := newPurchaseOrder(t, db, outlet, models.PurchaseOrderItem{ProductID: product, Quantity: models.Qty(1), UnitCost: 1000})
+
+	if err := repo.UpdatePurchaseOrder(po.ID, po); err == nil {
+		t.Error("changed an ordered purchase order")
+	}
+	if err := repo.OrderPurchaseOrder(po.ID); err == nil {
+		t.Error("ordered a purchase order twice")
+	}
+	if err := repo.CancelPurchaseOrder(po.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err := repo.ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+		ReceivedBy: "test",
+		Items:      []models.ReceiveItem{{ItemID: po.Items[0].ID, Quantity: models.Qty(1)}},
+	})
+	if err == nil {
+		t.Error("received a cancelled purchase order")
+	}
+	if stock := stockOf(t, db, product, outlet); stock != 0 {
+		t.Errorf("stock %s, want none", stock)
+	}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"gokasir-api/models"
+	"testing"
+	"time"
+)
+
+func TestRecipe(t *testing.T) {
+	db := testDB(t)
+	repo := NewProductRepository(db)
+	outlet := newOutlet(t, db)
+	flour := newProduct(t, db, models.Product{IsIngredient: true, Unit: "g", Cost: 20})
+	egg := newProduct(t, db, models.Product{IsIngredient: true, Cost: 2000})
+	stockUp(t, db, flour, outlet, models.Qty(1000))
+	stockUp(t, db, egg, outlet, models.Qty(10))
+
+	cake := newProduct(t, db, models.Product{Price: 25000})
+	err := repo.SetRecipe(cake, &models.SetRecipeRequest{Items: []models.RecipeItem{
+		{IngredientID: flour, Quantity: models.Qty(200)},
+		{IngredientID: egg, Quantity: models.Qty(2)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipe, err := repo.FindRecipe(cake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipe.Items) != 2 || recipe.Items[0].Unit != "g" {
+		t.Errorf("recipe %+v", recipe.Items)
+	}
+
+	sale, err := sell(db, outlet, models.CheckoutItem{ProductID: cake, Quantity: models.Qty(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stock := stockOf(t, db, flour, outlet); stock != models.Qty(400) {
+		t.Errorf("flour stock %s, want 400 g", stock)
+	}
+	if stock := stockOf(t, db, egg, outlet); stock != models.Qty(4) {
+		t.Errorf("egg stock %s, want 4", stock)
+	}
+	if d := sale.Details[0]; d.Cost != 24000 || len(d.Ingredients) != 2 {
+		t.Errorf("cake line costs %d from %d ingredients, want 24000 from 2", d.Cost, len(d.Ingredients))
+	}
+	history, err := repo.FindStockHistory(flour, &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := history[0]; m.Reason != models.StockUsage || m.Delta != -models.Qty(600) {
+		t.Errorf("flour booked %s as %s, want -600 as usage", m.Delta, m.Reason)
+	}
+
+	if _, err := sell(db, outlet, models.CheckoutItem{ProductID: cake, Quantity: models.Qty(3)}); err == nil {
+		t.Error("sold more cakes than the flour is enough for")
+	}
+	if _, err := sell(db, outlet, models.CheckoutItem{ProductID: flour, Quantity: models.Qty(100)}); err == nil {
+		t.Error("sold an ingredient")
+	}
+
+	// Baked cakes that come back do not put their flour back on the shelf.
+	void(t, db, sale.ID)
+	if stock := stockOf(t, db, flour, outlet); stock != models.Qty(400) {
+		t.Errorf("flour stock %s after the void, want 400 g", stock)
+	}
+
+	day := 24 * time.Hour
+	usage, err := NewInventoryRepository(db).IngredientUsage(time.Now().Add(-day).Format(time.DateOnly), time.Now().Add(day).Format(time.DateOnly), &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range usage {
+		if u.IngredientID == flour && u.TheoreticalUsage != models.Qty(600) {
+			t.Errorf("flour usage %s, want 600 g", u.TheoreticalUsage)
+		}
+	}
+}
+
+func TestSetRecipeRefuses(t *testing.T) {
+	db := testDB(t)
+	repo := NewProductRepository(db)
+	ingredient := newProduct(t, db, models.Product{IsIngredient: true})
+	product := newProduct(t, db, models.Product{})
+	bundle := newProduct(t, db, models.Product{})
+	err := repo.SetBundleComponents(bundle, &models.SetBundleRequest{Components: []models.BundleComponent{{ProductID: product, Quantity: models.Qty(1)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		id    int
+		items []models.RecipeItem
+	}{
+		{"not an ingredient", newProduct(t, db, models.Product{}), []models.RecipeItem{{IngredientID: product, Quantity: models.Qty(1)}}},
+		{"an ingredient", ingredient, []models.RecipeItem{{IngredientID: ingredient, Quantity: models.Qty(1)}}},
+		{"a bundle", bundle, []models.RecipeItem{{IngredientID: ingredient, Quantity: models.Qty(1)}}},
+		{"a component", product, []models.RecipeItem{{IngredientID: ingredient, Quantity: models.Qty(1)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.SetRecipe(tt.id, &models.SetRecipeRequest{Items: tt.items}); err == nil {
+				t.Error("SetRecipe accepted the recipe")
+			}
+		})
+	}
+}
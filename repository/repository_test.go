@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"gokasir-api/database"
+	"gokasir-api/models"
+	"gokasir-api/pricing"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// The repository tests run against PostgreSQL. Point TEST_DB_CONN at a
+// throwaway database set up the way the API's own one is; the migrations
+// are run on it once. Every test makes its own outlets and products, so
+// the tests can share the database and leave their rows behind.
+
+var (
+	testOnce sync.Once
+	testConn *sql.DB
+	testErr  error
+	testSeq  atomic.Int64
+)
+
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn := os.Getenv("TEST_DB_CONN")
+	if conn == "" {
+		t.Skip("TEST_DB_CONN is not set")
+	}
+	testOnce.Do(func() {
+		testConn, testErr = database.InitDB(conn)
+		if testErr == nil {
+			testErr = database.Migrate(testConn)
+		}
+	})
+	if testErr != nil {
+		t.Fatal(testErr)
+	}
+	return testConn
+}
+
+// unique returns a name no other test run has used.
+func unique(prefix string) string {
+	return fmt.Sprintf("%s%x", prefix, time.Now().UnixNano()+testSeq.Add(1))
+}
+
+func newOutlet(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	o := models.Outlet{Code: unique("T"), Name: "Test outlet", PaperWidth: 80}
+	if err := NewOutletRepository(db).CreateOutlet(&o); err != nil {
+		t.Fatal(err)
+	}
+	return o.ID
+}
+
+// newProduct creates p without stock, filling in what a test leaves out.
+func newProduct(t *testing.T, db *sql.DB, p models.Product) int {
+	t.Helper()
+	if p.Name == "" {
+		p.Name = unique("Product ")
+	}
+	if p.Price == 0 {
+		p.Price = 10000
+	}
+	if p.Unit == "" {
+		p.Unit = models.DefaultUnit
+	}
+	if p.CostMethod == "" {
+		p.CostMethod = models.CostAverage
+	}
+	if p.Category_ID == 0 {
+		c := models.Category{Name: unique("Category ")}
+		if err := NewCategoryRepository(db).CreateCategory(&c); err != nil {
+			t.Fatal(err)
+		}
+		p.Category_ID = c.ID
+	}
+	p.Stock = 0
+	if err := NewProductRepository(db).CreateProduct(&p); err != nil {
+		t.Fatal(err)
+	}
+	return p.ID
+}
+
+// stockUp sets a product's stock at an outlet the way a stock correction does.
+func stockUp(t *testing.T, db *sql.DB, productID, outletID int, stock models.Quantity) {
+	t.Helper()
+	req := models.SetOutletStockRequest{Stock: stock, UpdatedBy: "test"}
+	if err := NewOutletRepository(db).SetOutletStock(outletID, productID, &req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkout is a transaction repository without tax or a required shift.
+func checkout(db *sql.DB) TransactionRepository {
+	return NewTransactionRepository(db, pricing.DefaultTaxConfig(), pricing.DefaultLoyaltyConfig(), nil, false)
+}
+
+// sell checks items out at an outlet, paid in cash.
+func sell(db *sql.DB, outletID int, items ...models.CheckoutItem) (*models.Transaction, error) {
+	return checkout(db).CreateTransaction(&models.CheckoutRequest{
+		OutletID: &outletID,
+		Cashier:  "test",
+		Items:    items,
+		Payments: []models.PaymentRequest{{Method: models.PaymentCash, Amount: 100000000}},
+	})
+}
+
+// void voids a sale, returning what it sold.
+func void(t *testing.T, db *sql.DB, id int) *models.Transaction {
+	t.Helper()
+	refund, err := checkout(db).RefundTransaction(id, &models.RefundRequest{Reason: "test", RefundedBy: "test", Method: models.PaymentCash}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return refund
+}
+
+func stockOf(t *testing.T, db *sql.DB, productID, outletID int) models.Quantity {
+	t.Helper()
+	stock, err := stockAt(db, productID, &outletID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stock
+}
+
+func costOf(t *testing.T, db *sql.DB, productID int) int {
+	t.Helper()
+	var cost int
+	if err := db.QueryRow("SELECT cost FROM product WHERE id = $1", productID).Scan(&cost); err != nil {
+		t.Fatal(err)
+	}
+	return cost
+}
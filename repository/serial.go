@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"gokasir-api/models"
+
+	"github.com/lib/pq"
+)
+
+// registerSerials adds serials for units of a product coming into stock at
+// an outlet, from a goods receipt when receiptID is set.
+func registerSerials(q queryer, productID int, outletID, receiptID *int, serials []string) error {
+	for _, s := range serials {
+		var exists bool
+		if err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM serial_numbers WHERE serial = $1)", s).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%w: %s", models.ErrDuplicateSerial, s)
+		}
+		_, err := q.Exec("INSERT INTO serial_numbers (serial, product_id, outlet_id, status, goods_receipt_id) VALUES ($1, $2, $3, $4, $5)",
+			s, productID, outletID, models.SerialInStock, receiptID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sellSerials marks the serials picked for a transaction line as sold and
+// records them against the line together with the product's warranty.
+func sellSerials(q queryer, detailID, productID int, outletID *int, serials []string) error {
+	for _, s := range serials {
+		var serialID int
+		err := q.QueryRow(`UPDATE serial_numbers SET status = $1
+			WHERE serial = $2 AND product_id = $3 AND outlet_id IS NOT DISTINCT FROM $4 AND status = $5 RETURNING id`,
+			models.SerialSold, s, productID, outletID, models.SerialInStock).Scan(&serialID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("Serial %s is not in stock here", s)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = q.Exec(`INSERT INTO transaction_detail_serials (detail_id, serial_id, warranty_months)
+			SELECT $1, $2, warranty_months FROM product WHERE id = $3`, detailID, serialID, productID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// soldSerials lists the serials sold on a sale line that have not been
+// returned yet.
+func soldSerials(q queryer, saleDetailID int) ([]string, error) {
+	rows, err := q.Query(`SELECT s.serial FROM transaction_detail_serials ds
+		INNER JOIN serial_numbers s ON s.id = ds.serial_id
+		WHERE ds.detail_id = $1 AND NOT EXISTS (SELECT 1 FROM transaction_detail_serials r
+			INNER JOIN transaction_details rd ON rd.id = r.detail_id
+			WHERE rd.refund_of_detail_id = $1 AND r.serial_id = ds.serial_id)
+		ORDER BY s.serial`, saleDetailID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var serials []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		serials = append(serials, s)
+	}
+	return serials, rows.Err()
+}
+
+// returnSerials puts refunded serials back in stock at the outlet and
+// records them against the refund line.
+func returnSerials(q queryer, detailID int, outletID *int, serials []string) error {
+	for _, s := range serials {
+		var serialID int
+		err := q.QueryRow("UPDATE serial_numbers SET status = $1, outlet_id = $2 WHERE serial = $3 RETURNING id", models.SerialInStock, outletID, s).Scan(&serialID)
+		if err != nil {
+			return err
+		}
+		if _, err := q.Exec("INSERT INTO transaction_detail_serials (detail_id, serial_id) VALUES ($1, $2)", detailID, serialID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// detailSerials loads the serials recorded for transaction lines, keyed by
+// detail ID.
+func detailSerials(q queryer, detailIDs []int) (map[int][]string, error) {
+	serials := make(map[int][]string)
+	if len(detailIDs) == 0 {
+		return serials, nil
+	}
+	rows, err := q.Query(`SELECT ds.detail_id, s.serial FROM transaction_detail_serials ds
+		INNER JOIN serial_numbers s ON s.id = ds.serial_id
+		WHERE ds.detail_id = ANY($1) ORDER BY ds.detail_id, s.serial`, pq.Array(detailIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var detailID int
+		var s string
+		if err := rows.Scan(&detailID, &s); err != nil {
+			return nil, err
+		}
+		serials[detailID] = append(serials[detailID], s)
+	}
+	return serials, rows.Err()
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "gokasir-api/models"
+
+type SerialRepository interface {
+	FindAllSerial(productID int, outletID *int, status string) ([]models.SerialNumber, error)
+	FindSerial(serial string) (*models.SerialNumber, error)
+	RegisterSerials(req *models.RegisterSerialsRequest) error
+}
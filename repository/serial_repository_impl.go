@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"log"
+	"time"
+)
+
+type SerialRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewSerialRepository(db *sql.DB) SerialRepository {
+	return &SerialRepositoryImpl{db: db}
+}
+
+const serialColumns = `s.id, s.serial, s.product_id, p.name, s.outlet_id, s.status, s.goods_receipt_id, s.created_at
+	FROM serial_numbers s INNER JOIN product p ON p.id = s.product_id`
+
+func serialFields(s *models.SerialNumber) []any {
+	return []any{&s.ID, &s.Serial, &s.ProductID, &s.ProductName, &s.OutletID, &s.Status, &s.GoodsReceiptID, &s.CreatedAt}
+}
+
+func (r *SerialRepositoryImpl) FindAllSerial(productID int, outletID *int, status string) ([]models.SerialNumber, error) {
+	rows, err := r.db.Query("SELECT "+serialColumns+` WHERE s.product_id = $1 AND ($2::int IS NULL OR s.outlet_id = $2) AND ($3 = '' OR s.status = $3)
+		ORDER BY s.serial`, productID, outletID, status)
+	if err != nil {
+		log.Printf("Error getting all serial: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	serials := make([]models.SerialNumber, 0)
+	for rows.Next() {
+		var s models.SerialNumber
+		if err := rows.Scan(serialFields(&s)...); err != nil {
+			return nil, err
+		}
+		serials = append(serials, s)
+	}
+	return serials, rows.Err()
+}
+
+// FindSerial looks a unit up by its serial. A sold unit comes with its
+// sale as proof of purchase and the warranty it was sold with.
+func (r *SerialRepositoryImpl) FindSerial(serial string) (*models.SerialNumber, error) {
+	var s models.SerialNumber
+	if err := r.db.QueryRow("SELECT "+serialColumns+" WHERE s.serial = $1", serial).Scan(serialFields(&s)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Serial number not found")
+		}
+		log.Printf("Error getting single serial: %v", err)
+		return nil, err
+	}
+	if s.Status != models.SerialSold {
+		return &s, nil
+	}
+
+	var sale models.SerialSale
+	err := r.db.QueryRow(`SELECT t.id, d.id, t.created_at, t.customer_id, COALESCE(c.name, ''), ds.warranty_months
+		FROM transaction_detail_serials ds
+		INNER JOIN transaction_details d ON d.id = ds.detail_id
+		INNER JOIN transactions t ON t.id = d.transaction_id
+		LEFT JOIN customers c ON c.id = t.customer_id
+		WHERE ds.serial_id = $1 AND t.type = $2
+		ORDER BY t.created_at DESC, t.id DESC LIMIT 1`, s.ID, models.TransactionSale).Scan(&sale.TransactionID, &sale.DetailID, &sale.SoldAt, &sale.CustomerID, &sale.CustomerName, &sale.WarrantyMonths)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &s, nil
+		}
+		log.Printf("Error getting sale of serial: %v", err)
+		return nil, err
+	}
+	if sale.WarrantyMonths > 0 {
+		sale.WarrantyExpiry = sale.SoldAt.AddDate(0, sale.WarrantyMonths, 0).Format(models.DateLayout)
+		sale.UnderWarranty = time.Now().Format(models.DateLayout) <= sale.WarrantyExpiry
+	}
+	s.Sale = &sale
+	return &s, nil
+}
+
+// RegisterSerials adds serials for stock already at an outlet, at most one
+// per unit that has no serial yet.
+func (r *SerialRepositoryImpl) RegisterSerials(req *models.RegisterSerialsRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var name string
+	var serialized bool
+	err = tx.QueryRow("SELECT name, serialized FROM product WHERE id = $1", req.ProductID).Scan(&name, &serialized)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("Product %d not found", req.ProductID)
+		}
+		return err
+	}
+	if !serialized {
+		return fmt.Errorf("%s has no serial numbers", name)
+	}
+	if req.OutletID, err = stockOutlet(tx, req.OutletID); err != nil {
+		return err
+	}
+	stock, err := stockAt(tx, req.ProductID, req.OutletID)
+	if err != nil {
+		return err
+	}
+	var registered int
+	err = tx.QueryRow("SELECT COUNT(*) FROM serial_numbers WHERE product_id = $1 AND ($2::int IS NULL OR outlet_id = $2) AND status = $3",
+		req.ProductID, req.OutletID, models.SerialInStock).Scan(&registered)
+	if err != nil {
+		return err
+	}
+	if unregistered := stock - models.Qty(registered); models.Qty(len(req.Serials)) > unregistered {
+		return fmt.Errorf("Only %s units of %s have no serial yet", max(unregistered, 0), name)
+	}
+	if err := registerSerials(tx, req.ProductID, req.OutletID, nil, req.Serials); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+	"gokasir-api/models"
+	"testing"
+	"time"
+)
+
+func TestSerials(t *testing.T) {
+	db := testDB(t)
+	repo := NewSerialRepository(db)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{Serialized: true, WarrantyMonths: 12})
+	a, b, c, d := unique("SN-A"), unique("SN-B"), unique("SN-C"), unique("SN-D")
+
+	po := newPurchaseOrder(t, db, outlet, models.PurchaseOrderItem{ProductID: product, Quantity: models.Qty(3), UnitCost: 100000})
+	receiveSerials := func(serials ...string) error {
+		_, err := NewPurchaseOrderRepository(db).ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+			ReceivedBy: "test",
+			Items:      []models.ReceiveItem{{ItemID: po.Items[0].ID, Quantity: models.Qty(len(serials)), Serials: serials}},
+		})
+		return err
+	}
+	if err := receiveSerials(a, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiveSerials(a); !errors.Is(err, models.ErrDuplicateSerial) {
+		t.Errorf("received a serial twice: %v", err)
+	}
+	_, err := NewPurchaseOrderRepository(db).ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+		ReceivedBy: "test",
+		Items:      []models.ReceiveItem{{ItemID: po.Items[0].ID, Quantity: models.Qty(1)}},
+	})
+	if err == nil {
+		t.Error("received a serialised unit without its serial")
+	}
+	inStock, err := repo.FindAllSerial(product, &outlet, models.SerialInStock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inStock) != 2 {
+		t.Errorf("%d serials in stock, want 2", len(inStock))
+	}
+
+	if _, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(1)}); err == nil {
+		t.Error("sold a serialised unit without picking its serial")
+	}
+	if _, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(1), Serials: []string{c}}); err == nil {
+		t.Error("sold a serial that is not in stock")
+	}
+	sale, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(1), Serials: []string{a}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unit, err := repo.FindSerial(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unit.Status != models.SerialSold || unit.Sale == nil || unit.Sale.TransactionID != sale.ID {
+		t.Fatalf("serial %s is %s after its sale", a, unit.Status)
+	}
+	expiry := unit.Sale.SoldAt.AddDate(1, 0, 0).Format(models.DateLayout)
+	if unit.Sale.WarrantyMonths != 12 || unit.Sale.WarrantyExpiry != expiry || !unit.Sale.UnderWarranty {
+		t.Errorf("warranty of %d months until %s, want 12 until %s", unit.Sale.WarrantyMonths, unit.Sale.WarrantyExpiry, expiry)
+	}
+
+	_, err = checkout(db).RefundTransaction(sale.ID, &models.RefundRequest{
+		Reason:     "test",
+		RefundedBy: "test",
+		Method:     models.PaymentCash,
+		Items:      []models.RefundItem{{DetailID: sale.Details[0].ID, Quantity: models.Qty(1), Serials: []string{a}}},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unit, err := repo.FindSerial(a); err != nil || unit.Status != models.SerialInStock || unit.Sale != nil {
+		t.Errorf("serial %s after its refund: %+v, %v", a, unit, err)
+	}
+
+	// Opening stock gets its serials registered, one per unit without one.
+	stockUp(t, db, product, outlet, models.Qty(3))
+	if err := repo.RegisterSerials(&models.RegisterSerialsRequest{ProductID: product, OutletID: &outlet, Serials: []string{c}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RegisterSerials(&models.RegisterSerialsRequest{ProductID: product, OutletID: &outlet, Serials: []string{d}}); err == nil {
+		t.Error("registered more serials than there are units")
+	}
+}
+
+func TestWarrantyExpired(t *testing.T) {
+	db := testDB(t)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{Serialized: true, WarrantyMonths: 1})
+	serial := unique("SN-")
+	stockUp(t, db, product, outlet, models.Qty(1))
+	if err := NewSerialRepository(db).RegisterSerials(&models.RegisterSerialsRequest{ProductID: product, OutletID: &outlet, Serials: []string{serial}}); err != nil {
+		t.Fatal(err)
+	}
+	sale, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(1), Serials: []string{serial}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	soldAt := time.Now().AddDate(0, -2, 0)
+	if _, err := db.Exec("UPDATE transactions SET created_at = $1 WHERE id = $2", soldAt, sale.ID); err != nil {
+		t.Fatal(err)
+	}
+	unit, err := NewSerialRepository(db).FindSerial(serial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unit.Sale == nil || unit.Sale.UnderWarranty {
+		t.Errorf("a unit sold two months ago is still under its one month warranty: %+v", unit.Sale)
+	}
+}
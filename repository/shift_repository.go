@@ -0,0 +1,12 @@
+package repository
+
+import "gokasir-api/models"
+
+type ShiftRepository interface {
+	OpenShift(req *models.Shift) error
+	FindCurrentShift(outletID *int) (*models.Shift, error)
+	FindShiftByID(id int) (*models.Shift, error)
+	CreateCashMovement(req *models.CashMovement) error
+	ShiftSummary(id int) (*models.ShiftSummary, error)
+	CloseShift(id int, req *models.CloseShiftRequest) (*models.ShiftSummary, error)
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"gokasir-api/models"
+	"log"
+)
+
+type ShiftRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) ShiftRepository {
+	return &ShiftRepositoryImpl{db: db}
+}
+
+const shiftColumns = "id, outlet_id, cashier, status, opening_cash, opened_at, closed_at, expected_cash, counted_cash, variance, denominations, notes"
+
+func scanShift(scan func(dest ...any) error) (*models.Shift, error) {
+	var s models.Shift
+	var denominations []byte
+	if err := scan(&s.ID, &s.OutletID, &s.Cashier, &s.Status, &s.OpeningCash, &s.OpenedAt, &s.ClosedAt, &s.ExpectedCash, &s.CountedCash, &s.Variance, &denominations, &s.Notes); err != nil {
+		return nil, err
+	}
+	if len(denominations) > 0 {
+		if err := json.Unmarshal(denominations, &s.Denominations); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// currentShiftID returns the open shift of the outlet new transactions are
+// linked to, or nil when no shift is open there. The row is locked FOR SHARE
+// so CloseShift, which locks it FOR UPDATE, waits for transactions still
+// being written to it and they cannot land on a shift that has just closed.
+func currentShiftID(q queryer, outletID *int) (*int, error) {
+	var id int
+	err := q.QueryRow("SELECT id FROM shifts WHERE status = 'open' AND outlet_id IS NOT DISTINCT FROM $1 FOR SHARE", outletID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *ShiftRepositoryImpl) OpenShift(req *models.Shift) error {
+	outletID, err := stockOutlet(r.db, req.OutletID)
+	if err != nil {
+		return err
+	}
+	req.OutletID = outletID
+	current, err := currentShiftID(r.db, req.OutletID)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		return errors.New("Another shift is still open")
+	}
+	err = r.db.QueryRow("INSERT INTO shifts(outlet_id, cashier, status, opening_cash) VALUES($1, $2, 'open', $3) RETURNING id, opened_at", req.OutletID, req.Cashier, req.OpeningCash).Scan(&req.ID, &req.OpenedAt)
+	if err != nil {
+		log.Printf("Error opening shift: %v", err)
+		return err
+	}
+	req.Status = models.ShiftOpen
+	return nil
+}
+
+func (r *ShiftRepositoryImpl) FindCurrentShift(outletID *int) (*models.Shift, error) {
+	outletID, err := stockOutlet(r.db, outletID)
+	if err != nil {
+		return nil, err
+	}
+	s, err := scanShift(r.db.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE status = 'open' AND outlet_id IS NOT DISTINCT FROM $1", outletID).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("No shift is open")
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *ShiftRepositoryImpl) FindShiftByID(id int) (*models.Shift, error) {
+	s, err := scanShift(r.db.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE id = $1", id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Shift not found")
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// CreateCashMovement records cash put into or taken out of the drawer of an
+// open shift. The shift is locked FOR SHARE like at checkout, so it cannot
+// close and be reconciled before the movement is written.
+func (r *ShiftRepositoryImpl) CreateCashMovement(req *models.CashMovement) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow("SELECT status FROM shifts WHERE id = $1 FOR SHARE", req.ShiftID).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("Shift not found")
+		}
+		return err
+	}
+	if status != models.ShiftOpen {
+		return errors.New("Shift is already closed")
+	}
+	err = tx.QueryRow("INSERT INTO cash_movements(shift_id, type, amount, reason, created_by) VALUES($1, $2, $3, $4, $5) RETURNING id, created_at", req.ShiftID, req.Type, req.Amount, req.Reason, req.CreatedBy).Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating cash movement: %v", err)
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ShiftRepositoryImpl) ShiftSummary(id int) (*models.ShiftSummary, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	summary, err := shiftSummary(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return summary, tx.Commit()
+}
+
+func (r *ShiftRepositoryImpl) CloseShift(id int, req *models.CloseShiftRequest) (*models.ShiftSummary, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow("SELECT status FROM shifts WHERE id = $1 FOR UPDATE", id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Shift not found")
+		}
+		return nil, err
+	}
+	if status != models.ShiftOpen {
+		return nil, errors.New("Shift is already closed")
+	}
+	summary, err := shiftSummary(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	counted := req.Counted()
+	variance := counted - summary.ExpectedCash
+	var denominations []byte
+	if len(req.Denominations) > 0 {
+		if denominations, err = json.Marshal(req.Denominations); err != nil {
+			return nil, err
+		}
+	}
+	_, err = tx.Exec("UPDATE shifts SET status = 'closed', closed_at = CURRENT_TIMESTAMP, expected_cash = $1, counted_cash = $2, variance = $3, denominations = $4, notes = $5 WHERE id = $6",
+		summary.ExpectedCash, counted, variance, denominations, req.Notes, id)
+	if err != nil {
+		log.Printf("Error closing shift: %v", err)
+		return nil, err
+	}
+	shift, err := scanShift(tx.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE id = $1", id).Scan)
+	if err != nil {
+		return nil, err
+	}
+	summary.Shift = *shift
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func shiftSummary(q queryer, id int) (*models.ShiftSummary, error) {
+	shift, err := scanShift(q.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE id = $1", id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Shift not found")
+		}
+		return nil, err
+	}
+	summary := models.ShiftSummary{
+		Shift:            *shift,
+		PaymentsByMethod: make(map[string]int),
+		Movements:        make([]models.CashMovement, 0),
+	}
+
+	rows, err := q.Query("SELECT p.method, SUM(p.amount) FROM payments p INNER JOIN transactions t ON p.transaction_id = t.id WHERE t.shift_id = $1 GROUP BY p.method", id)
+	if err != nil {
+		log.Printf("Error getting shift payments: %v", err)
+		return nil, err
+	}
+	for rows.Next() {
+		var method string
+		var amount int
+		if err := rows.Scan(&method, &amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		summary.PaymentsByMethod[method] = amount
+	}
+	rows.Close()
+	summary.CashReceived = summary.PaymentsByMethod[models.PaymentCash]
+
+	if err := q.QueryRow("SELECT COALESCE(SUM(change_amount), 0) FROM transactions WHERE shift_id = $1", id).Scan(&summary.ChangeGiven); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query("SELECT id, shift_id, type, amount, reason, created_by, created_at FROM cash_movements WHERE shift_id = $1 ORDER BY id", id)
+	if err != nil {
+		log.Printf("Error getting cash movements: %v", err)
+		return nil, err
+	}
+	for rows.Next() {
+		var m models.CashMovement
+		if err := rows.Scan(&m.ID, &m.ShiftID, &m.Type, &m.Amount, &m.Reason, &m.CreatedBy, &m.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if m.Type == models.CashIn {
+			summary.CashIn += m.Amount
+		} else {
+			summary.CashOut += m.Amount
+		}
+		summary.Movements = append(summary.Movements, m)
+	}
+	rows.Close()
+
+	summary.ExpectedCash = shift.OpeningCash + summary.CashReceived - summary.ChangeGiven + summary.CashIn - summary.CashOut
+	summary.Report, err = buildReport(q, "t.shift_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"log"
+)
+
+// stockAt returns the stock of a product at an outlet, or the product's
+// total stock when outletID is nil. The row is locked until the transaction ends.
+func stockAt(q queryer, productID int, outletID *int) (models.Quantity, error) {
+	var stock models.Quantity
+	var err error
+	if outletID == nil {
+		err = q.QueryRow("SELECT stock FROM product WHERE id = $1 FOR UPDATE", productID).Scan(&stock)
+	} else {
+		err = q.QueryRow("SELECT stock FROM product_stock WHERE product_id = $1 AND outlet_id = $2 FOR UPDATE", productID, *outletID).Scan(&stock)
+	}
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return stock, err
+}
+
+// stockOutlet returns the outlet stock is kept at: outletID once it is known
+// to exist, or the default outlet when none is given.
+func stockOutlet(q queryer, outletID *int) (*int, error) {
+	var id int
+	err := q.QueryRow("SELECT id FROM outlets WHERE CASE WHEN $1::int IS NULL THEN is_default ELSE id = $1 END", outletID).Scan(&id)
+	if err == sql.ErrNoRows {
+		if outletID == nil {
+			return nil, errors.New("No default outlet is set up")
+		}
+		return nil, fmt.Errorf("Outlet %d not found", *outletID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// adjustStock moves a product's stock at an outlet by m.Delta and records
+// the movement in the stock ledger, filling in its ID, balance and time.
+// product.stock moves with it so it always holds the total over all
+// outlets. Every stock change must go through here. Stock that goes out
+// comes from the earliest expiring lots whenever the lots would otherwise
+// hold more than the outlet has.
+func adjustStock(q queryer, m *models.StockMovement) (models.Quantity, error) {
+	if m.OutletID == nil {
+		return 0, errors.New("Stock can only move at an outlet")
+	}
+	var total models.Quantity
+	var bundle bool
+	err := q.QueryRow("UPDATE product SET stock = stock + $1 WHERE id = $2 RETURNING stock, is_bundle", m.Delta, m.ProductID).Scan(&total, &bundle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("Product %d not found", m.ProductID)
+		}
+		return 0, err
+	}
+	if bundle {
+		return 0, fmt.Errorf("Product %d is a bundle, its stock comes from its components", m.ProductID)
+	}
+	err = q.QueryRow(`INSERT INTO product_stock (product_id, outlet_id, stock) VALUES ($1, $2, $3)
+		ON CONFLICT (product_id, outlet_id) DO UPDATE SET stock = product_stock.stock + EXCLUDED.stock
+		RETURNING stock`, m.ProductID, *m.OutletID, m.Delta).Scan(&m.Balance)
+	if err != nil {
+		return 0, err
+	}
+	if total < 0 || m.Balance < 0 {
+		return 0, errors.New("Stock cannot go below zero")
+	}
+	if m.Delta < 0 {
+		if err := trimLots(q, m.ProductID, m.OutletID, m.Balance); err != nil {
+			return 0, err
+		}
+	}
+
+	err = q.QueryRow(`INSERT INTO stock_movements (product_id, outlet_id, delta, balance, reason, reference_type, reference_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`,
+		m.ProductID, m.OutletID, m.Delta, m.Balance, m.Reason, m.ReferenceType, m.ReferenceID, m.CreatedBy).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		log.Printf("Error recording stock movement: %v", err)
+		return 0, err
+	}
+	return m.Balance, nil
+}
+
+// productUnit returns how many base units one unit of a product holds and,
+// when the unit has a price of its own, what it sells for. No unit or the
+// base unit is a factor of 1.
+func productUnit(q queryer, productID int, unit string) (models.Quantity, *int, error) {
+	if unit == "" {
+		return models.Qty(1), nil, nil
+	}
+	var name, baseUnit string
+	var factor models.Quantity
+	var price *int
+	err := q.QueryRow(`SELECT p.name, p.unit, u.factor, u.price FROM product p
+		LEFT JOIN product_units u ON u.product_id = p.id AND u.name = $2
+		WHERE p.id = $1`, productID, unit).Scan(&name, &baseUnit, &factor, &price)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil, fmt.Errorf("Product %d not found", productID)
+		}
+		return 0, nil, err
+	}
+	if unit == baseUnit {
+		return models.Qty(1), nil, nil
+	}
+	if factor <= 0 {
+		return 0, nil, fmt.Errorf("%s has no unit %s", name, unit)
+	}
+	return factor, price, nil
+}
+
+// bundleComponents lists the components of a bundle with their stock at the
+// outlet, or over all outlets when outletID is nil.
+func bundleComponents(q queryer, bundleID int, outletID *int) ([]models.BundleComponent, error) {
+	rows, err := q.Query(`SELECT p.id, p.name, bc.quantity,
+			CASE WHEN $2::int IS NULL THEN p.stock ELSE COALESCE(s.stock, 0) END
+		FROM bundle_components bc INNER JOIN product p ON p.id = bc.component_id
+		LEFT JOIN product_stock s ON s.product_id = p.id AND s.outlet_id = $2
+		WHERE bc.bundle_id = $1 ORDER BY p.id`, bundleID, outletID)
+	if err != nil {
+		log.Printf("Error getting bundle components: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	components := make([]models.BundleComponent, 0)
+	for rows.Next() {
+		var c models.BundleComponent
+		if err := rows.Scan(&c.ProductID, &c.ProductName, &c.Quantity, &c.Stock); err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	return components, rows.Err()
+}
+
+// bundleAvailable is how many bundles the component stock is enough for.
+func bundleAvailable(components []models.BundleComponent) int {
+	available := 0
+	for i, c := range components {
+		n := c.Stock.Div(c.Quantity)
+		if i == 0 || n < available {
+			available = n
+		}
+	}
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
+// stockMovements lists the ledger of a product, newest first, optionally
+// limited to one outlet.
+func stockMovements(q queryer, productID int, outletID *int) ([]models.StockMovement, error) {
+	rows, err := q.Query(`SELECT id, product_id, outlet_id, delta, balance, reason, reference_type, reference_id, created_by, created_at
+		FROM stock_movements WHERE product_id = $1 AND ($2::int IS NULL OR outlet_id = $2)
+		ORDER BY id DESC`, productID, outletID)
+	if err != nil {
+		log.Printf("Error getting stock movements: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	movements := make([]models.StockMovement, 0)
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.OutletID, &m.Delta, &m.Balance, &m.Reason, &m.ReferenceType, &m.ReferenceID, &m.CreatedBy, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+	return movements, nil
+}
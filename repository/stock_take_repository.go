@@ -0,0 +1,12 @@
+package repository
+
+import "gokasir-api/models"
+
+type StockTakeRepository interface {
+	FindAllStockTake(status string) ([]models.StockTake, error)
+	StartStockTake(req *models.StockTake, productIDs []int) error
+	FindStockTakeByID(id int) (*models.StockTake, error)
+	RecordCounts(id int, req *models.StockCountRequest) error
+	ApproveStockTake(id int, approvedBy string) error
+	CancelStockTake(id int) error
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+type StockTakeRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewStockTakeRepository(db *sql.DB) StockTakeRepository {
+	return &StockTakeRepositoryImpl{db: db}
+}
+
+const stockTakeColumns = "id, outlet_id, status, notes, created_by, created_at, approved_by, approved_at"
+
+func stockTakeFields(st *models.StockTake) []any {
+	return []any{&st.ID, &st.OutletID, &st.Status, &st.Notes, &st.CreatedBy, &st.CreatedAt, &st.ApprovedBy, &st.ApprovedAt}
+}
+
+func (r *StockTakeRepositoryImpl) FindAllStockTake(status string) ([]models.StockTake, error) {
+	rows, err := r.db.Query("SELECT "+stockTakeColumns+" FROM stock_takes WHERE $1 = '' OR status = $1 ORDER BY id DESC", status)
+	if err != nil {
+		log.Printf("Error getting all stock take: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	stockTakes := make([]models.StockTake, 0)
+	for rows.Next() {
+		var st models.StockTake
+		if err := rows.Scan(stockTakeFields(&st)...); err != nil {
+			return nil, err
+		}
+		stockTakes = append(stockTakes, st)
+	}
+	return stockTakes, nil
+}
+
+// StartStockTake snapshots the stock of the outlet (or the total stock when
+// no outlet is given) together with the current end of the stock ledger.
+func (r *StockTakeRepositoryImpl) StartStockTake(req *models.StockTake, productIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Hold off stock changes so the snapshot and the ledger position agree.
+	if _, err := tx.Exec("LOCK TABLE stock_movements IN SHARE MODE"); err != nil {
+		return err
+	}
+	if req.OutletID, err = stockOutlet(tx, req.OutletID); err != nil {
+		return err
+	}
+	var open int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM stock_takes WHERE status = 'open' AND outlet_id IS NOT DISTINCT FROM $1", req.OutletID).Scan(&open); err != nil {
+		return err
+	}
+	if open > 0 {
+		return errors.New("Another stock take is still open")
+	}
+	err = tx.QueryRow(`INSERT INTO stock_takes(outlet_id, status, notes, snapshot_movement_id, created_by)
+		VALUES($1, 'open', $2, (SELECT COALESCE(MAX(id), 0) FROM stock_movements), $3) RETURNING id, created_at`,
+		req.OutletID, req.Notes, req.CreatedBy).Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		log.Printf("Error starting stock take: %v", err)
+		return err
+	}
+	result, err := tx.Exec(`INSERT INTO stock_take_items(stock_take_id, product_id, system_qty, unit_value)
+		SELECT $1, p.id, CASE WHEN $2::int IS NULL THEN p.stock ELSE COALESCE(s.stock, 0) END, p.cost
+		FROM product p
+		LEFT JOIN product_stock s ON s.product_id = p.id AND s.outlet_id = $2
+		WHERE NOT p.is_bundle AND (cardinality($3::int[]) = 0 OR p.id = ANY($3))`, req.ID, req.OutletID, pq.Array(productIDs))
+	if err != nil {
+		log.Printf("Error snapshotting stock: %v", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errors.New("No products to count")
+	}
+	req.Status = models.StockTakeOpen
+	return tx.Commit()
+}
+
+func (r *StockTakeRepositoryImpl) FindStockTakeByID(id int) (*models.StockTake, error) {
+	var st models.StockTake
+	if err := r.db.QueryRow("SELECT "+stockTakeColumns+" FROM stock_takes WHERE id = $1", id).Scan(stockTakeFields(&st)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Stock take not found")
+		}
+		log.Printf("Error getting single stock take: %v", err)
+		return nil, err
+	}
+	if err := stockTakeLines(r.db, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// stockTakeLines fills in the lines and totals of a stock take. Stock moved
+// after the snapshot and before a product was counted is added to what the
+// system expects, so sales during the count do not show up as variance.
+func stockTakeLines(q queryer, st *models.StockTake) error {
+	rows, err := q.Query(`SELECT i.product_id, p.name, i.system_qty, i.unit_value, c.counted,
+		COALESCE((SELECT SUM(m.delta) FROM stock_movements m
+			WHERE m.product_id = i.product_id AND ($2::int IS NULL OR m.outlet_id = $2)
+			AND m.id > st.snapshot_movement_id
+			AND NOT (m.reference_type = 'stock_take' AND m.reference_id = st.id)
+			AND m.created_at <= COALESCE(c.last_counted, st.approved_at, CURRENT_TIMESTAMP)), 0)
+		FROM stock_take_items i
+		INNER JOIN stock_takes st ON i.stock_take_id = st.id
+		INNER JOIN product p ON i.product_id = p.id
+		LEFT JOIN (SELECT product_id, SUM(quantity) AS counted, MAX(counted_at) AS last_counted
+			FROM stock_take_counts WHERE stock_take_id = $1 GROUP BY product_id) c ON c.product_id = i.product_id
+		WHERE i.stock_take_id = $1 ORDER BY i.product_id`, st.ID, st.OutletID)
+	if err != nil {
+		log.Printf("Error getting stock take lines: %v", err)
+		return err
+	}
+	defer rows.Close()
+	st.Lines = make([]models.StockTakeLine, 0)
+	byProduct := make(map[int]int)
+	for rows.Next() {
+		var l models.StockTakeLine
+		if err := rows.Scan(&l.ProductID, &l.ProductName, &l.SystemQty, &l.UnitValue, &l.CountedQty, &l.MovedQty); err != nil {
+			return err
+		}
+		l.ExpectedQty = l.SystemQty + l.MovedQty
+		if l.CountedQty != nil {
+			l.VarianceQty = *l.CountedQty - l.ExpectedQty
+			l.VarianceValue = l.VarianceQty.Amount(l.UnitValue)
+			st.CountedLines++
+			st.TotalVarianceQty += l.VarianceQty
+			st.TotalVarianceValue += l.VarianceValue
+		} else {
+			st.UncountedLines++
+		}
+		byProduct[l.ProductID] = len(st.Lines)
+		st.Lines = append(st.Lines, l)
+	}
+
+	counts, err := q.Query("SELECT product_id, counter, batch_number, unit, quantity, counted_at FROM stock_take_counts WHERE stock_take_id = $1 ORDER BY counted_at", st.ID)
+	if err != nil {
+		log.Printf("Error getting stock counts: %v", err)
+		return err
+	}
+	defer counts.Close()
+	for counts.Next() {
+		var productID int
+		var c models.StockCount
+		if err := counts.Scan(&productID, &c.Counter, &c.BatchNumber, &c.Unit, &c.Quantity, &c.CountedAt); err != nil {
+			return err
+		}
+		l := &st.Lines[byProduct[productID]]
+		l.Counts = append(l.Counts, c)
+	}
+	return nil
+}
+
+// lockStockTake locks an open stock take until the transaction ends.
+func lockStockTake(tx *sql.Tx, id int) error {
+	var status string
+	err := tx.QueryRow("SELECT status FROM stock_takes WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return errors.New("Stock take not found")
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.StockTakeOpen {
+		return errors.New("Stock take is not open")
+	}
+	return nil
+}
+
+func (r *StockTakeRepositoryImpl) RecordCounts(id int, req *models.StockCountRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockStockTake(tx, id); err != nil {
+		return err
+	}
+	for _, item := range req.Items {
+		var included bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM stock_take_items WHERE stock_take_id = $1 AND product_id = $2)", id, item.ProductID).Scan(&included); err != nil {
+			return err
+		}
+		if !included {
+			return fmt.Errorf("Product %d is not part of stock take %d", item.ProductID, id)
+		}
+		factor, _, err := productUnit(tx, item.ProductID, item.Unit)
+		if err != nil {
+			return err
+		}
+		unit := item.Unit
+		if factor == models.Qty(1) {
+			unit = ""
+		}
+		_, err = tx.Exec(`INSERT INTO stock_take_counts(stock_take_id, product_id, counter, batch_number, unit, expiry_date, quantity) VALUES($1, $2, $3, $4, $5, NULLIF($6, '')::date, $7)
+			ON CONFLICT (stock_take_id, product_id, counter, batch_number, unit) DO UPDATE SET quantity = EXCLUDED.quantity, expiry_date = EXCLUDED.expiry_date, counted_at = CURRENT_TIMESTAMP`,
+			id, item.ProductID, req.Counter, item.BatchNumber, unit, item.ExpiryDate, item.Quantity.Mul(factor))
+		if err != nil {
+			log.Printf("Error recording stock count: %v", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ApproveStockTake posts the variance of every counted product as an
+// adjustment, which brings the stock in line with the count. Products
+// counted per batch have their lots set to what was counted; what was
+// counted without a batch is left outside any lot.
+func (r *StockTakeRepositoryImpl) ApproveStockTake(id int, approvedBy string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockStockTake(tx, id); err != nil {
+		return err
+	}
+	st := models.StockTake{ID: id}
+	if err := tx.QueryRow("SELECT outlet_id FROM stock_takes WHERE id = $1", id).Scan(&st.OutletID); err != nil {
+		return err
+	}
+	if err := stockTakeLines(tx, &st); err != nil {
+		return err
+	}
+	if st.CountedLines == 0 {
+		return errors.New("Nothing has been counted yet")
+	}
+	for _, l := range st.Lines {
+		if l.CountedQty == nil || l.VarianceQty == 0 {
+			continue
+		}
+		if _, err := adjustCost(tx, l.ProductID, l.VarianceQty); err != nil {
+			return err
+		}
+		_, err := adjustStock(tx, &models.StockMovement{
+			ProductID:     l.ProductID,
+			OutletID:      st.OutletID,
+			Delta:         l.VarianceQty,
+			Reason:        models.StockAdjustment,
+			ReferenceType: models.RefStockTake,
+			ReferenceID:   &st.ID,
+			CreatedBy:     approvedBy,
+		})
+		if err != nil {
+			return fmt.Errorf("Adjusting %s: %v", l.ProductName, err)
+		}
+	}
+	if err := countLots(tx, &st); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE stock_takes SET status = $1, approved_by = $2, approved_at = CURRENT_TIMESTAMP WHERE id = $3", models.StockTakeApproved, approvedBy, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *StockTakeRepositoryImpl) CancelStockTake(id int) error {
+	result, err := r.db.Exec("UPDATE stock_takes SET status = $1 WHERE id = $2 AND status = $3", models.StockTakeCancelled, id, models.StockTakeOpen)
+	if err != nil {
+		log.Printf("Error cancelling stock take: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Only open stock takes can be cancelled")
+	}
+	return nil
+}
+
+// countLots sets the lots of every product counted per batch to the
+// quantities counted. Lots of those products that were not counted are
+// emptied, batches that were not in stock become new lots.
+func countLots(tx *sql.Tx, st *models.StockTake) error {
+	type lotCount struct {
+		productID int
+		batch     string
+		expiry    string
+		quantity  models.Quantity
+	}
+	rows, err := tx.Query(`SELECT product_id, batch_number, COALESCE(to_char(MAX(expiry_date), 'YYYY-MM-DD'), ''), SUM(quantity)
+		FROM stock_take_counts WHERE stock_take_id = $1 AND batch_number <> ''
+		GROUP BY product_id, batch_number ORDER BY product_id, batch_number`, st.ID)
+	if err != nil {
+		return err
+	}
+	var counts []lotCount
+	for rows.Next() {
+		var c lotCount
+		if err := rows.Scan(&c.productID, &c.batch, &c.expiry, &c.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		counts = append(counts, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	emptied := make(map[int]bool)
+	for _, c := range counts {
+		if !emptied[c.productID] {
+			if _, err := tx.Exec("UPDATE stock_lots SET quantity = 0 WHERE product_id = $1 AND outlet_id IS NOT DISTINCT FROM $2", c.productID, st.OutletID); err != nil {
+				return err
+			}
+			emptied[c.productID] = true
+		}
+		if _, _, err := addToLot(tx, c.productID, st.OutletID, c.batch, c.expiry, c.quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
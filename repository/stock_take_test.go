@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"gokasir-api/models"
+	"testing"
+)
+
+func TestStockTake(t *testing.T) {
+	db := testDB(t)
+	repo := NewStockTakeRepository(db)
+	outlet := newOutlet(t, db)
+	counted := newProduct(t, db, models.Product{Cost: 2000})
+	uncounted := newProduct(t, db, models.Product{})
+	left := newProduct(t, db, models.Product{})
+	stockUp(t, db, counted, outlet, models.Qty(10))
+	stockUp(t, db, uncounted, outlet, models.Qty(5))
+
+	st := models.StockTake{OutletID: &outlet, CreatedBy: "test"}
+	if err := repo.StartStockTake(&st, []int{counted, uncounted}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.StartStockTake(&models.StockTake{OutletID: &outlet, CreatedBy: "test"}, []int{counted}); err == nil {
+		t.Error("started a second stock take at the outlet")
+	}
+
+	// Two sold while counting are expected to be gone, not a variance.
+	stockUp(t, db, counted, outlet, models.Qty(8))
+	counts := []struct {
+		counter  string
+		quantity models.Quantity
+	}{{"ani", models.Qty(6)}, {"budi", models.Qty(3)}, {"budi", models.Qty(1)}}
+	for _, c := range counts {
+		err := repo.RecordCounts(st.ID, &models.StockCountRequest{
+			Counter: c.counter,
+			Items:   []models.StockCountItem{{ProductID: counted, Quantity: c.quantity}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := repo.RecordCounts(st.ID, &models.StockCountRequest{
+		Counter: "ani",
+		Items:   []models.StockCountItem{{ProductID: left, Quantity: models.Qty(1)}},
+	})
+	if err == nil {
+		t.Error("counted a product outside the stock take")
+	}
+
+	got, err := repo.FindStockTakeByID(st.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CountedLines != 1 || got.UncountedLines != 1 {
+		t.Errorf("%d lines counted and %d not, want 1 and 1", got.CountedLines, got.UncountedLines)
+	}
+	for _, l := range got.Lines {
+		if l.ProductID != counted {
+			continue
+		}
+		if l.SystemQty != models.Qty(10) || l.MovedQty != -models.Qty(2) || l.ExpectedQty != models.Qty(8) {
+			t.Errorf("system %s moved %s expected %s, want 10, -2 and 8", l.SystemQty, l.MovedQty, l.ExpectedQty)
+		}
+		if l.CountedQty == nil || *l.CountedQty != models.Qty(7) {
+			t.Errorf("counted %v, want 7 from both counters", l.CountedQty)
+		}
+	}
+	if got.TotalVarianceQty != -models.Qty(1) || got.TotalVarianceValue != -2000 {
+		t.Errorf("variance %s worth %d, want -1 worth -2000", got.TotalVarianceQty, got.TotalVarianceValue)
+	}
+
+	if err := repo.ApproveStockTake(st.ID, "manager"); err != nil {
+		t.Fatal(err)
+	}
+	if stock := stockOf(t, db, counted, outlet); stock != models.Qty(7) {
+		t.Errorf("counted stock %s after approval, want 7", stock)
+	}
+	if stock := stockOf(t, db, uncounted, outlet); stock != models.Qty(5) {
+		t.Errorf("uncounted stock %s after approval, want 5", stock)
+	}
+	history, err := NewProductRepository(db).FindStockHistory(counted, &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := history[0]; m.ReferenceType != models.RefStockTake || m.Delta != -models.Qty(1) || m.CreatedBy != "manager" {
+		t.Errorf("approval booked %s on %s by %q", m.Delta, m.ReferenceType, m.CreatedBy)
+	}
+	if err := repo.ApproveStockTake(st.ID, "manager"); err == nil {
+		t.Error("approved a stock take twice")
+	}
+	if err := repo.CancelStockTake(st.ID); err == nil {
+		t.Error("cancelled an approved stock take")
+	}
+}
+
+func TestApproveUncountedStockTake(t *testing.T) {
+	db := testDB(t)
+	repo := NewStockTakeRepository(db)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{})
+
+	st := models.StockTake{OutletID: &outlet, CreatedBy: "test"}
+	if err := repo.StartStockTake(&st, []int{product}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ApproveStockTake(st.ID, "manager"); err == nil {
+		t.Error("approved a stock take nothing was counted in")
+	}
+	if err := repo.CancelStockTake(st.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.StartStockTake(&models.StockTake{OutletID: &outlet, CreatedBy: "test"}, []int{product}); err != nil {
+		t.Errorf("cannot start a stock take after cancelling the last one: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"gokasir-api/models"
+	"testing"
+)
+
+func TestStockLedger(t *testing.T) {
+	db := testDB(t)
+	outlet := newOutlet(t, db)
+	other := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{})
+
+	stockUp(t, db, product, outlet, models.Qty(10))
+	stockUp(t, db, product, other, models.Qty(4))
+	stockUp(t, db, product, outlet, models.Qty(7))
+
+	history, err := NewProductRepository(db).FindStockHistory(product, &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ delta, balance models.Quantity }{
+		{-models.Qty(3), models.Qty(7)},
+		{models.Qty(10), models.Qty(10)},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("got %d movements at the outlet, want %d", len(history), len(want))
+	}
+	for i, m := range history {
+		if m.Delta != want[i].delta || m.Balance != want[i].balance {
+			t.Errorf("movement %d: delta %s balance %s, want %s and %s", i, m.Delta, m.Balance, want[i].delta, want[i].balance)
+		}
+		if m.Reason != models.StockAdjustment || m.ReferenceType != models.RefOutlet || m.CreatedBy != "test" {
+			t.Errorf("movement %d: %s of %s by %q", i, m.Reason, m.ReferenceType, m.CreatedBy)
+		}
+	}
+
+	all, err := NewProductRepository(db).FindStockHistory(product, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("got %d movements over all outlets, want 3", len(all))
+	}
+	p, err := NewProductRepository(db).FindProductByID(product)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Stock != models.Qty(11) {
+		t.Errorf("product stock %s, want the 11 held over both outlets", p.Stock)
+	}
+}
+
+func TestAdjustStockRefuses(t *testing.T) {
+	db := testDB(t)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{})
+	stockUp(t, db, product, outlet, models.Qty(2))
+
+	tests := []struct {
+		name string
+		m    models.StockMovement
+	}{
+		{"no outlet", models.StockMovement{ProductID: product, Delta: models.Qty(1), Reason: models.StockAdjustment}},
+		{"below zero", models.StockMovement{ProductID: product, OutletID: &outlet, Delta: -models.Qty(3), Reason: models.StockAdjustment}},
+		{"unknown product", models.StockMovement{ProductID: -1, OutletID: &outlet, Delta: models.Qty(1), Reason: models.StockAdjustment}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+			if _, err := adjustStock(tx, &tt.m); err == nil {
+				t.Error("adjustStock accepted the movement")
+			}
+		})
+	}
+	if stock := stockOf(t, db, product, outlet); stock != models.Qty(2) {
+		t.Errorf("stock %s after refused movements, want 2", stock)
+	}
+}
@@ -0,0 +1,13 @@
+package repository
+
+import "gokasir-api/models"
+
+type StockTransferRepository interface {
+	FindAllStockTransfer(status string, outletID *int) ([]models.Transfer, error)
+	CreateStockTransfer(req *models.Transfer) error
+	FindStockTransferByID(id int) (*models.Transfer, error)
+	DispatchStockTransfer(id int, dispatchedBy string) error
+	ReceiveStockTransfer(id int, req *models.ReceiveTransferRequest) error
+	CancelStockTransfer(id int) error
+	FindInTransit(outletID *int) ([]models.InTransitStock, error)
+}
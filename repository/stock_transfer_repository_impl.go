@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"log"
+	"slices"
+
+	"github.com/lib/pq"
+)
+
+type StockTransferRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewStockTransferRepository(db *sql.DB) StockTransferRepository {
+	return &StockTransferRepositoryImpl{db: db}
+}
+
+const stockTransferColumns = `t.id, t.from_outlet_id, f.name, t.to_outlet_id, o.name, t.status, t.notes, t.created_by, t.created_at,
+	t.dispatched_by, t.dispatched_at, t.received_by, t.received_at
+	FROM stock_transfers t
+	INNER JOIN outlets f ON f.id = t.from_outlet_id
+	INNER JOIN outlets o ON o.id = t.to_outlet_id`
+
+func stockTransferFields(t *models.Transfer) []any {
+	return []any{&t.ID, &t.FromOutletID, &t.FromOutletName, &t.ToOutletID, &t.ToOutletName, &t.Status, &t.Notes, &t.CreatedBy, &t.CreatedAt,
+		&t.DispatchedBy, &t.DispatchedAt, &t.ReceivedBy, &t.ReceivedAt}
+}
+
+// FindAllStockTransfer lists transfers, optionally only those with a status
+// or going out of or into an outlet.
+func (r *StockTransferRepositoryImpl) FindAllStockTransfer(status string, outletID *int) ([]models.Transfer, error) {
+	rows, err := r.db.Query("SELECT "+stockTransferColumns+`
+		WHERE ($1 = '' OR t.status = $1) AND ($2::int IS NULL OR t.from_outlet_id = $2 OR t.to_outlet_id = $2)
+		ORDER BY t.id DESC`, status, outletID)
+	if err != nil {
+		log.Printf("Error getting all stock transfer: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	transfers := make([]models.Transfer, 0)
+	for rows.Next() {
+		var t models.Transfer
+		if err := rows.Scan(stockTransferFields(&t)...); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+func (r *StockTransferRepositoryImpl) CreateStockTransfer(req *models.Transfer) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`INSERT INTO stock_transfers(from_outlet_id, to_outlet_id, status, notes, created_by) VALUES($1, $2, $3, $4, $5) RETURNING id`,
+		req.FromOutletID, req.ToOutletID, models.TransferDraft, req.Notes, req.CreatedBy).Scan(&req.ID)
+	if err != nil {
+		log.Printf("Error creating stock transfer: %v", err)
+		return err
+	}
+	for _, item := range req.Items {
+		var name string
+		var bundle, serialized bool
+		var precision int
+		err := tx.QueryRow("SELECT name, is_bundle, serialized, precision FROM product WHERE id = $1", item.ProductID).Scan(&name, &bundle, &serialized, &precision)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("Product %d not found", item.ProductID)
+			}
+			return err
+		}
+		if bundle {
+			return fmt.Errorf("%s is a bundle, transfer its components instead", name)
+		}
+		if item.Quantity.Decimals() > precision {
+			return fmt.Errorf("%s cannot be transferred in steps of %s", name, item.Quantity)
+		}
+		if serialized && item.Quantity != models.Qty(len(item.Serials)) {
+			return fmt.Errorf("Name the %s serial numbers of %s being transferred", item.Quantity, name)
+		}
+		if !serialized && len(item.Serials) > 0 {
+			return fmt.Errorf("%s has no serial numbers", name)
+		}
+		serials := item.Serials
+		if serials == nil {
+			serials = []string{}
+		}
+		_, err = tx.Exec("INSERT INTO stock_transfer_items(transfer_id, product_id, quantity, serials) VALUES($1, $2, $3, $4)",
+			req.ID, item.ProductID, item.Quantity, pq.Array(serials))
+		if err != nil {
+			log.Printf("Error creating stock transfer item: %v", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *StockTransferRepositoryImpl) FindStockTransferByID(id int) (*models.Transfer, error) {
+	var t models.Transfer
+	if err := r.db.QueryRow("SELECT "+stockTransferColumns+" WHERE t.id = $1", id).Scan(stockTransferFields(&t)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Stock transfer not found")
+		}
+		log.Printf("Error getting single stock transfer: %v", err)
+		return nil, err
+	}
+	items, err := stockTransferItems(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	t.Items = items
+	return &t, nil
+}
+
+// stockTransferItems loads the lines of a transfer with the lots they were
+// sent from.
+func stockTransferItems(q queryer, transferID int) ([]models.TransferItem, error) {
+	rows, err := q.Query(`SELECT i.id, i.product_id, p.name, i.quantity, i.received_qty, i.discrepancy_value, i.discrepancy_reason, i.serials, i.received_serials
+		FROM stock_transfer_items i INNER JOIN product p ON p.id = i.product_id
+		WHERE i.transfer_id = $1 ORDER BY i.id`, transferID)
+	if err != nil {
+		log.Printf("Error getting stock transfer items: %v", err)
+		return nil, err
+	}
+	items := make([]models.TransferItem, 0)
+	byID := make(map[int]int)
+	for rows.Next() {
+		var item models.TransferItem
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.Quantity, &item.ReceivedQty, &item.DiscrepancyValue, &item.DiscrepancyReason,
+			pq.Array(&item.Serials), pq.Array(&item.ReceivedSerials)); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if item.ReceivedQty != nil {
+			item.DiscrepancyQty = *item.ReceivedQty - item.Quantity
+		}
+		byID[item.ID] = len(items)
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lots, err := q.Query(`SELECT tl.item_id, tl.lot_id, l.product_id, l.batch_number, COALESCE(to_char(l.expiry_date, 'YYYY-MM-DD'), ''), tl.quantity
+		FROM stock_transfer_lots tl
+		INNER JOIN stock_transfer_items i ON i.id = tl.item_id
+		INNER JOIN stock_lots l ON l.id = tl.lot_id
+		WHERE i.transfer_id = $1 ORDER BY tl.item_id, l.expiry_date NULLS LAST, tl.lot_id`, transferID)
+	if err != nil {
+		log.Printf("Error getting stock transfer lots: %v", err)
+		return nil, err
+	}
+	defer lots.Close()
+	for lots.Next() {
+		var itemID int
+		var l models.DetailLot
+		if err := lots.Scan(&itemID, &l.LotID, &l.ProductID, &l.BatchNumber, &l.ExpiryDate, &l.Quantity); err != nil {
+			return nil, err
+		}
+		item := &items[byID[itemID]]
+		item.Lots = append(item.Lots, l)
+	}
+	return items, lots.Err()
+}
+
+// lockStockTransfer returns the status of a transfer and locks it until the
+// transaction ends.
+func lockStockTransfer(tx *sql.Tx, id int) (string, error) {
+	var status string
+	err := tx.QueryRow("SELECT status FROM stock_transfers WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", errors.New("Stock transfer not found")
+	}
+	return status, err
+}
+
+// DispatchStockTransfer takes the lines out of the source outlet's stock,
+// first expiring lots first, and puts them in transit.
+func (r *StockTransferRepositoryImpl) DispatchStockTransfer(id int, dispatchedBy string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockStockTransfer(tx, id)
+	if err != nil {
+		return err
+	}
+	if status != models.TransferDraft {
+		return errors.New("Only draft stock transfers can be dispatched")
+	}
+	var fromOutletID int
+	if err := tx.QueryRow("SELECT from_outlet_id FROM stock_transfers WHERE id = $1", id).Scan(&fromOutletID); err != nil {
+		return err
+	}
+	items, err := stockTransferItems(tx, id)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		lots, err := takeFromLots(tx, item.ProductID, &fromOutletID, item.Quantity)
+		if err != nil {
+			return err
+		}
+		for _, l := range lots {
+			if _, err := tx.Exec("INSERT INTO stock_transfer_lots (item_id, lot_id, quantity) VALUES ($1, $2, $3)", item.ID, l.LotID, l.Quantity); err != nil {
+				return err
+			}
+		}
+		if len(item.Serials) > 0 {
+			result, err := tx.Exec("UPDATE serial_numbers SET status = $1 WHERE serial = ANY($2) AND product_id = $3 AND outlet_id = $4 AND status = $5",
+				models.SerialInTransit, pq.Array(item.Serials), item.ProductID, fromOutletID, models.SerialInStock)
+			if err != nil {
+				return err
+			}
+			if n, _ := result.RowsAffected(); n != int64(len(item.Serials)) {
+				return fmt.Errorf("Not every serial of %s is in stock at the source outlet", item.ProductName)
+			}
+		}
+		_, err = adjustStock(tx, &models.StockMovement{
+			ProductID:     item.ProductID,
+			OutletID:      &fromOutletID,
+			Delta:         -item.Quantity,
+			Reason:        models.StockTransfer,
+			ReferenceType: models.RefTransfer,
+			ReferenceID:   &id,
+			CreatedBy:     dispatchedBy,
+		})
+		if err != nil {
+			return fmt.Errorf("Dispatching %s: %v", item.ProductName, err)
+		}
+	}
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, dispatched_by = $2, dispatched_at = CURRENT_TIMESTAMP WHERE id = $3", models.TransferInTransit, dispatchedBy, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReceiveStockTransfer lands what arrived at the destination outlet, into
+// lots of the same batches it was sent from. A shortfall is recorded on
+// its line and valued at cost; it stays out of stock and its serials are
+// marked missing.
+func (r *StockTransferRepositoryImpl) ReceiveStockTransfer(id int, req *models.ReceiveTransferRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockStockTransfer(tx, id)
+	if err != nil {
+		return err
+	}
+	if status != models.TransferInTransit {
+		return errors.New("Only stock transfers in transit can be received")
+	}
+	var toOutletID int
+	if err := tx.QueryRow("SELECT to_outlet_id FROM stock_transfers WHERE id = $1", id).Scan(&toOutletID); err != nil {
+		return err
+	}
+	items, err := stockTransferItems(tx, id)
+	if err != nil {
+		return err
+	}
+	arrived := make(map[int]models.ReceiveTransferItem)
+	for _, item := range req.Items {
+		if !slices.ContainsFunc(items, func(i models.TransferItem) bool { return i.ID == item.ItemID }) {
+			return fmt.Errorf("Item %d does not belong to stock transfer %d", item.ItemID, id)
+		}
+		arrived[item.ItemID] = item
+	}
+
+	for _, item := range items {
+		received := models.ReceiveTransferItem{ItemID: item.ID, Quantity: item.Quantity, Serials: item.Serials}
+		if a, ok := arrived[item.ID]; ok {
+			received = a
+			if len(item.Serials) > 0 && a.Serials == nil && a.Quantity == item.Quantity {
+				received.Serials = item.Serials
+			}
+		}
+		var precision int
+		if err := tx.QueryRow("SELECT precision FROM product WHERE id = $1", item.ProductID).Scan(&precision); err != nil {
+			return err
+		}
+		if received.Quantity.Decimals() > precision {
+			return fmt.Errorf("%s cannot be received in steps of %s", item.ProductName, received.Quantity)
+		}
+		diff := received.Quantity - item.Quantity
+		if diff != 0 && received.Reason == "" {
+			return fmt.Errorf("Give a reason why %s of %s arrived instead of %s", received.Quantity, item.ProductName, item.Quantity)
+		}
+		if len(item.Serials) > 0 {
+			if models.Qty(len(received.Serials)) != received.Quantity {
+				return fmt.Errorf("Name the %s serial numbers of %s that arrived", received.Quantity, item.ProductName)
+			}
+			for _, s := range received.Serials {
+				if !slices.Contains(item.Serials, s) {
+					return fmt.Errorf("Serial %s was not sent on this transfer", s)
+				}
+			}
+			_, err := tx.Exec("UPDATE serial_numbers SET status = $1, outlet_id = $2 WHERE serial = ANY($3) AND status = $4",
+				models.SerialInStock, toOutletID, pq.Array(received.Serials), models.SerialInTransit)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec("UPDATE serial_numbers SET status = $1 WHERE serial = ANY($2) AND status = $3",
+				models.SerialMissing, pq.Array(item.Serials), models.SerialInTransit)
+			if err != nil {
+				return err
+			}
+		} else if len(received.Serials) > 0 {
+			return fmt.Errorf("%s has no serial numbers", item.ProductName)
+		}
+
+		// What arrived fills the batches it was sent from, first expiring
+		// first; anything beyond them lands outside any lot.
+		left := received.Quantity
+		for _, l := range item.Lots {
+			if left == 0 {
+				break
+			}
+			give := min(l.Quantity, left)
+			if _, _, err := addToLot(tx, item.ProductID, &toOutletID, l.BatchNumber, l.ExpiryDate, give); err != nil {
+				return err
+			}
+			left -= give
+		}
+		// Goods lost on the way use up cost, goods beyond what was sent come
+		// in at the current cost.
+		value, err := adjustCost(tx, item.ProductID, diff)
+		if err != nil {
+			return err
+		}
+		if received.Quantity > 0 {
+			_, err = adjustStock(tx, &models.StockMovement{
+				ProductID:     item.ProductID,
+				OutletID:      &toOutletID,
+				Delta:         received.Quantity,
+				Reason:        models.StockTransfer,
+				ReferenceType: models.RefTransfer,
+				ReferenceID:   &id,
+				CreatedBy:     req.ReceivedBy,
+			})
+			if err != nil {
+				return fmt.Errorf("Receiving %s: %v", item.ProductName, err)
+			}
+		}
+
+		serials := received.Serials
+		if serials == nil {
+			serials = []string{}
+		}
+		_, err = tx.Exec("UPDATE stock_transfer_items SET received_qty = $1, discrepancy_value = $2, discrepancy_reason = $3, received_serials = $4 WHERE id = $5",
+			received.Quantity, value, received.Reason, pq.Array(serials), item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, received_by = $2, received_at = CURRENT_TIMESTAMP WHERE id = $3", models.TransferReceived, req.ReceivedBy, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *StockTransferRepositoryImpl) CancelStockTransfer(id int) error {
+	result, err := r.db.Exec("UPDATE stock_transfers SET status = $1 WHERE id = $2 AND status = $3", models.TransferCancelled, id, models.TransferDraft)
+	if err != nil {
+		log.Printf("Error cancelling stock transfer: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Only draft stock transfers can be cancelled")
+	}
+	return nil
+}
+
+// FindInTransit adds up per product what has been dispatched and not yet
+// received, optionally only transfers going out of or into an outlet.
+func (r *StockTransferRepositoryImpl) FindInTransit(outletID *int) ([]models.InTransitStock, error) {
+	rows, err := r.db.Query(`SELECT i.product_id, p.name, SUM(i.quantity), COUNT(DISTINCT t.id)
+		FROM stock_transfer_items i
+		INNER JOIN stock_transfers t ON t.id = i.transfer_id
+		INNER JOIN product p ON p.id = i.product_id
+		WHERE t.status = $1 AND ($2::int IS NULL OR t.from_outlet_id = $2 OR t.to_outlet_id = $2)
+		GROUP BY i.product_id, p.name ORDER BY i.product_id`, models.TransferInTransit, outletID)
+	if err != nil {
+		log.Printf("Error getting stock in transit: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	stock := make([]models.InTransitStock, 0)
+	for rows.Next() {
+		var s models.InTransitStock
+		if err := rows.Scan(&s.ProductID, &s.ProductName, &s.Quantity, &s.Transfers); err != nil {
+			return nil, err
+		}
+		stock = append(stock, s)
+	}
+	return stock, rows.Err()
+}
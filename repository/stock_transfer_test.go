@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"gokasir-api/models"
+	"testing"
+)
+
+func TestStockTransfer(t *testing.T) {
+	db := testDB(t)
+	repo := NewStockTransferRepository(db)
+	from, to := newOutlet(t, db), newOutlet(t, db)
+
+	plain := newProduct(t, db, models.Product{Cost: 2000})
+	stockUp(t, db, plain, from, models.Qty(10))
+	serialized := newProduct(t, db, models.Product{Serialized: true})
+	stockUp(t, db, serialized, from, models.Qty(2))
+	sent, lost := unique("SN-"), unique("SN-")
+	if err := NewSerialRepository(db).RegisterSerials(&models.RegisterSerialsRequest{ProductID: serialized, OutletID: &from, Serials: []string{sent, lost}}); err != nil {
+		t.Fatal(err)
+	}
+	lotted := newProduct(t, db, models.Product{})
+	expiry := inDays(30)
+	po := newPurchaseOrder(t, db, from, models.PurchaseOrderItem{ProductID: lotted, Quantity: models.Qty(4), UnitCost: 1000})
+	_, err := NewPurchaseOrderRepository(db).ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+		ReceivedBy: "test",
+		Items:      []models.ReceiveItem{{ItemID: po.Items[0].ID, Quantity: models.Qty(4), BatchNumber: "L1", ExpiryDate: expiry}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer := models.Transfer{FromOutletID: from, ToOutletID: to, CreatedBy: "test", Items: []models.TransferItem{
+		{ProductID: plain, Quantity: models.Qty(6)},
+		{ProductID: serialized, Quantity: models.Qty(2), Serials: []string{sent, lost}},
+		{ProductID: lotted, Quantity: models.Qty(3)},
+	}}
+	if err := repo.CreateStockTransfer(&transfer); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DispatchStockTransfer(transfer.ID, "sender"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DispatchStockTransfer(transfer.ID, "sender"); err == nil {
+		t.Error("dispatched a transfer twice")
+	}
+	for product, want := range map[int]models.Quantity{plain: models.Qty(4), serialized: 0, lotted: models.Qty(1)} {
+		if stock := stockOf(t, db, product, from); stock != want {
+			t.Errorf("product %d has %s left at the source, want %s", product, stock, want)
+		}
+	}
+	inTransit, err := repo.FindInTransit(&to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inTransit) != 3 {
+		t.Errorf("%d products in transit, want 3", len(inTransit))
+	}
+	if unit, err := NewSerialRepository(db).FindSerial(sent); err != nil || unit.Status != models.SerialInTransit {
+		t.Errorf("serial %s after dispatch: %+v, %v", sent, unit, err)
+	}
+
+	got, err := repo.FindStockTransferByID(transfer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := got.Items
+	if len(items[2].Lots) != 1 || items[2].Lots[0].BatchNumber != "L1" || items[2].Lots[0].Quantity != models.Qty(3) {
+		t.Errorf("lotted line sent from %+v", items[2].Lots)
+	}
+	receive := func(items ...models.ReceiveTransferItem) error {
+		return repo.ReceiveStockTransfer(transfer.ID, &models.ReceiveTransferRequest{ReceivedBy: "receiver", Items: items})
+	}
+	if err := receive(models.ReceiveTransferItem{ItemID: items[0].ID, Quantity: models.Qty(5)}); err == nil {
+		t.Error("received a shortfall without a reason")
+	}
+	if err := receive(models.ReceiveTransferItem{ItemID: items[0].ID, Quantity: 5500, Reason: "cut"}); err == nil {
+		t.Error("received part of a piece")
+	}
+	err = receive(
+		models.ReceiveTransferItem{ItemID: items[0].ID, Quantity: models.Qty(5), Reason: "broken on the way"},
+		models.ReceiveTransferItem{ItemID: items[1].ID, Quantity: models.Qty(1), Reason: "lost", Serials: []string{sent}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for product, want := range map[int]models.Quantity{plain: models.Qty(5), serialized: models.Qty(1), lotted: models.Qty(3)} {
+		if stock := stockOf(t, db, product, to); stock != want {
+			t.Errorf("product %d arrived %s, want %s", product, stock, want)
+		}
+	}
+	got, err = repo.FindStockTransferByID(transfer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := got.Items[0]; l.DiscrepancyQty != -models.Qty(1) || l.DiscrepancyValue != -2000 || l.DiscrepancyReason != "broken on the way" {
+		t.Errorf("shortfall %s worth %d for %q", l.DiscrepancyQty, l.DiscrepancyValue, l.DiscrepancyReason)
+	}
+	if unit, err := NewSerialRepository(db).FindSerial(sent); err != nil || unit.Status != models.SerialInStock || unit.OutletID == nil || *unit.OutletID != to {
+		t.Errorf("serial %s after arriving: %+v, %v", sent, unit, err)
+	}
+	if unit, err := NewSerialRepository(db).FindSerial(lost); err != nil || unit.Status != models.SerialMissing {
+		t.Errorf("serial %s that never arrived: %+v, %v", lost, unit, err)
+	}
+	lots, err := NewProductRepository(db).FindLots(lotted, &to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lots.Lots) != 1 || lots.Lots[0].BatchNumber != "L1" || lots.Lots[0].ExpiryDate != expiry || lots.Lots[0].Quantity != models.Qty(3) {
+		t.Errorf("lots at the destination %+v", lots.Lots)
+	}
+	if err := repo.CancelStockTransfer(transfer.ID); err == nil {
+		t.Error("cancelled a received transfer")
+	}
+	if inTransit, err := repo.FindInTransit(&to); err != nil || len(inTransit) != 0 {
+		t.Errorf("still in transit after receiving: %+v, %v", inTransit, err)
+	}
+}
+
+func TestDispatchWithoutStock(t *testing.T) {
+	db := testDB(t)
+	repo := NewStockTransferRepository(db)
+	from, to := newOutlet(t, db), newOutlet(t, db)
+	product := newProduct(t, db, models.Product{})
+	stockUp(t, db, product, from, models.Qty(2))
+
+	transfer := models.Transfer{FromOutletID: from, ToOutletID: to, CreatedBy: "test", Items: []models.TransferItem{{ProductID: product, Quantity: models.Qty(3)}}}
+	if err := repo.CreateStockTransfer(&transfer); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DispatchStockTransfer(transfer.ID, "sender"); err == nil {
+		t.Error("dispatched more than the outlet holds")
+	}
+	if stock := stockOf(t, db, product, from); stock != models.Qty(2) {
+		t.Errorf("stock %s after a refused dispatch, want 2", stock)
+	}
+	if err := repo.CancelStockTransfer(transfer.ID); err != nil {
+		t.Fatal(err)
+	}
+}
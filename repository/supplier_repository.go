@@ -0,0 +1,11 @@
+package repository
+
+import "gokasir-api/models"
+
+type SupplierRepository interface {
+	FindAllSupplier(search string) ([]models.Supplier, error)
+	CreateSupplier(req *models.Supplier) error
+	FindSupplierByID(id int) (*models.Supplier, error)
+	UpdateSupplier(id int, req *models.Supplier) error
+	DeleteSupplier(id int) error
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"gokasir-api/models"
+	"log"
+)
+
+type SupplierRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) SupplierRepository {
+	return &SupplierRepositoryImpl{db: db}
+}
+
+// supplierColumns includes what is still owed over all purchase orders.
+const supplierColumns = `s.id, s.name, s.contact_name, s.phone, s.email, s.address,
+	COALESCE((SELECT SUM(po.received_amount - po.paid_amount) FROM purchase_orders po WHERE po.supplier_id = s.id AND po.status <> 'cancelled'), 0),
+	s.created_at`
+
+func supplierFields(s *models.Supplier) []any {
+	return []any{&s.ID, &s.Name, &s.ContactName, &s.Phone, &s.Email, &s.Address, &s.Outstanding, &s.CreatedAt}
+}
+
+func (r *SupplierRepositoryImpl) FindAllSupplier(search string) ([]models.Supplier, error) {
+	query := "SELECT " + supplierColumns + " FROM suppliers s"
+	var args []any
+	if search != "" {
+		query += " WHERE s.name ILIKE $1 OR s.contact_name ILIKE $1"
+		args = append(args, "%"+search+"%")
+	}
+	rows, err := r.db.Query(query+" ORDER BY s.id", args...)
+	if err != nil {
+		log.Printf("Error getting all supplier: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var suppliers []models.Supplier
+	for rows.Next() {
+		var s models.Supplier
+		if err := rows.Scan(supplierFields(&s)...); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, s)
+	}
+	return suppliers, nil
+}
+
+func (r *SupplierRepositoryImpl) CreateSupplier(req *models.Supplier) error {
+	err := r.db.QueryRow("INSERT INTO suppliers(name, contact_name, phone, email, address) VALUES($1, $2, $3, $4, $5) RETURNING id, created_at", req.Name, req.ContactName, req.Phone, req.Email, req.Address).Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating supplier: %v", err)
+	}
+	return err
+}
+
+func (r *SupplierRepositoryImpl) FindSupplierByID(id int) (*models.Supplier, error) {
+	var s models.Supplier
+	if err := r.db.QueryRow("SELECT "+supplierColumns+" FROM suppliers s WHERE s.id = $1", id).Scan(supplierFields(&s)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Supplier not found")
+		}
+		log.Printf("Error getting single supplier: %v", err)
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *SupplierRepositoryImpl) UpdateSupplier(id int, req *models.Supplier) error {
+	result, err := r.db.Exec("UPDATE suppliers SET name = $1, contact_name = $2, phone = $3, email = $4, address = $5 WHERE id = $6", req.Name, req.ContactName, req.Phone, req.Email, req.Address, id)
+	if err != nil {
+		log.Printf("Error update supplier: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Supplier not found")
+	}
+	return nil
+}
+
+func (r *SupplierRepositoryImpl) DeleteSupplier(id int) error {
+	var orders int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM purchase_orders WHERE supplier_id = $1", id).Scan(&orders); err != nil {
+		return err
+	}
+	if orders > 0 {
+		return errors.New("Supplier has purchase orders and cannot be deleted")
+	}
+	result, err := r.db.Exec("DELETE FROM suppliers WHERE id = $1", id)
+	if err != nil {
+		log.Printf("Error delete supplier: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Supplier not found")
+	}
+	return nil
+}
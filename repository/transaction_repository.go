@@ -3,8 +3,13 @@ package repository
 import "gokasir-api/models"
 
 type TransactionRepository interface {
-	CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error)
-	FindAllTransaction() ([]models.TransactionDetail, error)
-	TodaysTransaction() (*models.Report, error)
-	RangeTransaction(start, end string) (*models.Report, error)
+	CreateTransaction(req *models.CheckoutRequest) (*models.Transaction, error)
+	FindAllTransaction(outletID *int) ([]models.TransactionDetail, error)
+	FindTransactionByID(id int) (*models.Transaction, error)
+	RefundTransaction(id int, req *models.RefundRequest, void bool) (*models.Transaction, error)
+	TodaysTransaction(outletID *int) (*models.Report, error)
+	RangeTransaction(start, end string, outletID *int) (*models.Report, error)
+	PromotionReport(start, end string, outletID *int) ([]models.PromotionUsage, error)
+	TaxReport(start, end string, outletID *int) (*models.TaxReport, error)
+	ProductSalesReport(start, end, groupBy string, outletID *int) ([]models.ProductSales, error)
 }
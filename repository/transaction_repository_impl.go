@@ -2,20 +2,46 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/barcode"
 	"gokasir-api/models"
+	"gokasir-api/pricing"
 	"log"
+	"slices"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type TransactionRepositoryImpl struct {
-	db *sql.DB
+	db      *sql.DB
+	tax     pricing.TaxConfig
+	loyalty pricing.LoyaltyConfig
+	scales  []barcode.ScaleLayout
+	// requireShift refuses checkouts and refunds while no shift is open at
+	// the outlet instead of recording them without one.
+	requireShift bool
+}
+
+func NewTransactionRepository(db *sql.DB, tax pricing.TaxConfig, loyalty pricing.LoyaltyConfig, scales []barcode.ScaleLayout, requireShift bool) TransactionRepository {
+	return &TransactionRepositoryImpl{db: db, tax: tax, loyalty: loyalty, scales: scales, requireShift: requireShift}
 }
 
-func NewTransactionRepository(db *sql.DB) TransactionRepository {
-	return &TransactionRepositoryImpl{db: db}
+// shiftID returns the outlet's open shift, failing when one is required
+// and none is open.
+func (r *TransactionRepositoryImpl) shiftID(tx *sql.Tx, outletID *int) (*int, error) {
+	id, err := currentShiftID(tx, outletID)
+	if err != nil {
+		return nil, err
+	}
+	if id == nil && r.requireShift {
+		return nil, errors.New("No shift is open, open a shift first")
+	}
+	return id, nil
 }
 
-func (r *TransactionRepositoryImpl) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
+func (r *TransactionRepositoryImpl) CreateTransaction(req *models.CheckoutRequest) (*models.Transaction, error) {
 	// Crerate db transaction
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -23,63 +49,657 @@ func (r *TransactionRepositoryImpl) CreateTransaction(items []models.CheckoutIte
 	}
 	defer tx.Rollback()
 
-	totalAmount := 0
-	details := make([]models.TransactionDetail, 0)
-	for _, item := range items {
-		var productPrice, stock int
+	if req.OutletID, err = stockOutlet(tx, req.OutletID); err != nil {
+		return nil, err
+	}
+
+	// Scanned items name a barcode instead of the product. A code stored as
+	// a barcode or SKU wins; otherwise a scale label carries the PLU and the
+	// weight or price of what was weighed.
+	labelPrices := make([]int, len(req.Items))
+	weighed := make([]bool, len(req.Items))
+	for i, item := range req.Items {
+		if item.ProductID != 0 {
+			continue
+		}
+		req.Items[i].ProductID, err = productByCode(tx, item.Barcode)
+		if err == nil {
+			if item.Quantity == 0 {
+				req.Items[i].Quantity = models.Qty(1)
+			}
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+		if reading, ok := barcode.DecodeScale(r.scales, item.Barcode); ok {
+			var price int
+			err := tx.QueryRow(`SELECT p.id, COALESCE(op.price, p.price) FROM product p
+				LEFT JOIN product_outlet_prices op ON op.product_id = p.id AND op.outlet_id = $2
+				WHERE p.plu <> '' AND p.plu = LTRIM($1, '0')`, reading.PLU, req.OutletID).Scan(&req.Items[i].ProductID, &price)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					return nil, fmt.Errorf("PLU %s of barcode %s not found", reading.PLU, item.Barcode)
+				}
+				return nil, err
+			}
+			if reading.Kind == barcode.ScaleWeight {
+				req.Items[i].Quantity = models.Quantity(reading.Thousandths())
+			} else {
+				if price <= 0 {
+					return nil, fmt.Errorf("Barcode %s has a price but its product has none to weigh it by", item.Barcode)
+				}
+				req.Items[i].Quantity = models.Quantity((int64(reading.Value)*models.QuantityScale + int64(price)/2) / int64(price))
+				labelPrices[i] = reading.Value
+			}
+			if req.Items[i].Quantity <= 0 {
+				return nil, fmt.Errorf("Barcode %s has no weight or price", item.Barcode)
+			}
+			weighed[i] = true
+			req.Items[i].Unit = ""
+			continue
+		}
+		return nil, fmt.Errorf("Barcode %s not found", item.Barcode)
+	}
+
+	now := time.Now()
+	lines := make([]pricing.Line, len(req.Items))
+	names := make([]string, len(req.Items))
+	taxRates := make([]float64, len(req.Items))
+	inclusive := make([]bool, len(req.Items))
+	quantities := make([]models.Quantity, len(req.Items))
+	factors := make([]models.Quantity, len(req.Items))
+	basePrices := make([]int, len(req.Items))
+	priceRules := make([]string, len(req.Items))
+	priceListIDs := make([]*int, len(req.Items))
+	reserved := make(map[int]models.Quantity)
+	parts := make([][]stockPart, len(req.Items))
+	ingredients := make([][]stockPart, len(req.Items))
+	for i, item := range req.Items {
+		var productPrice, categoryID, precision int
+		var outletPrice *int
 		var productName string
-		err := tx.QueryRow("SELECT name, price, stock FROM product WHERE id = $1", item.ProductID).Scan(&productName, &productPrice, &stock)
+		var productRate, categoryRate *float64
+		var productInclusive, categoryInclusive *bool
+		var productExempt, categoryExempt, hasVariants, isBundle, isIngredient, serialized, retired bool
+		err := tx.QueryRow(`SELECT p.name, p.price, op.price, p.category_id, p.tax_rate, p.tax_exempt, c.tax_rate, c.tax_exempt, p.tax_inclusive, c.tax_inclusive,
+			EXISTS(SELECT 1 FROM product v WHERE v.parent_id = p.id AND NOT v.retired), p.is_bundle, p.is_ingredient, p.precision, p.serialized, p.retired
+			FROM product p INNER JOIN category c ON p.category_id = c.id
+			LEFT JOIN product_outlet_prices op ON op.product_id = p.id AND op.outlet_id = $2
+			WHERE p.id = $1`, item.ProductID, req.OutletID).Scan(&productName, &productPrice, &outletPrice, &categoryID, &productRate, &productExempt, &categoryRate, &categoryExempt, &productInclusive, &categoryInclusive, &hasVariants, &isBundle, &isIngredient, &precision, &serialized, &retired)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				log.Printf("Product %d not found", item.ProductID)
+				return nil, fmt.Errorf("Product %d not found", item.ProductID)
+			}
+			return nil, err
+		}
+		if hasVariants {
+			return nil, fmt.Errorf("Choose a variant of %s", productName)
+		}
+		if retired {
+			return nil, fmt.Errorf("%s is no longer sold", productName)
+		}
+		if isIngredient {
+			return nil, fmt.Errorf("%s is an ingredient and not for sale", productName)
+		}
+		basePrices[i], priceRules[i] = productPrice, models.PriceRuleStandard
+		if outletPrice != nil {
+			basePrices[i], priceRules[i] = *outletPrice, models.PriceRuleOutlet
+		}
+		// Stock is kept in the base unit; a pack or box sells at its own
+		// price, or at the price of the pieces in it.
+		factor, unitPrice, err := productUnit(tx, item.ProductID, item.Unit)
+		if err != nil {
+			return nil, err
+		}
+		quantity := item.Quantity.Mul(factor)
+		factors[i] = factor
+		switch {
+		case labelPrices[i] != 0:
+			priceRules[i] = models.PriceRuleLabel
+		case unitPrice != nil:
+			priceRules[i] = models.PriceRuleUnit
+		}
+		if unitPrice == nil {
+			price := factor.Amount(basePrices[i])
+			unitPrice = &price
+		}
+		// The scale decides the quantity of what it weighed.
+		if !weighed[i] && quantity.Decimals() > precision {
+			if precision == 0 {
+				return nil, fmt.Errorf("%s is only sold in whole units", productName)
+			}
+			return nil, fmt.Errorf("%s is sold in steps of at most %d decimals", productName, precision)
+		}
+		// Every unit of a serialised product is picked by its serial.
+		if serialized && quantity != models.Qty(len(item.Serials)) {
+			return nil, fmt.Errorf("Pick %s serial numbers for %s", quantity, productName)
+		}
+		if !serialized && len(item.Serials) > 0 {
+			return nil, fmt.Errorf("%s has no serial numbers", productName)
+		}
+		// A bundle sells the stock of its components, a menu item with a
+		// recipe uses up its ingredients.
+		var taken []stockPart
+		if isBundle {
+			if parts[i], err = bundleParts(tx, item.ProductID, req.OutletID); err != nil {
+				return nil, err
+			}
+			taken = parts[i]
+		} else {
+			if ingredients[i], err = recipeParts(tx, item.ProductID); err != nil {
+				return nil, err
+			}
+			taken = ingredients[i]
+		}
+		if taken != nil {
+			for _, part := range taken {
+				if part.serialized {
+					return nil, fmt.Errorf("%s has serial numbers and cannot be sold as part of %s", part.name, productName)
+				}
+				stock, err := stockAt(tx, part.productID, req.OutletID)
+				if err != nil {
+					return nil, err
+				}
+				need := part.quantity.Mul(quantity)
+				if stock < reserved[part.productID]+need {
+					return nil, fmt.Errorf("Insufficient stock of %s for %s", part.name, productName)
+				}
+				reserved[part.productID] += need
+			}
+		} else {
+			stock, err := stockAt(tx, item.ProductID, req.OutletID)
+			if err != nil {
 				return nil, err
 			}
+			if stock == 0 || stock < reserved[item.ProductID]+quantity {
+				log.Print("Stock quantity is less than required quantity")
+				return nil, fmt.Errorf("Insufficient stock for %s", productName)
+			}
+			reserved[item.ProductID] += quantity
+		}
+		quantities[i] = quantity
+		names[i] = productName
+		taxRates[i] = r.tax.ResolveRate(productRate, productExempt, categoryRate, categoryExempt)
+		inclusive[i] = r.tax.ResolveInclusive(productInclusive, categoryInclusive)
+		lines[i] = pricing.Line{
+			ProductID:  item.ProductID,
+			CategoryID: categoryID,
+			UnitPrice:  *unitPrice,
+			Quantity:   item.Quantity,
+			LabelPrice: labelPrices[i],
+		}
+	}
+
+	// Wholesale tiers count a product over the whole basket.
+	var group string
+	if req.CustomerID != nil {
+		err := tx.QueryRow("SELECT customer_group FROM customers WHERE id = $1", *req.CustomerID).Scan(&group)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("Customer %d not found", *req.CustomerID)
+			}
 			return nil, err
 		}
-		if stock == 0 || stock < item.Quantity {
-			log.Print("Stock quantity is less than required quantity")
+	}
+	basket := make(map[int]models.Quantity)
+	for i := range lines {
+		basket[lines[i].ProductID] += quantities[i]
+	}
+	for i := range lines {
+		if priceRules[i] == models.PriceRuleLabel || priceRules[i] == models.PriceRuleUnit {
+			continue
+		}
+		price, rule, listID, err := bestPrice(tx, lines[i].ProductID, basePrices[i], priceRules[i], basket[lines[i].ProductID], group)
+		if err != nil {
 			return nil, err
 		}
-		subTotal := productPrice * item.Quantity
-		totalAmount += subTotal
-		_, err = tx.Exec("UPDATE product SET stock = stock - $1 WHERE id = $2", item.Quantity, item.ProductID)
+		lines[i].UnitPrice = factors[i].Amount(price)
+		priceRules[i], priceListIDs[i] = rule, listID
+	}
+
+	// Promotion
+	promotions, err := activePromotions(tx, now)
+	if err != nil {
+		return nil, err
+	}
+	pricing.ApplyPromotions(lines, promotions, now)
+
+	// Tax and service charge
+	subtotal, taxBase, taxAmount := 0, 0, 0
+	bases := make([]int, len(lines))
+	taxes := make([]int, len(lines))
+	for i := range lines {
+		bases[i], taxes[i] = pricing.LineTax(lines[i].Net(), taxRates[i], inclusive[i])
+		subtotal += lines[i].Net()
+		taxBase += bases[i]
+		taxAmount += taxes[i]
+	}
+	serviceCharge := pricing.ServiceCharge(taxBase, r.tax.ServiceChargeRate)
+	serviceCharges := pricing.Split(serviceCharge, bases)
+	totalAmount := taxBase + taxAmount + serviceCharge
+
+	details := make([]models.TransactionDetail, 0, len(lines))
+	for i, l := range lines {
+		promotionIDs := make([]int, 0, len(l.Applied))
+		for _, a := range l.Applied {
+			promotionIDs = append(promotionIDs, a.PromotionID)
+		}
+		d := models.TransactionDetail{
+			ProductID:     l.ProductID,
+			ProductName:   names[i],
+			Quantity:      quantities[i],
+			UnitPrice:     l.UnitPrice,
+			PriceRule:     priceRules[i],
+			PriceListID:   priceListIDs[i],
+			GrossAmount:   l.Gross(),
+			Discount:      l.Discount,
+			SubTotal:      l.Net(),
+			TaxRate:       taxRates[i],
+			TaxBase:       bases[i],
+			TaxAmount:     taxes[i],
+			ServiceCharge: serviceCharges[i],
+			PromotionIDs:  promotionIDs,
+		}
+		if req.Items[i].Unit != "" {
+			d.Unit = req.Items[i].Unit
+			d.UnitQuantity = l.Quantity
+		}
+		details = append(details, d)
+	}
+
+	// Loyalty
+	var totalSpent, pointsEarned int
+	paymentReqs := req.Payments
+	if req.CustomerID != nil {
+		var points int
+		err := tx.QueryRow("SELECT points, total_spent FROM customers WHERE id = $1 FOR UPDATE", *req.CustomerID).Scan(&points, &totalSpent)
 		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("Customer %d not found", *req.CustomerID)
+			}
 			return nil, err
 		}
-		details = append(details, models.TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: productName,
-			Quantity:    item.Quantity,
-			SubTotal:    subTotal,
-		})
+		if req.RedeemPoints > points {
+			return nil, fmt.Errorf("Customer only has %d points", points)
+		}
+		pointsValue := req.RedeemPoints * r.loyalty.PointValue
+		if pointsValue > totalAmount {
+			return nil, errors.New("Redeemed points are worth more than the total amount")
+		}
+		if pointsValue > 0 {
+			paymentReqs = append([]models.PaymentRequest{{Method: models.PaymentPoints, Amount: pointsValue}}, req.Payments...)
+		}
+		// Points are only earned on the part not paid with points.
+		pointsEarned = r.loyalty.EarnedPoints(totalAmount-pointsValue, r.loyalty.TierFor(totalSpent))
+	}
+
+	// Payment
+	paidAmount := 0
+	for _, p := range paymentReqs {
+		paidAmount += p.Amount
+	}
+	if paidAmount < totalAmount {
+		return nil, fmt.Errorf("Payment of %d is less than total amount %d", paidAmount, totalAmount)
+	}
+	changeAmount := paidAmount - totalAmount
+	if changeAmount > req.TenderedCash() {
+		return nil, errors.New("Change can only be given from cash payment")
+	}
+
+	shiftID, err := r.shiftID(tx, req.OutletID)
+	if err != nil {
+		return nil, err
 	}
+
 	var transactionID int
-	err = tx.QueryRow("INSERT INTO transactions(total_amount) VALUES ($1) RETURNING id", totalAmount).Scan(&transactionID)
+	var createdAt time.Time
+	err = tx.QueryRow("INSERT INTO transactions(subtotal, tax_base, tax_amount, service_charge, total_amount, paid_amount, change_amount, customer_id, points_earned, points_redeemed, shift_id, outlet_id, created_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, created_at", subtotal, taxBase, taxAmount, serviceCharge, totalAmount, paidAmount, changeAmount, req.CustomerID, pointsEarned, req.RedeemPoints, shiftID, req.OutletID, req.Cashier).Scan(&transactionID, &createdAt)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.CustomerID != nil {
+		if req.RedeemPoints > 0 {
+			if err := r.addPoints(tx, *req.CustomerID, transactionID, -req.RedeemPoints, models.PointsRedeem); err != nil {
+				return nil, err
+			}
+		}
+		if pointsEarned > 0 {
+			if err := r.addPoints(tx, *req.CustomerID, transactionID, pointsEarned, models.PointsEarn); err != nil {
+				return nil, err
+			}
+		}
+		if err := r.setTotalSpent(tx, *req.CustomerID, totalSpent+totalAmount); err != nil {
+			return nil, err
+		}
+	}
+
 	for i := range details {
 		details[i].TransactionID = transactionID
-		_, err := tx.Exec("INSERT INTO transaction_details (transaction_id, product_id, quantity, sub_total) VALUES ($1,$2,$3,$4)", transactionID, details[i].ProductID, details[i].Quantity, details[i].SubTotal)
+		d := &details[i]
+		// The cost of goods sold is taken while the stock is still there: a
+		// bundle costs its components, a menu item its ingredients.
+		taken := []stockPart{{productID: d.ProductID, quantity: models.Qty(1)}}
+		if parts[i] != nil {
+			taken = parts[i]
+		} else if ingredients[i] != nil {
+			taken = ingredients[i]
+		}
+		costs := make([]int, len(taken))
+		for j, p := range taken {
+			if costs[j], err = consumeCost(tx, p.productID, p.quantity.Mul(d.Quantity)); err != nil {
+				return nil, err
+			}
+			d.Cost += costs[j]
+		}
+		err := tx.QueryRow("INSERT INTO transaction_details (transaction_id, product_id, quantity, unit, unit_quantity, unit_price, price_rule, price_list_id, cost, gross_amount, discount, sub_total, tax_rate, tax_base, tax_amount, service_charge) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16) RETURNING id", transactionID, d.ProductID, d.Quantity, d.Unit, d.UnitQuantity, d.UnitPrice, d.PriceRule, d.PriceListID, d.Cost, d.GrossAmount, d.Discount, d.SubTotal, d.TaxRate, d.TaxBase, d.TaxAmount, d.ServiceCharge).Scan(&d.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range lines[i].Applied {
+			_, err := tx.Exec("INSERT INTO transaction_detail_promotions (detail_id, promotion_id, amount) VALUES ($1,$2,$3)", details[i].ID, a.PromotionID, a.Amount)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if parts[i] != nil {
+			if d.Components, err = sellComponents(tx, d, parts[i], costs, req.OutletID, req.Cashier); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if ingredients[i] != nil {
+			if d.Ingredients, err = useIngredients(tx, d, ingredients[i], req.OutletID, req.Cashier); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if d.Lots, err = sellFromLots(tx, d.ID, d.ProductID, req.OutletID, d.Quantity); err != nil {
+			return nil, err
+		}
+		if err := sellSerials(tx, d.ID, d.ProductID, req.OutletID, req.Items[i].Serials); err != nil {
+			return nil, err
+		}
+		d.Serials = req.Items[i].Serials
+		_, err = adjustStock(tx, &models.StockMovement{
+			ProductID:     d.ProductID,
+			OutletID:      req.OutletID,
+			Delta:         -d.Quantity,
+			Reason:        models.StockSale,
+			ReferenceType: models.RefTransaction,
+			ReferenceID:   &transactionID,
+			CreatedBy:     req.Cashier,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payments := make([]models.Payment, 0, len(paymentReqs))
+	for _, p := range paymentReqs {
+		payment := models.Payment{
+			TransactionID: transactionID,
+			Method:        p.Method,
+			Amount:        p.Amount,
+			Reference:     p.Reference,
+		}
+		err := tx.QueryRow("INSERT INTO payments (transaction_id, method, amount, reference) VALUES ($1,$2,$3,$4) RETURNING id", transactionID, p.Method, p.Amount, p.Reference).Scan(&payment.ID)
 		if err != nil {
 			return nil, err
 		}
+		payments = append(payments, payment)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 	return &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		Details:     details,
+		ID:             transactionID,
+		Type:           models.TransactionSale,
+		OutletID:       req.OutletID,
+		Subtotal:       subtotal,
+		TaxBase:        taxBase,
+		TaxAmount:      taxAmount,
+		ServiceCharge:  serviceCharge,
+		GrandTotal:     totalAmount,
+		TotalAmount:    totalAmount,
+		PaidAmount:     paidAmount,
+		ChangeAmount:   changeAmount,
+		Outstanding:    0,
+		CustomerID:     req.CustomerID,
+		ShiftID:        shiftID,
+		PointsEarned:   pointsEarned,
+		PointsRedeemed: req.RedeemPoints,
+		CreatedBy:      req.Cashier,
+		CreatedAt:      createdAt,
+		Details:        details,
+		Payments:       payments,
 	}, err
 }
 
-func (r *TransactionRepositoryImpl) FindAllTransaction() ([]models.TransactionDetail, error) {
-	query := "SELECT t.id, t.transaction_id, t.product_id, p.name, t.quantity, t.sub_total FROM transaction_details t INNER JOIN product p ON t.product_id = p.id ORDER BY t.id"
-	rows, err := r.db.Query(query)
+// bestPrice picks the lowest price per base unit of a product from its
+// standard or outlet price, the wholesale tier the basket quantity reaches
+// and the price list of the customer's group, and says which rule gave it.
+func bestPrice(tx *sql.Tx, productID, price int, rule string, quantity models.Quantity, group string) (int, string, *int, error) {
+	var tierPrice int
+	err := tx.QueryRow("SELECT price FROM price_tiers WHERE product_id = $1 AND min_qty <= $2 ORDER BY min_qty DESC LIMIT 1", productID, quantity).Scan(&tierPrice)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, "", nil, err
+	}
+	if err == nil && tierPrice < price {
+		price, rule = tierPrice, models.PriceRuleTier
+	}
+	if group == "" {
+		return price, rule, nil, nil
+	}
+	var listID, listPrice int
+	err = tx.QueryRow(`SELECT pl.id, i.price FROM price_lists pl INNER JOIN price_list_items i ON i.price_list_id = pl.id
+		WHERE $1 = ANY(pl.customer_groups) AND i.product_id = $2`, group, productID).Scan(&listID, &listPrice)
+	if err == sql.ErrNoRows {
+		return price, rule, nil, nil
+	}
+	if err != nil {
+		return 0, "", nil, err
+	}
+	if listPrice < price {
+		return listPrice, models.PriceRulePriceList, &listID, nil
+	}
+	return price, rule, nil, nil
+}
+
+// stockPart is a product whose stock a sold line takes: a bundle component
+// priced at the outlet or a recipe ingredient.
+type stockPart struct {
+	productID  int
+	name       string
+	unit       string
+	quantity   models.Quantity
+	price      int
+	serialized bool
+}
+
+func bundleParts(tx *sql.Tx, bundleID int, outletID *int) ([]stockPart, error) {
+	rows, err := tx.Query(`SELECT p.id, p.name, bc.quantity, COALESCE(op.price, p.price), p.serialized
+		FROM bundle_components bc INNER JOIN product p ON p.id = bc.component_id
+		LEFT JOIN product_outlet_prices op ON op.product_id = p.id AND op.outlet_id = $2
+		WHERE bc.bundle_id = $1 ORDER BY p.id`, bundleID, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var parts []stockPart
+	for rows.Next() {
+		var p stockPart
+		if err := rows.Scan(&p.productID, &p.name, &p.quantity, &p.price, &p.serialized); err != nil {
+			return nil, err
+		}
+		parts = append(parts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("Bundle %d has no components", bundleID)
+	}
+	return parts, nil
+}
+
+// recipeParts returns the ingredients of a product's recipe, or nil when it
+// has none.
+func recipeParts(tx *sql.Tx, productID int) ([]stockPart, error) {
+	rows, err := tx.Query(`SELECT p.id, p.name, p.unit, ri.quantity
+		FROM recipe_items ri INNER JOIN product p ON p.id = ri.ingredient_id
+		WHERE ri.product_id = $1 ORDER BY p.id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var parts []stockPart
+	for rows.Next() {
+		var p stockPart
+		if err := rows.Scan(&p.productID, &p.name, &p.unit, &p.quantity); err != nil {
+			return nil, err
+		}
+		parts = append(parts, p)
+	}
+	return parts, rows.Err()
+}
+
+// useIngredients takes the ingredients of a sold menu item line out of
+// stock and records them against the line.
+func useIngredients(tx *sql.Tx, d *models.TransactionDetail, parts []stockPart, outletID *int, user string) ([]models.RecipeItem, error) {
+	used := make([]models.RecipeItem, 0, len(parts))
+	for _, p := range parts {
+		item := models.RecipeItem{IngredientID: p.productID, IngredientName: p.name, Unit: p.unit, Quantity: p.quantity.Mul(d.Quantity)}
+		if _, err := tx.Exec("INSERT INTO transaction_detail_ingredients (detail_id, ingredient_id, quantity) VALUES ($1, $2, $3)", d.ID, item.IngredientID, item.Quantity); err != nil {
+			return nil, err
+		}
+		lots, err := sellFromLots(tx, d.ID, item.IngredientID, outletID, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		d.Lots = append(d.Lots, lots...)
+		_, err = adjustStock(tx, &models.StockMovement{
+			ProductID:     item.IngredientID,
+			OutletID:      outletID,
+			Delta:         -item.Quantity,
+			Reason:        models.StockUsage,
+			ReferenceType: models.RefTransaction,
+			ReferenceID:   &d.TransactionID,
+			CreatedBy:     user,
+		})
+		if err != nil {
+			return nil, err
+		}
+		used = append(used, item)
+	}
+	return used, nil
+}
+
+// sellComponents takes the components of a sold bundle line out of stock
+// and records them against the line. The line's revenue is spread over the
+// components by their own selling price.
+func sellComponents(tx *sql.Tx, d *models.TransactionDetail, parts []stockPart, costs []int, outletID *int, user string) ([]models.DetailComponent, error) {
+	weights := make([]int, len(parts))
+	total := 0
+	for i, p := range parts {
+		weights[i] = p.quantity.Amount(p.price)
+		total += weights[i]
+	}
+	if total == 0 {
+		for i, p := range parts {
+			weights[i] = int(p.quantity)
+		}
+	}
+	revenues := pricing.Allocate(d.SubTotal, weights)
+	components := make([]models.DetailComponent, 0, len(parts))
+	for i, p := range parts {
+		c := models.DetailComponent{ProductID: p.productID, ProductName: p.name, Quantity: p.quantity.Mul(d.Quantity), Revenue: revenues[i], Cost: costs[i]}
+		if _, err := tx.Exec("INSERT INTO transaction_detail_components (detail_id, product_id, quantity, revenue, cost) VALUES ($1, $2, $3, $4, $5)", d.ID, c.ProductID, c.Quantity, c.Revenue, c.Cost); err != nil {
+			return nil, err
+		}
+		lots, err := sellFromLots(tx, d.ID, c.ProductID, outletID, c.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		d.Lots = append(d.Lots, lots...)
+		_, err = adjustStock(tx, &models.StockMovement{
+			ProductID:     c.ProductID,
+			OutletID:      outletID,
+			Delta:         -c.Quantity,
+			Reason:        models.StockSale,
+			ReferenceType: models.RefTransaction,
+			ReferenceID:   &d.TransactionID,
+			CreatedBy:     user,
+		})
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	return components, nil
+}
+
+// detailIngredients loads the ingredients recorded for menu item lines,
+// keyed by detail ID.
+func detailIngredients(q queryer, detailIDs []int) (map[int][]models.RecipeItem, error) {
+	ingredients := make(map[int][]models.RecipeItem)
+	if len(detailIDs) == 0 {
+		return ingredients, nil
+	}
+	rows, err := q.Query(`SELECT di.detail_id, di.ingredient_id, p.name, p.unit, di.quantity
+		FROM transaction_detail_ingredients di INNER JOIN product p ON p.id = di.ingredient_id
+		WHERE di.detail_id = ANY($1) ORDER BY di.detail_id, di.ingredient_id`, pq.Array(detailIDs))
+	if err != nil {
+		log.Printf("Error getting detail ingredients: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var detailID int
+		var item models.RecipeItem
+		if err := rows.Scan(&detailID, &item.IngredientID, &item.IngredientName, &item.Unit, &item.Quantity); err != nil {
+			return nil, err
+		}
+		ingredients[detailID] = append(ingredients[detailID], item)
+	}
+	return ingredients, rows.Err()
+}
+
+// detailComponents loads the components recorded for bundle lines, keyed
+// by detail ID.
+func detailComponents(q queryer, detailIDs []int) (map[int][]models.DetailComponent, error) {
+	components := make(map[int][]models.DetailComponent)
+	if len(detailIDs) == 0 {
+		return components, nil
+	}
+	rows, err := q.Query(`SELECT dc.detail_id, dc.product_id, p.name, dc.quantity, dc.revenue, dc.cost
+		FROM transaction_detail_components dc INNER JOIN product p ON p.id = dc.product_id
+		WHERE dc.detail_id = ANY($1) ORDER BY dc.detail_id, dc.product_id`, pq.Array(detailIDs))
+	if err != nil {
+		log.Printf("Error getting detail components: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var detailID int
+		var c models.DetailComponent
+		if err := rows.Scan(&detailID, &c.ProductID, &c.ProductName, &c.Quantity, &c.Revenue, &c.Cost); err != nil {
+			return nil, err
+		}
+		components[detailID] = append(components[detailID], c)
+	}
+	return components, rows.Err()
+}
+
+func (r *TransactionRepositoryImpl) FindAllTransaction(outletID *int) ([]models.TransactionDetail, error) {
+	query := `SELECT t.id, t.transaction_id, t.product_id, p.name, t.quantity, t.sub_total FROM transaction_details t
+		INNER JOIN product p ON t.product_id = p.id
+		INNER JOIN transactions tr ON t.transaction_id = tr.id
+		WHERE $1::int IS NULL OR tr.outlet_id = $1 ORDER BY t.id`
+	rows, err := r.db.Query(query, outletID)
 	if err != nil {
 		log.Printf("Error getting all transaction details: %v", err)
 		return nil, err
@@ -96,136 +716,577 @@ func (r *TransactionRepositoryImpl) FindAllTransaction() ([]models.TransactionDe
 	return transactions, nil
 }
 
-func (r *TransactionRepositoryImpl) TodaysTransaction() (*models.Report, error) {
-	currentTime := time.Now().Format("2006-01-02")
+func (r *TransactionRepositoryImpl) FindTransactionByID(id int) (*models.Transaction, error) {
+	var t models.Transaction
+	var referenceID sql.NullInt64
+	err := r.db.QueryRow("SELECT id, type, reference_id, outlet_id, subtotal, tax_base, tax_amount, service_charge, total_amount, paid_amount, change_amount, customer_id, shift_id, points_earned, points_redeemed, reason, created_by, created_at FROM transactions WHERE id = $1", id).Scan(&t.ID, &t.Type, &referenceID, &t.OutletID, &t.Subtotal, &t.TaxBase, &t.TaxAmount, &t.ServiceCharge, &t.TotalAmount, &t.PaidAmount, &t.ChangeAmount, &t.CustomerID, &t.ShiftID, &t.PointsEarned, &t.PointsRedeemed, &t.Reason, &t.CreatedBy, &t.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Transaction not found")
+		}
+		return nil, err
+	}
+	t.GrandTotal = t.TotalAmount
+	if referenceID.Valid {
+		ref := int(referenceID.Int64)
+		t.ReferenceID = &ref
+	}
+	if t.Type == models.TransactionSale && t.PaidAmount < t.TotalAmount {
+		t.Outstanding = t.TotalAmount - t.PaidAmount
+	}
 
+	rows, err := r.db.Query(`SELECT d.id, d.transaction_id, d.product_id, p.name, d.quantity, d.unit, d.unit_quantity, d.unit_price, d.price_rule, d.price_list_id, d.cost, d.gross_amount, d.discount, d.sub_total,
+		d.tax_rate, d.tax_base, d.tax_amount, d.service_charge, d.refund_of_detail_id,
+		ARRAY(SELECT dp.promotion_id FROM transaction_detail_promotions dp WHERE dp.detail_id = d.id ORDER BY dp.promotion_id)
+		FROM transaction_details d INNER JOIN product p ON d.product_id = p.id WHERE d.transaction_id = $1 ORDER BY d.id`, id)
+	if err != nil {
+		log.Printf("Error getting transaction details: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	t.Details = make([]models.TransactionDetail, 0)
+	for rows.Next() {
+		var d models.TransactionDetail
+		var refundOf sql.NullInt64
+		var promotionIDs pq.Int64Array
+		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.Quantity, &d.Unit, &d.UnitQuantity, &d.UnitPrice, &d.PriceRule, &d.PriceListID, &d.Cost, &d.GrossAmount, &d.Discount, &d.SubTotal, &d.TaxRate, &d.TaxBase, &d.TaxAmount, &d.ServiceCharge, &refundOf, &promotionIDs); err != nil {
+			return nil, err
+		}
+		for _, promotionID := range promotionIDs {
+			d.PromotionIDs = append(d.PromotionIDs, int(promotionID))
+		}
+		if refundOf.Valid {
+			ref := int(refundOf.Int64)
+			d.RefundOfDetailID = &ref
+		}
+		t.Details = append(t.Details, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	detailIDs := make([]int, len(t.Details))
+	for i, d := range t.Details {
+		detailIDs[i] = d.ID
+	}
+	components, err := detailComponents(r.db, detailIDs)
+	if err != nil {
+		return nil, err
+	}
+	ingredients, err := detailIngredients(r.db, detailIDs)
+	if err != nil {
+		return nil, err
+	}
+	lots, err := detailLots(r.db, detailIDs)
+	if err != nil {
+		return nil, err
+	}
+	serials, err := detailSerials(r.db, detailIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range t.Details {
+		t.Details[i].Components = components[t.Details[i].ID]
+		t.Details[i].Ingredients = ingredients[t.Details[i].ID]
+		t.Details[i].Lots = lots[t.Details[i].ID]
+		t.Details[i].Serials = serials[t.Details[i].ID]
+	}
+
+	payments, err := r.db.Query("SELECT id, transaction_id, method, amount, reference FROM payments WHERE transaction_id = $1 ORDER BY id", id)
+	if err != nil {
+		log.Printf("Error getting payments: %v", err)
+		return nil, err
+	}
+	defer payments.Close()
+	t.Payments = make([]models.Payment, 0)
+	for payments.Next() {
+		var p models.Payment
+		if err := payments.Scan(&p.ID, &p.TransactionID, &p.Method, &p.Amount, &p.Reference); err != nil {
+			return nil, err
+		}
+		t.Payments = append(t.Payments, p)
+	}
+	return &t, nil
+}
+
+// RefundTransaction reverses sale lines as a new negative transaction that
+// references the original sale, and puts the returned quantity back in stock.
+// When void is true every quantity that has not been refunded yet is reversed.
+func (r *TransactionRepositoryImpl) RefundTransaction(id int, req *models.RefundRequest, void bool) (*models.Transaction, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	rows, err := tx.Query("SELECT id, total_amount FROM transactions WHERE created_at::date = $1", currentTime)
+	var saleType string
+	var customerID, outletID *int
+	var saleTotal, saleEarned, saleRedeemed int
+	if err := tx.QueryRow("SELECT type, total_amount, customer_id, outlet_id, points_earned, points_redeemed FROM transactions WHERE id = $1 FOR UPDATE", id).Scan(&saleType, &saleTotal, &customerID, &outletID, &saleEarned, &saleRedeemed); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Transaction not found")
+		}
+		return nil, err
+	}
+	if saleType != models.TransactionSale {
+		return nil, errors.New("Only sale transactions can be refunded")
+	}
+	if outletID, err = stockOutlet(tx, outletID); err != nil {
+		return nil, err
+	}
+
+	type saleLine struct {
+		productID     int
+		productName   string
+		quantity      models.Quantity
+		cost          int
+		discount      int
+		subTotal      int
+		taxRate       float64
+		taxBase       int
+		taxAmount     int
+		serviceCharge int
+		refunded      models.Quantity
+	}
+	rows, err := tx.Query(`SELECT d.id, d.product_id, p.name, d.quantity, d.cost, d.discount, d.sub_total, d.tax_rate, d.tax_base, d.tax_amount, d.service_charge,
+		COALESCE((SELECT -SUM(r.quantity) FROM transaction_details r WHERE r.refund_of_detail_id = d.id), 0)
+		FROM transaction_details d INNER JOIN product p ON d.product_id = p.id
+		WHERE d.transaction_id = $1 ORDER BY d.id`, id)
 	if err != nil {
-		log.Printf("Error getting transaction: %v", err)
+		log.Printf("Error getting transaction details: %v", err)
 		return nil, err
 	}
-	defer rows.Close()
-	totalEarning := 0
-	var transactions []models.Transaction
+	lines := make(map[int]*saleLine)
+	var order []int
 	for rows.Next() {
-		var transaction models.Transaction
-		if err := rows.Scan(&transaction.ID, &transaction.TotalAmount); err != nil {
+		var detailID int
+		var l saleLine
+		if err := rows.Scan(&detailID, &l.productID, &l.productName, &l.quantity, &l.cost, &l.discount, &l.subTotal, &l.taxRate, &l.taxBase, &l.taxAmount, &l.serviceCharge, &l.refunded); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lines[detailID] = &l
+		order = append(order, detailID)
+	}
+	rows.Close()
+	components, err := detailComponents(tx, order)
+	if err != nil {
+		return nil, err
+	}
+	ingredients, err := detailIngredients(tx, order)
+	if err != nil {
+		return nil, err
+	}
+	type promotionAmount struct {
+		promotionID int
+		amount      int
+	}
+	promotionRows, err := tx.Query("SELECT detail_id, promotion_id, amount FROM transaction_detail_promotions WHERE detail_id = ANY($1) ORDER BY detail_id, promotion_id", pq.Array(order))
+	if err != nil {
+		return nil, err
+	}
+	salePromotions := make(map[int][]promotionAmount)
+	for promotionRows.Next() {
+		var detailID int
+		var p promotionAmount
+		if err := promotionRows.Scan(&detailID, &p.promotionID, &p.amount); err != nil {
+			promotionRows.Close()
+			return nil, err
+		}
+		salePromotions[detailID] = append(salePromotions[detailID], p)
+	}
+	promotionRows.Close()
+	if err := promotionRows.Err(); err != nil {
+		return nil, err
+	}
+
+	items := req.Items
+	if void {
+		items = nil
+		for _, detailID := range order {
+			if remaining := lines[detailID].quantity - lines[detailID].refunded; remaining > 0 {
+				items = append(items, models.RefundItem{DetailID: detailID, Quantity: remaining})
+			}
+		}
+		if len(items) == 0 {
+			return nil, errors.New("Transaction has already been fully refunded")
+		}
+	}
+
+	refund := models.Transaction{
+		ReferenceID: &id,
+		OutletID:    outletID,
+		Reason:      req.Reason,
+		CreatedBy:   req.RefundedBy,
+		Details:     make([]models.TransactionDetail, 0, len(items)),
+		Payments:    make([]models.Payment, 0, 1),
+	}
+	// The discount each promotion gave is handed back with the refund line.
+	refundPromotions := make([][]promotionAmount, 0, len(items))
+	for _, item := range items {
+		l, ok := lines[item.DetailID]
+		if !ok {
+			return nil, fmt.Errorf("Detail %d does not belong to transaction %d", item.DetailID, id)
+		}
+		if item.Quantity > l.quantity-l.refunded {
+			return nil, fmt.Errorf("Only %s of %s can still be refunded", l.quantity-l.refunded, l.productName)
+		}
+		// Prorate against the cumulative quantity so repeated partial refunds
+		// never add up to more than the original line amounts.
+		prorate := func(v int) int {
+			return int(int64(v)*int64(l.refunded+item.Quantity)/int64(l.quantity) - int64(v)*int64(l.refunded)/int64(l.quantity))
+		}
+		d := models.TransactionDetail{
+			ProductID:     l.productID,
+			ProductName:   l.productName,
+			Quantity:      -item.Quantity,
+			Cost:          -prorate(l.cost),
+			Discount:      -prorate(l.discount),
+			SubTotal:      -prorate(l.subTotal),
+			TaxRate:       l.taxRate,
+			TaxBase:       -prorate(l.taxBase),
+			TaxAmount:     -prorate(l.taxAmount),
+			ServiceCharge: -prorate(l.serviceCharge),
+		}
+		d.GrossAmount = d.SubTotal + d.Discount
+		for _, c := range components[item.DetailID] {
+			d.Components = append(d.Components, models.DetailComponent{
+				ProductID:   c.ProductID,
+				ProductName: c.ProductName,
+				Quantity:    -models.Quantity(prorate(int(c.Quantity))),
+				Revenue:     -prorate(c.Revenue),
+				Cost:        -prorate(c.Cost),
+			})
+		}
+		var promotions []promotionAmount
+		for _, p := range salePromotions[item.DetailID] {
+			if amount := prorate(p.amount); amount != 0 {
+				promotions = append(promotions, promotionAmount{promotionID: p.promotionID, amount: -amount})
+				d.PromotionIDs = append(d.PromotionIDs, p.promotionID)
+			}
+		}
+		// Ingredients are used up, their cost stays with the sale.
+		if len(ingredients[item.DetailID]) > 0 {
+			d.Cost = 0
+		}
+		// A serialised line returns the units it names, a void all of them.
+		sold, err := soldSerials(tx, item.DetailID)
+		if err != nil {
+			return nil, err
+		}
+		if len(sold) > 0 {
+			d.Serials = item.Serials
+			if void {
+				d.Serials = sold
+			}
+			if models.Qty(len(d.Serials)) != item.Quantity {
+				return nil, fmt.Errorf("Name the %s serial numbers of %s being returned", item.Quantity, l.productName)
+			}
+			for _, s := range d.Serials {
+				if !slices.Contains(sold, s) {
+					return nil, fmt.Errorf("Serial %s was not sold on detail %d", s, item.DetailID)
+				}
+			}
+		}
+		l.refunded += item.Quantity
+		detailID := item.DetailID
+		d.RefundOfDetailID = &detailID
+		refund.Details = append(refund.Details, d)
+		refundPromotions = append(refundPromotions, promotions)
+
+		refund.Subtotal += d.SubTotal
+		refund.TaxBase += d.TaxBase
+		refund.TaxAmount += d.TaxAmount
+		refund.ServiceCharge += d.ServiceCharge
+	}
+
+	refund.Type = models.TransactionRefund
+	if void {
+		refund.Type = models.TransactionVoid
+	}
+	refund.TotalAmount = refund.TaxBase + refund.TaxAmount + refund.ServiceCharge
+	refund.GrandTotal = refund.TotalAmount
+	refund.PaidAmount = refund.TotalAmount
+
+	// Take back the share of points earned on the refunded amount and hand
+	// back the share of redeemed points, the rest goes out through req.Method.
+	var totalSpent, pointsValue int
+	if customerID != nil {
+		var refundedBefore, salePointsValue int
+		if err := tx.QueryRow("SELECT COALESCE(-SUM(total_amount), 0) FROM transactions WHERE reference_id = $1", id).Scan(&refundedBefore); err != nil {
+			return nil, err
+		}
+		if err := tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM payments WHERE transaction_id = $1 AND method = $2", id, models.PaymentPoints).Scan(&salePointsValue); err != nil {
+			return nil, err
+		}
+		if err := tx.QueryRow("SELECT total_spent FROM customers WHERE id = $1 FOR UPDATE", *customerID).Scan(&totalSpent); err != nil {
+			return nil, err
+		}
+		if saleTotal > 0 {
+			refunded := -refund.TotalAmount
+			share := func(v int) int {
+				return v*(refundedBefore+refunded)/saleTotal - v*refundedBefore/saleTotal
+			}
+			refund.PointsEarned = -share(saleEarned)
+			refund.PointsRedeemed = -share(saleRedeemed)
+			pointsValue = share(salePointsValue)
+		}
+		refund.CustomerID = customerID
+	}
+	// Refunded cash leaves the drawer of the outlet's shift that is open now.
+	if refund.ShiftID, err = r.shiftID(tx, outletID); err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow("INSERT INTO transactions(type, reference_id, subtotal, tax_base, tax_amount, service_charge, total_amount, paid_amount, change_amount, customer_id, points_earned, points_redeemed, shift_id, reason, created_by, outlet_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 0, $9, $10, $11, $12, $13, $14, $15) RETURNING id, created_at", refund.Type, id, refund.Subtotal, refund.TaxBase, refund.TaxAmount, refund.ServiceCharge, refund.TotalAmount, refund.PaidAmount, refund.CustomerID, refund.PointsEarned, refund.PointsRedeemed, refund.ShiftID, refund.Reason, refund.CreatedBy, outletID).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if customerID != nil {
+		if refund.PointsEarned != 0 {
+			if err := r.addPoints(tx, *customerID, refund.ID, refund.PointsEarned, models.PointsRefund); err != nil {
+				return nil, err
+			}
+		}
+		if refund.PointsRedeemed != 0 {
+			if err := r.addPoints(tx, *customerID, refund.ID, -refund.PointsRedeemed, models.PointsRefund); err != nil {
+				return nil, err
+			}
+		}
+		if err := r.setTotalSpent(tx, *customerID, totalSpent+refund.TotalAmount); err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, transaction)
-		totalEarning += transaction.TotalAmount
 	}
-	var productSold []models.ProductSold
-	for i := range transactions {
-		rows, err := tx.Query("SELECT p.name, t.quantity FROM transaction_details t INNER JOIN product p ON t.product_id = p.id WHERE t.transaction_id = $1", transactions[i].ID)
+	for i := range refund.Details {
+		d := &refund.Details[i]
+		d.TransactionID = refund.ID
+		err := tx.QueryRow("INSERT INTO transaction_details (transaction_id, product_id, quantity, cost, gross_amount, discount, sub_total, tax_rate, tax_base, tax_amount, service_charge, refund_of_detail_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) RETURNING id", refund.ID, d.ProductID, d.Quantity, d.Cost, d.GrossAmount, d.Discount, d.SubTotal, d.TaxRate, d.TaxBase, d.TaxAmount, d.ServiceCharge, d.RefundOfDetailID).Scan(&d.ID)
 		if err != nil {
-			log.Printf("Error getting transaction details: %v", err)
 			return nil, err
 		}
-		defer rows.Close()
-		var product models.ProductSold
-		for rows.Next() {
-			if err := rows.Scan(&product.ProductName, &product.ProductQty); err != nil {
+		for _, p := range refundPromotions[i] {
+			if _, err := tx.Exec("INSERT INTO transaction_detail_promotions (detail_id, promotion_id, amount) VALUES ($1,$2,$3)", d.ID, p.promotionID, p.amount); err != nil {
+				return nil, err
+			}
+		}
+		// Returned goods go back to the outlet and lots that sold them, a
+		// returned bundle as its components. Ingredients of a menu item are
+		// used up and stay out of stock.
+		if len(ingredients[*d.RefundOfDetailID]) > 0 {
+			continue
+		}
+		if err := returnSerials(tx, d.ID, outletID, d.Serials); err != nil {
+			return nil, err
+		}
+		returned := d.Components
+		if returned == nil {
+			returned = []models.DetailComponent{{ProductID: d.ProductID, Quantity: d.Quantity, Cost: d.Cost}}
+		}
+		for _, c := range returned {
+			if d.Components != nil {
+				if _, err := tx.Exec("INSERT INTO transaction_detail_components (detail_id, product_id, quantity, revenue, cost) VALUES ($1, $2, $3, $4, $5)", d.ID, c.ProductID, c.Quantity, c.Revenue, c.Cost); err != nil {
+					return nil, err
+				}
+			}
+			lots, err := returnToLots(tx, d.ID, *d.RefundOfDetailID, c.ProductID, -c.Quantity)
+			if err != nil {
+				return nil, err
+			}
+			d.Lots = append(d.Lots, lots...)
+			// Returned goods go back into stock at the cost they were sold at.
+			if c.Quantity != 0 {
+				unitCost := int((int64(-c.Cost)*models.QuantityScale - int64(c.Quantity)/2) / int64(-c.Quantity))
+				if err := receiveCost(tx, c.ProductID, -c.Quantity, unitCost, nil); err != nil {
+					return nil, err
+				}
+			}
+			_, err = adjustStock(tx, &models.StockMovement{
+				ProductID:     c.ProductID,
+				OutletID:      outletID,
+				Delta:         -c.Quantity,
+				Reason:        models.StockRefund,
+				ReferenceType: models.RefTransaction,
+				ReferenceID:   &refund.ID,
+				CreatedBy:     req.RefundedBy,
+			})
+			if err != nil {
 				return nil, err
 			}
-			productSold = append(productSold, product)
 		}
 	}
-
-	highSelling := 0
-	var mostSold string
-	for _, v := range productSold {
-		if v.ProductQty > highSelling {
-			highSelling = v.ProductQty
-			mostSold = v.ProductName
+	payments := []models.Payment{{TransactionID: refund.ID, Method: req.Method, Amount: refund.TotalAmount + pointsValue}}
+	if pointsValue != 0 {
+		payments = append(payments, models.Payment{TransactionID: refund.ID, Method: models.PaymentPoints, Amount: -pointsValue})
+		if payments[0].Amount == 0 {
+			payments = payments[1:]
 		}
 	}
-	details := models.ProductSold{
-		ProductName: mostSold,
-		ProductQty:  highSelling,
+	for _, payment := range payments {
+		err = tx.QueryRow("INSERT INTO payments (transaction_id, method, amount) VALUES ($1,$2,$3) RETURNING id", refund.ID, payment.Method, payment.Amount).Scan(&payment.ID)
+		if err != nil {
+			return nil, err
+		}
+		refund.Payments = append(refund.Payments, payment)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
+	return &refund, nil
+}
 
-	return &models.Report{
-		TotalRevenue:     totalEarning,
-		TotalTransaction: len(transactions),
-		HighestSelling:   details,
-	}, err
+// addPoints writes a ledger entry and moves the customer's point balance with it.
+func (r *TransactionRepositoryImpl) addPoints(tx *sql.Tx, customerID, transactionID, points int, reason string) error {
+	if _, err := tx.Exec("INSERT INTO point_ledger (customer_id, transaction_id, points, reason) VALUES ($1,$2,$3,$4)", customerID, transactionID, points, reason); err != nil {
+		return err
+	}
+	_, err := tx.Exec("UPDATE customers SET points = points + $1 WHERE id = $2", points, customerID)
+	return err
 }
 
-func (r *TransactionRepositoryImpl) RangeTransaction(start, end string) (*models.Report, error) {
-	start_date := start + " 00:00:00"
-	end_date := end + " 23:59:50"
+// setTotalSpent stores the customer's new lifetime spend and the tier it reaches.
+func (r *TransactionRepositoryImpl) setTotalSpent(tx *sql.Tx, customerID, totalSpent int) error {
+	_, err := tx.Exec("UPDATE customers SET total_spent = $1, tier = $2 WHERE id = $3", totalSpent, r.loyalty.TierFor(totalSpent).Name, customerID)
+	return err
+}
 
-	tx, err := r.db.Begin()
+// TodaysTransaction and RangeTransaction report on one outlet when outletID
+// is set and roll all outlets up otherwise.
+func (r *TransactionRepositoryImpl) TodaysTransaction(outletID *int) (*models.Report, error) {
+	currentTime := time.Now().Format("2006-01-02")
+	return buildReport(r.db, "t.created_at::date = $1 AND ($2::int IS NULL OR t.outlet_id = $2)", currentTime, outletID)
+}
+
+func (r *TransactionRepositoryImpl) RangeTransaction(start, end string, outletID *int) (*models.Report, error) {
+	return buildReport(r.db, "t.created_at >= $1::date AND t.created_at < $2::date + 1 AND ($3::int IS NULL OR t.outlet_id = $3)", start, end, outletID)
+}
+
+// PromotionReport sums the discount each promotion gave away on sales in the
+// range, net of what refunds handed back. TimesApplied only counts sales.
+func (r *TransactionRepositoryImpl) PromotionReport(start, end string, outletID *int) ([]models.PromotionUsage, error) {
+	rows, err := r.db.Query(`SELECT p.id, p.name, COUNT(*) FILTER (WHERE dp.amount > 0), SUM(dp.amount)
+		FROM transaction_detail_promotions dp
+		INNER JOIN transaction_details d ON dp.detail_id = d.id
+		INNER JOIN transactions t ON d.transaction_id = t.id
+		INNER JOIN promotions p ON dp.promotion_id = p.id
+		WHERE t.created_at >= $1::date AND t.created_at < $2::date + 1 AND ($3::int IS NULL OR t.outlet_id = $3)
+		GROUP BY p.id, p.name ORDER BY SUM(dp.amount) DESC`, start, end, outletID)
 	if err != nil {
+		log.Printf("Error getting promotion report: %v", err)
 		return nil, err
 	}
-	defer tx.Rollback()
+	defer rows.Close()
+	usages := make([]models.PromotionUsage, 0)
+	for rows.Next() {
+		var u models.PromotionUsage
+		if err := rows.Scan(&u.PromotionID, &u.Name, &u.TimesApplied, &u.TotalDiscount); err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+	return usages, nil
+}
 
-	rows, err := tx.Query("SELECT id, total_amount FROM transactions WHERE created_at >= $1 AND created_at < $2", start_date, end_date)
+// ProductSalesReport sums quantity and revenue per product in the range.
+// Grouped by parent, variants are rolled up into their parent product;
+// grouped by component, bundle lines count as the components they took.
+func (r *TransactionRepositoryImpl) ProductSalesReport(start, end, groupBy string, outletID *int) ([]models.ProductSales, error) {
+	group, quantity, revenue, netRevenue, cost, join, names := "p.id", "d.quantity", "d.sub_total", "d.tax_base", "d.cost", "", "product"
+	switch groupBy {
+	case models.SalesByParent:
+		group = "COALESCE(p.parent_id, p.id)"
+	case models.SalesByComponent:
+		// A component's share of the line before tax follows its share of the revenue.
+		group, quantity, revenue, cost = "COALESCE(dc.product_id, p.id)", "COALESCE(dc.quantity, d.quantity)", "COALESCE(dc.revenue, d.sub_total)", "COALESCE(dc.cost, d.cost)"
+		netRevenue = "COALESCE(ROUND(dc.revenue::numeric * d.tax_base / NULLIF(d.sub_total, 0)), d.tax_base)"
+		join = " LEFT JOIN transaction_detail_components dc ON dc.detail_id = d.id"
+	case models.SalesByCategory:
+		group, names = "p.category_id", "category"
+	}
+	rows, err := r.db.Query(`SELECT g.id, g.name, s.quantity, s.revenue, s.net_revenue, s.cost FROM (
+			SELECT `+group+` AS group_id, SUM(`+quantity+`) AS quantity, SUM(`+revenue+`) AS revenue,
+				SUM(`+netRevenue+`) AS net_revenue, SUM(`+cost+`) AS cost
+			FROM transaction_details d
+			INNER JOIN transactions t ON d.transaction_id = t.id
+			INNER JOIN product p ON d.product_id = p.id`+join+`
+			WHERE t.created_at >= $1::date AND t.created_at < $2::date + 1 AND ($3::int IS NULL OR t.outlet_id = $3)
+			GROUP BY 1
+		) s INNER JOIN `+names+` g ON g.id = s.group_id
+		ORDER BY s.quantity DESC, g.id`, start, end, outletID)
 	if err != nil {
-		log.Printf("Error getting transaction: %v", err)
+		log.Printf("Error getting product sales report: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
-	totalEarning := 0
-	var transactions []models.Transaction
+	sales := make([]models.ProductSales, 0)
 	for rows.Next() {
-		var transaction models.Transaction
-		if err := rows.Scan(&transaction.ID, &transaction.TotalAmount); err != nil {
+		var s models.ProductSales
+		if err := rows.Scan(&s.ProductID, &s.ProductName, &s.Quantity, &s.Revenue, &s.NetRevenue, &s.Cost); err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, transaction)
-		totalEarning += transaction.TotalAmount
+		s.GrossProfit = s.NetRevenue - s.Cost
+		s.Margin = models.Margin(s.GrossProfit, s.NetRevenue)
+		sales = append(sales, s)
 	}
-	var productSold []models.ProductSold
-	for i := range transactions {
-		rows, err := tx.Query("SELECT p.name, t.quantity FROM transaction_details t INNER JOIN product p ON t.product_id = p.id WHERE t.transaction_id = $1", transactions[i].ID)
-		if err != nil {
-			log.Printf("Error getting transaction details: %v", err)
+	return sales, nil
+}
+
+// TaxReport groups tax base and tax by rate for the range. Refund lines
+// are negative so they reduce the rate they were charged at.
+func (r *TransactionRepositoryImpl) TaxReport(start, end string, outletID *int) (*models.TaxReport, error) {
+	rows, err := r.db.Query(`SELECT d.tax_rate, COALESCE(SUM(d.tax_base), 0), COALESCE(SUM(d.tax_amount), 0), COALESCE(SUM(d.service_charge), 0)
+		FROM transaction_details d INNER JOIN transactions t ON d.transaction_id = t.id
+		WHERE t.created_at >= $1::date AND t.created_at < $2::date + 1 AND ($3::int IS NULL OR t.outlet_id = $3)
+		GROUP BY d.tax_rate ORDER BY d.tax_rate`, start, end, outletID)
+	if err != nil {
+		log.Printf("Error getting tax report: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	report := models.TaxReport{StartDate: start, EndDate: end, Rates: make([]models.TaxRateSummary, 0)}
+	for rows.Next() {
+		var rate models.TaxRateSummary
+		var serviceCharge int
+		if err := rows.Scan(&rate.Rate, &rate.TaxBase, &rate.TaxAmount, &serviceCharge); err != nil {
 			return nil, err
 		}
-		defer rows.Close()
-		var product models.ProductSold
-		for rows.Next() {
-			if err := rows.Scan(&product.ProductName, &product.ProductQty); err != nil {
-				return nil, err
-			}
-			productSold = append(productSold, product)
-		}
+		report.Rates = append(report.Rates, rate)
+		report.TotalTaxBase += rate.TaxBase
+		report.TotalTaxAmount += rate.TaxAmount
+		report.TotalServiceCharge += serviceCharge
 	}
-	log.Println("All product sold: ", productSold)
+	return &report, nil
+}
 
-	highSelling := 0
-	var mostSold string
-	for _, v := range productSold {
-		if v.ProductQty > highSelling {
-			highSelling = v.ProductQty
-			mostSold = v.ProductName
-		}
+// buildReport summarises transactions matching where. Voids and refunds are
+// negative rows, so summing them nets refunded revenue and quantities out.
+func buildReport(q queryer, where string, args ...any) (*models.Report, error) {
+	var report models.Report
+	err := q.QueryRow(`SELECT COALESCE(SUM(t.total_amount), 0),
+		COALESCE(-SUM(t.total_amount) FILTER (WHERE t.type <> 'sale'), 0),
+		COUNT(*) FILTER (WHERE t.type = 'sale'),
+		COALESCE(SUM(t.tax_base), 0)
+		FROM transactions t WHERE `+where, args...).Scan(&report.TotalRevenue, &report.TotalRefund, &report.TotalTransaction, &report.NetSales)
+	if err != nil {
+		log.Printf("Error getting transaction: %v", err)
+		return nil, err
 	}
-	details := models.ProductSold{
-		ProductName: mostSold,
-		ProductQty:  highSelling,
+	err = q.QueryRow(`SELECT COALESCE(SUM(d.cost), 0)
+		FROM transaction_details d INNER JOIN transactions t ON d.transaction_id = t.id
+		WHERE `+where, args...).Scan(&report.COGS)
+	if err != nil {
+		log.Printf("Error getting cost of goods sold: %v", err)
+		return nil, err
 	}
+	report.GrossProfit = report.NetSales - report.COGS
+	report.Margin = models.Margin(report.GrossProfit, report.NetSales)
 
-	if err := tx.Commit(); err != nil {
+	err = q.QueryRow(`SELECT p.name, SUM(d.quantity) AS qty
+		FROM transaction_details d
+		INNER JOIN transactions t ON d.transaction_id = t.id
+		INNER JOIN product p ON d.product_id = p.id
+		WHERE `+where+` GROUP BY p.id, p.name HAVING SUM(d.quantity) > 0 ORDER BY qty DESC LIMIT 1`, args...).Scan(&report.HighestSelling.ProductName, &report.HighestSelling.ProductQty)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error getting transaction details: %v", err)
 		return nil, err
 	}
-
-	return &models.Report{
-		TotalRevenue:     totalEarning,
-		TotalTransaction: len(transactions),
-		HighestSelling:   details,
-	}, err
+	return &report, nil
 }
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"gokasir-api/models"
+	"testing"
+)
+
+func TestUnits(t *testing.T) {
+	db := testDB(t)
+	repo := NewProductRepository(db)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{Price: 3500, Cost: 3000})
+	stockUp(t, db, product, outlet, models.Qty(100))
+
+	packPrice := 20000
+	err := repo.SetUnits(product, &models.SetUnitsRequest{Units: []models.ProductUnit{
+		{Name: "carton", Factor: models.Qty(48)},
+		{Name: "pack", Factor: models.Qty(6), Price: &packPrice},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, err := repo.FindUnits(product)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units.Units) != 2 || units.Units[0].Name != "pack" || units.BaseUnit != models.DefaultUnit {
+		t.Errorf("units %+v in base unit %s", units.Units, units.BaseUnit)
+	}
+	if err := repo.SetUnits(product, &models.SetUnitsRequest{Units: []models.ProductUnit{{Name: models.DefaultUnit, Factor: models.Qty(2)}}}); err == nil {
+		t.Error("added the base unit as a unit")
+	}
+
+	sale, err := sell(db, outlet,
+		models.CheckoutItem{ProductID: product, Quantity: models.Qty(2), Unit: "pack"},
+		models.CheckoutItem{ProductID: product, Quantity: models.Qty(1), Unit: "carton"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		unit        string
+		quantity    models.Quantity
+		unitPrice   int
+		grossAmount int
+		rule        string
+	}{
+		{"pack", models.Qty(12), 20000, 40000, models.PriceRuleUnit},
+		{"carton", models.Qty(48), 168000, 168000, models.PriceRuleStandard},
+	}
+	for i, tt := range tests {
+		d := sale.Details[i]
+		if d.Unit != tt.unit || d.Quantity != tt.quantity || d.UnitPrice != tt.unitPrice || d.GrossAmount != tt.grossAmount || d.PriceRule != tt.rule {
+			t.Errorf("%s line: %s base units at %d for %d by %s rule", tt.unit, d.Quantity, d.UnitPrice, d.GrossAmount, d.PriceRule)
+		}
+	}
+	if stock := stockOf(t, db, product, outlet); stock != models.Qty(40) {
+		t.Errorf("stock %s, want 40 pieces", stock)
+	}
+	if _, err := sell(db, outlet, models.CheckoutItem{ProductID: product, Quantity: models.Qty(1), Unit: "crate"}); err == nil {
+		t.Error("sold in a unit the product does not have")
+	}
+
+	// Cartons are bought at 150.000, 3.125 a piece.
+	po := newPurchaseOrder(t, db, outlet, models.PurchaseOrderItem{ProductID: product, Quantity: models.Qty(2), UnitCost: 150000, Unit: "carton"})
+	if po.Items[0].Factor != models.Qty(48) || po.TotalAmount != 300000 {
+		t.Errorf("ordered cartons of %s worth %d", po.Items[0].Factor, po.TotalAmount)
+	}
+	_, err = NewPurchaseOrderRepository(db).ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+		ReceivedBy: "test",
+		Items:      []models.ReceiveItem{{ItemID: po.Items[0].ID, Quantity: models.Qty(2)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stock := stockOf(t, db, product, outlet); stock != models.Qty(136) {
+		t.Errorf("stock %s after receiving 2 cartons, want 136 pieces", stock)
+	}
+	if cost := costOf(t, db, product); cost != 3088 {
+		t.Errorf("average cost %d, want 3088", cost)
+	}
+
+	// Counts in different units add up.
+	stockTakes := NewStockTakeRepository(db)
+	st := models.StockTake{OutletID: &outlet, CreatedBy: "test"}
+	if err := stockTakes.StartStockTake(&st, []int{product}); err != nil {
+		t.Fatal(err)
+	}
+	err = stockTakes.RecordCounts(st.ID, &models.StockCountRequest{Counter: "ani", Items: []models.StockCountItem{
+		{ProductID: product, Quantity: models.Qty(2), Unit: "carton"},
+		{ProductID: product, Quantity: models.Qty(40)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := stockTakes.FindStockTakeByID(st.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := got.Lines[0]; l.CountedQty == nil || *l.CountedQty != models.Qty(136) || l.VarianceQty != 0 {
+		t.Errorf("counted %v with variance %s, want 136 and none", l.CountedQty, l.VarianceQty)
+	}
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "gokasir-api/models"
+
+type WriteOffRepository interface {
+	FindAllWriteOff(status string, outletID *int) ([]models.WriteOff, error)
+	CreateWriteOff(req *models.WriteOffRequest, approvalLimit int) (int, error)
+	FindWriteOffByID(id int) (*models.WriteOff, error)
+	ApproveWriteOff(id int, approvedBy string) error
+	CancelWriteOff(id int) error
+	WriteOffReport(start, end string, outletID *int) (*models.WriteOffReport, error)
+}
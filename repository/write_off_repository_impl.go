@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+type WriteOffRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewWriteOffRepository(db *sql.DB) WriteOffRepository {
+	return &WriteOffRepositoryImpl{db: db}
+}
+
+const writeOffColumns = "id, outlet_id, reason, status, notes, total_value, created_by, created_at, approved_by, approved_at, posted_at"
+
+func writeOffFields(w *models.WriteOff) []any {
+	return []any{&w.ID, &w.OutletID, &w.Reason, &w.Status, &w.Notes, &w.TotalValue, &w.CreatedBy, &w.CreatedAt, &w.ApprovedBy, &w.ApprovedAt, &w.PostedAt}
+}
+
+func (r *WriteOffRepositoryImpl) FindAllWriteOff(status string, outletID *int) ([]models.WriteOff, error) {
+	rows, err := r.db.Query("SELECT "+writeOffColumns+` FROM write_offs
+		WHERE ($1 = '' OR status = $1) AND ($2::int IS NULL OR outlet_id = $2) ORDER BY id DESC`, status, outletID)
+	if err != nil {
+		log.Printf("Error getting all write off: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	writeOffs := make([]models.WriteOff, 0)
+	for rows.Next() {
+		var w models.WriteOff
+		if err := rows.Scan(writeOffFields(&w)...); err != nil {
+			return nil, err
+		}
+		writeOffs = append(writeOffs, w)
+	}
+	return writeOffs, rows.Err()
+}
+
+// CreateWriteOff records a write-off with its lines valued at what posting
+// them would cost, which for a FIFO product means its oldest layers. Up to
+// approvalLimit it is posted straight away; above it, it waits for a
+// manager's approval.
+func (r *WriteOffRepositoryImpl) CreateWriteOff(req *models.WriteOffRequest, approvalLimit int) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if req.OutletID, err = stockOutlet(tx, req.OutletID); err != nil {
+		return 0, err
+	}
+	var id int
+	err = tx.QueryRow("INSERT INTO write_offs(outlet_id, reason, status, notes, created_by) VALUES($1, $2, $3, $4, $5) RETURNING id",
+		req.OutletID, req.Reason, models.WriteOffPending, req.Notes, req.CreatedBy).Scan(&id)
+	if err != nil {
+		log.Printf("Error creating write off: %v", err)
+		return 0, err
+	}
+	total := 0
+	// What earlier lines write off of a product comes out of its layers first.
+	writtenOff := make(map[int]models.Quantity)
+	for _, item := range req.Items {
+		var name string
+		var bundle, serialized bool
+		var precision int
+		err := tx.QueryRow("SELECT name, is_bundle, serialized, precision FROM product WHERE id = $1", item.ProductID).Scan(&name, &bundle, &serialized, &precision)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return 0, fmt.Errorf("Product %d not found", item.ProductID)
+			}
+			return 0, err
+		}
+		if bundle {
+			return 0, fmt.Errorf("%s is a bundle, write off its components instead", name)
+		}
+		factor, _, err := productUnit(tx, item.ProductID, item.Unit)
+		if err != nil {
+			return 0, err
+		}
+		quantity := item.Quantity.Mul(factor)
+		if quantity.Decimals() > precision {
+			return 0, fmt.Errorf("%s cannot be written off in steps of %s", name, quantity)
+		}
+		if serialized && quantity != models.Qty(len(item.Serials)) {
+			return 0, fmt.Errorf("Name the %s serial numbers of %s being written off", quantity, name)
+		}
+		if !serialized && len(item.Serials) > 0 {
+			return 0, fmt.Errorf("%s has no serial numbers", name)
+		}
+		serials := item.Serials
+		if serials == nil {
+			serials = []string{}
+		}
+		before, err := estimateCost(tx, item.ProductID, writtenOff[item.ProductID])
+		if err != nil {
+			return 0, err
+		}
+		writtenOff[item.ProductID] += quantity
+		after, err := estimateCost(tx, item.ProductID, writtenOff[item.ProductID])
+		if err != nil {
+			return 0, err
+		}
+		value := after - before
+		total += value
+		_, err = tx.Exec("INSERT INTO write_off_items(write_off_id, product_id, quantity, batch_number, value, serials) VALUES($1, $2, $3, $4, $5, $6)",
+			id, item.ProductID, quantity, item.BatchNumber, value, pq.Array(serials))
+		if err != nil {
+			log.Printf("Error creating write off item: %v", err)
+			return 0, err
+		}
+	}
+	if total > approvalLimit {
+		if _, err := tx.Exec("UPDATE write_offs SET total_value = $1 WHERE id = $2", total, id); err != nil {
+			return 0, err
+		}
+	} else if err := postWriteOff(tx, id, req.OutletID, req.CreatedBy); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (r *WriteOffRepositoryImpl) FindWriteOffByID(id int) (*models.WriteOff, error) {
+	var w models.WriteOff
+	if err := r.db.QueryRow("SELECT "+writeOffColumns+" FROM write_offs WHERE id = $1", id).Scan(writeOffFields(&w)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("Write off not found")
+		}
+		log.Printf("Error getting single write off: %v", err)
+		return nil, err
+	}
+	items, err := writeOffItems(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	w.Items = items
+	return &w, nil
+}
+
+// writeOffItems loads the lines of a write-off with the lots they were
+// taken from.
+func writeOffItems(q queryer, writeOffID int) ([]models.WriteOffItem, error) {
+	rows, err := q.Query(`SELECT i.id, i.product_id, p.name, i.quantity, i.batch_number, i.value, i.serials
+		FROM write_off_items i INNER JOIN product p ON p.id = i.product_id
+		WHERE i.write_off_id = $1 ORDER BY i.id`, writeOffID)
+	if err != nil {
+		log.Printf("Error getting write off items: %v", err)
+		return nil, err
+	}
+	items := make([]models.WriteOffItem, 0)
+	byID := make(map[int]int)
+	for rows.Next() {
+		var item models.WriteOffItem
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.Quantity, &item.BatchNumber, &item.Value, pq.Array(&item.Serials)); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		byID[item.ID] = len(items)
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lots, err := q.Query(`SELECT wl.item_id, wl.lot_id, l.product_id, l.batch_number, COALESCE(to_char(l.expiry_date, 'YYYY-MM-DD'), ''), wl.quantity
+		FROM write_off_lots wl
+		INNER JOIN write_off_items i ON i.id = wl.item_id
+		INNER JOIN stock_lots l ON l.id = wl.lot_id
+		WHERE i.write_off_id = $1 ORDER BY wl.item_id, l.expiry_date NULLS LAST, wl.lot_id`, writeOffID)
+	if err != nil {
+		log.Printf("Error getting write off lots: %v", err)
+		return nil, err
+	}
+	defer lots.Close()
+	for lots.Next() {
+		var itemID int
+		var l models.DetailLot
+		if err := lots.Scan(&itemID, &l.LotID, &l.ProductID, &l.BatchNumber, &l.ExpiryDate, &l.Quantity); err != nil {
+			return nil, err
+		}
+		item := &items[byID[itemID]]
+		item.Lots = append(item.Lots, l)
+	}
+	return items, lots.Err()
+}
+
+// postWriteOff takes the lines of a write-off out of stock, expired lots
+// first, and values them at the cost they use up.
+func postWriteOff(tx *sql.Tx, id int, outletID *int, postedBy string) error {
+	items, err := writeOffItems(tx, id)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, item := range items {
+		lots, err := discardFromLots(tx, item.ProductID, outletID, item.BatchNumber, item.Quantity)
+		if err != nil {
+			return err
+		}
+		for _, l := range lots {
+			if _, err := tx.Exec("INSERT INTO write_off_lots (item_id, lot_id, quantity) VALUES ($1, $2, $3)", item.ID, l.LotID, l.Quantity); err != nil {
+				return err
+			}
+		}
+		if len(item.Serials) > 0 {
+			result, err := tx.Exec("UPDATE serial_numbers SET status = $1 WHERE serial = ANY($2) AND product_id = $3 AND outlet_id IS NOT DISTINCT FROM $4 AND status = $5",
+				models.SerialWrittenOff, pq.Array(item.Serials), item.ProductID, outletID, models.SerialInStock)
+			if err != nil {
+				return err
+			}
+			if n, _ := result.RowsAffected(); n != int64(len(item.Serials)) {
+				return fmt.Errorf("Not every serial of %s is in stock at the outlet", item.ProductName)
+			}
+		}
+		_, err = adjustStock(tx, &models.StockMovement{
+			ProductID:     item.ProductID,
+			OutletID:      outletID,
+			Delta:         -item.Quantity,
+			Reason:        models.StockWriteOff,
+			ReferenceType: models.RefWriteOff,
+			ReferenceID:   &id,
+			CreatedBy:     postedBy,
+		})
+		if err != nil {
+			return fmt.Errorf("Writing off %s: %v", item.ProductName, err)
+		}
+		value, err := consumeCost(tx, item.ProductID, item.Quantity)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE write_off_items SET value = $1 WHERE id = $2", value, item.ID); err != nil {
+			return err
+		}
+		total += value
+	}
+	_, err = tx.Exec("UPDATE write_offs SET status = $1, total_value = $2, posted_at = CURRENT_TIMESTAMP WHERE id = $3", models.WriteOffPosted, total, id)
+	return err
+}
+
+// lockWriteOff loads a write-off and locks it until the transaction ends.
+func lockWriteOff(tx *sql.Tx, id int) (*models.WriteOff, error) {
+	var w models.WriteOff
+	err := tx.QueryRow("SELECT "+writeOffColumns+" FROM write_offs WHERE id = $1 FOR UPDATE", id).Scan(writeOffFields(&w)...)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Write off not found")
+	}
+	return &w, err
+}
+
+// ApproveWriteOff posts a pending write-off. Whoever created it cannot
+// approve it.
+func (r *WriteOffRepositoryImpl) ApproveWriteOff(id int, approvedBy string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	w, err := lockWriteOff(tx, id)
+	if err != nil {
+		return err
+	}
+	if w.Status != models.WriteOffPending {
+		return errors.New("Only pending write offs can be approved")
+	}
+	if w.CreatedBy == approvedBy {
+		return errors.New("A write off must be approved by someone other than its creator")
+	}
+	if _, err := tx.Exec("UPDATE write_offs SET approved_by = $1, approved_at = CURRENT_TIMESTAMP WHERE id = $2", approvedBy, id); err != nil {
+		return err
+	}
+	if err := postWriteOff(tx, id, w.OutletID, approvedBy); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *WriteOffRepositoryImpl) CancelWriteOff(id int) error {
+	result, err := r.db.Exec("UPDATE write_offs SET status = $1 WHERE id = $2 AND status = $3", models.WriteOffCancelled, id, models.WriteOffPending)
+	if err != nil {
+		log.Printf("Error cancelling write off: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Only pending write offs can be cancelled")
+	}
+	return nil
+}
+
+// WriteOffReport sums the write-offs posted between two days per reason and
+// per product, optionally at one outlet.
+func (r *WriteOffRepositoryImpl) WriteOffReport(start, end string, outletID *int) (*models.WriteOffReport, error) {
+	report := models.WriteOffReport{
+		StartDate: start,
+		EndDate:   end,
+		Reasons:   make([]models.WriteOffByReason, 0),
+		Products:  make([]models.WriteOffProduct, 0),
+	}
+
+	rows, err := r.db.Query(`SELECT reason, COUNT(*), SUM(total_value) FROM write_offs
+		WHERE status = $1 AND posted_at >= $2::date AND posted_at < $3::date + 1 AND ($4::int IS NULL OR outlet_id = $4)
+		GROUP BY reason ORDER BY SUM(total_value) DESC, reason`, models.WriteOffPosted, start, end, outletID)
+	if err != nil {
+		log.Printf("Error getting write off report: %v", err)
+		return nil, err
+	}
+	for rows.Next() {
+		var w models.WriteOffByReason
+		if err := rows.Scan(&w.Reason, &w.WriteOffs, &w.Value); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.WriteOffs += w.WriteOffs
+		report.TotalValue += w.Value
+		report.Reasons = append(report.Reasons, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(`SELECT i.product_id, p.name, SUM(i.quantity), SUM(i.value)
+		FROM write_off_items i
+		INNER JOIN write_offs w ON w.id = i.write_off_id
+		INNER JOIN product p ON p.id = i.product_id
+		WHERE w.status = $1 AND w.posted_at >= $2::date AND w.posted_at < $3::date + 1 AND ($4::int IS NULL OR w.outlet_id = $4)
+		GROUP BY i.product_id, p.name ORDER BY SUM(i.value) DESC, i.product_id`, models.WriteOffPosted, start, end, outletID)
+	if err != nil {
+		log.Printf("Error getting write off report: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p models.WriteOffProduct
+		if err := rows.Scan(&p.ProductID, &p.ProductName, &p.Quantity, &p.Value); err != nil {
+			return nil, err
+		}
+		report.Products = append(report.Products, p)
+	}
+	return &report, rows.Err()
+}
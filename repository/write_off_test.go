@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"gokasir-api/models"
+	"testing"
+)
+
+func TestWriteOff(t *testing.T) {
+	db := testDB(t)
+	repo := NewWriteOffRepository(db)
+	outlet := newOutlet(t, db)
+	product := newProduct(t, db, models.Product{Cost: 2000})
+	stockUp(t, db, product, outlet, models.Qty(10))
+	writeOff := func(reason string, quantity models.Quantity) (int, error) {
+		return repo.CreateWriteOff(&models.WriteOffRequest{
+			OutletID:  &outlet,
+			Reason:    reason,
+			CreatedBy: "clerk",
+			Items:     []models.WriteOffItemRequest{{ProductID: product, Quantity: quantity}},
+		}, 5000)
+	}
+
+	if _, err := writeOff(models.WriteOffDamaged, 500); err == nil {
+		t.Error("wrote off part of a piece")
+	}
+	// Within the approval limit it is posted straight away.
+	damaged, err := writeOff(models.WriteOffDamaged, models.Qty(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, err := repo.FindWriteOffByID(damaged); err != nil || w.Status != models.WriteOffPosted || w.TotalValue != 4000 {
+		t.Errorf("write off within the limit: %+v, %v", w, err)
+	}
+	if stock := stockOf(t, db, product, outlet); stock != models.Qty(8) {
+		t.Errorf("stock %s, want 8", stock)
+	}
+
+	// Above it the stock stays until a manager approves.
+	expired, err := writeOff(models.WriteOffExpired, models.Qty(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, err := repo.FindWriteOffByID(expired); err != nil || w.Status != models.WriteOffPending || w.TotalValue != 10000 {
+		t.Errorf("write off above the limit: %+v, %v", w, err)
+	}
+	if stock := stockOf(t, db, product, outlet); stock != models.Qty(8) {
+		t.Errorf("stock %s before approval, want 8", stock)
+	}
+	if err := repo.ApproveWriteOff(expired, "clerk"); err == nil {
+		t.Error("approved by its own creator")
+	}
+	if err := repo.ApproveWriteOff(expired, "manager"); err != nil {
+		t.Fatal(err)
+	}
+	if stock := stockOf(t, db, product, outlet); stock != models.Qty(3) {
+		t.Errorf("stock %s after approval, want 3", stock)
+	}
+	if err := repo.CancelWriteOff(expired); err == nil {
+		t.Error("cancelled a posted write off")
+	}
+
+	sample, err := writeOff(models.WriteOffSample, models.Qty(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CancelWriteOff(sample); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ApproveWriteOff(sample, "manager"); err == nil {
+		t.Error("approved a cancelled write off")
+	}
+	if stock := stockOf(t, db, product, outlet); stock != models.Qty(3) {
+		t.Errorf("stock %s after the cancel, want 3", stock)
+	}
+
+	report, err := repo.WriteOffReport(inDays(-1), inDays(1), &outlet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.WriteOffs != 2 || report.TotalValue != 14000 {
+		t.Errorf("%d write offs worth %d, want 2 worth 14000", report.WriteOffs, report.TotalValue)
+	}
+	if len(report.Reasons) != 2 || report.Reasons[0].Reason != models.WriteOffExpired || report.Reasons[0].Value != 10000 {
+		t.Errorf("reasons %+v", report.Reasons)
+	}
+	if len(report.Products) != 1 || report.Products[0].Quantity != models.Qty(7) || report.Products[0].Value != 14000 {
+		t.Errorf("products %+v", report.Products)
+	}
+}
+
+func TestWriteOffLotsAndSerials(t *testing.T) {
+	db := testDB(t)
+	repo := NewWriteOffRepository(db)
+	outlet := newOutlet(t, db)
+	const limit = 1000000
+
+	product := newProduct(t, db, models.Product{})
+	po := newPurchaseOrder(t, db, outlet, models.PurchaseOrderItem{ProductID: product, Quantity: models.Qty(6), UnitCost: 1000})
+	for _, lot := range []struct {
+		batch, expiry string
+	}{{"OLD", inDays(-5)}, {"NEW", inDays(30)}} {
+		_, err := NewPurchaseOrderRepository(db).ReceivePurchaseOrder(po.ID, &models.ReceiveRequest{
+			ReceivedBy: "test",
+			Items:      []models.ReceiveItem{{ItemID: po.Items[0].ID, Quantity: models.Qty(3), BatchNumber: lot.batch, ExpiryDate: lot.expiry}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeOff := func(item models.WriteOffItemRequest) (int, error) {
+		return repo.CreateWriteOff(&models.WriteOffRequest{OutletID: &outlet, Reason: models.WriteOffExpired, CreatedBy: "test", Items: []models.WriteOffItemRequest{item}}, limit)
+	}
+	if _, err := writeOff(models.WriteOffItemRequest{ProductID: product, Quantity: models.Qty(4), BatchNumber: "NEW"}); err == nil {
+		t.Error("wrote off more of a batch than it holds")
+	}
+	// Without a batch the expired lot goes first.
+	id, err := writeOff(models.WriteOffItemRequest{ProductID: product, Quantity: models.Qty(4)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := repo.FindWriteOffByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lots := w.Items[0].Lots
+	if len(lots) != 2 || lots[0].BatchNumber != "OLD" || lots[0].Quantity != models.Qty(3) || lots[1].BatchNumber != "NEW" || lots[1].Quantity != models.Qty(1) {
+		t.Errorf("written off from %+v", lots)
+	}
+	if w.TotalValue != 4000 {
+		t.Errorf("written off worth %d, want 4000", w.TotalValue)
+	}
+
+	serialized := newProduct(t, db, models.Product{Serialized: true})
+	stockUp(t, db, serialized, outlet, models.Qty(1))
+	serial := unique("SN-")
+	if err := NewSerialRepository(db).RegisterSerials(&models.RegisterSerialsRequest{ProductID: serialized, OutletID: &outlet, Serials: []string{serial}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeOff(models.WriteOffItemRequest{ProductID: serialized, Quantity: models.Qty(1)}); err == nil {
+		t.Error("wrote off a serialised unit without its serial")
+	}
+	if _, err := writeOff(models.WriteOffItemRequest{ProductID: serialized, Quantity: models.Qty(1), Serials: []string{serial}}); err != nil {
+		t.Fatal(err)
+	}
+	if unit, err := NewSerialRepository(db).FindSerial(serial); err != nil || unit.Status != models.SerialWrittenOff {
+		t.Errorf("serial %s after its write off: %+v, %v", serial, unit, err)
+	}
+	if stock := stockOf(t, db, serialized, outlet); stock != 0 {
+		t.Errorf("stock %s, want 0", stock)
+	}
+}
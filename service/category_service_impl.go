@@ -22,8 +22,11 @@ func (s *CategoryServiceImpl) CreateCategory(req *models.CreateCategoryRequest)
 		return nil, err
 	}
 	category := &models.Category{
-		Name:        req.Name,
-		Description: req.Description,
+		Name:         req.Name,
+		Description:  req.Description,
+		TaxRate:      req.TaxRate,
+		TaxExempt:    req.TaxExempt,
+		TaxInclusive: req.TaxInclusive,
 	}
 	if err := s.repo.CreateCategory(category); err != nil {
 		return nil, err
@@ -40,8 +43,11 @@ func (s *CategoryServiceImpl) UpdateCategory(id int, req *models.UpdateCategoryR
 		return nil, err
 	}
 	category := &models.Category{
-		Name:        req.Name,
-		Description: req.Description,
+		Name:         req.Name,
+		Description:  req.Description,
+		TaxRate:      req.TaxRate,
+		TaxExempt:    req.TaxExempt,
+		TaxInclusive: req.TaxInclusive,
 	}
 	if err := s.repo.UpdateCategory(id, category); err != nil {
 		return nil, err
@@ -50,7 +56,10 @@ func (s *CategoryServiceImpl) UpdateCategory(id int, req *models.UpdateCategoryR
 }
 
 func (s *CategoryServiceImpl) PatchCategory(id int, req *models.PatchCategoryRequest) (*models.Category, error) {
-	return s.repo.PatchCategory(id, req.Name, req.Description)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.PatchCategory(id, req)
 }
 
 func (s *CategoryServiceImpl) DeleteCategory(id int) error {
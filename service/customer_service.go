@@ -0,0 +1,14 @@
+package service
+
+import "gokasir-api/models"
+
+type CustomerService interface {
+	GetAllCustomer(search string) ([]models.Customer, error)
+	CreateCustomer(req *models.CreateCustomerRequest) (*models.Customer, error)
+	GetCustomerByID(id int) (*models.Customer, error)
+	UpdateCustomer(id int, req *models.UpdateCustomerRequest) (*models.Customer, error)
+	PatchCustomer(id int, req *models.PatchCustomerRequest) (*models.Customer, error)
+	DeleteCustomer(id int) error
+	GetPurchaseHistory(id int) ([]models.Transaction, error)
+	GetPointEntries(id int) ([]models.PointEntry, error)
+}
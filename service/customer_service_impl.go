@@ -0,0 +1,79 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/pricing"
+	"gokasir-api/repository"
+)
+
+type CustomerServiceImpl struct {
+	repo    repository.CustomerRepository
+	loyalty pricing.LoyaltyConfig
+}
+
+func NewCustomerService(repo repository.CustomerRepository, loyalty pricing.LoyaltyConfig) CustomerService {
+	return &CustomerServiceImpl{repo: repo, loyalty: loyalty}
+}
+
+func (s *CustomerServiceImpl) GetAllCustomer(search string) ([]models.Customer, error) {
+	return s.repo.FindAllCustomer(search)
+}
+
+func (s *CustomerServiceImpl) CreateCustomer(req *models.CreateCustomerRequest) (*models.Customer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	customer := &models.Customer{
+		Name:  req.Name,
+		Phone: req.Phone,
+		Email: req.Email,
+		Group: req.Group,
+		Tier:  s.loyalty.TierFor(0).Name,
+	}
+	if err := s.repo.CreateCustomer(customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (s *CustomerServiceImpl) GetCustomerByID(id int) (*models.Customer, error) {
+	return s.repo.FindCustomerByID(id)
+}
+
+func (s *CustomerServiceImpl) UpdateCustomer(id int, req *models.UpdateCustomerRequest) (*models.Customer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	customer := &models.Customer{
+		Name:  req.Name,
+		Phone: req.Phone,
+		Email: req.Email,
+		Group: req.Group,
+	}
+	if err := s.repo.UpdateCustomer(id, customer); err != nil {
+		return nil, err
+	}
+	return s.repo.FindCustomerByID(id)
+}
+
+func (s *CustomerServiceImpl) PatchCustomer(id int, req *models.PatchCustomerRequest) (*models.Customer, error) {
+	return s.repo.PatchCustomer(id, req)
+}
+
+func (s *CustomerServiceImpl) DeleteCustomer(id int) error {
+	return s.repo.DeleteCustomer(id)
+}
+
+func (s *CustomerServiceImpl) GetPurchaseHistory(id int) ([]models.Transaction, error) {
+	if _, err := s.repo.FindCustomerByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindCustomerTransactions(id)
+}
+
+func (s *CustomerServiceImpl) GetPointEntries(id int) ([]models.PointEntry, error) {
+	if _, err := s.repo.FindCustomerByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPointEntries(id)
+}
@@ -0,0 +1,10 @@
+package service
+
+import "gokasir-api/models"
+
+type InventoryService interface {
+	GetLowStock(outletID *int) ([]models.LowStockItem, error)
+	CheckLowStock(tr *models.Transaction) error
+	GetIngredientUsage(start, end string, outletID *int) ([]models.IngredientUsage, error)
+	GetExpiringLots(days int, outletID *int) ([]models.ExpiringLot, error)
+}
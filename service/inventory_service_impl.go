@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type InventoryServiceImpl struct {
+	repo          repository.InventoryRepository
+	notifications NotificationService
+}
+
+func NewInventoryService(repo repository.InventoryRepository, notifications NotificationService) InventoryService {
+	return &InventoryServiceImpl{repo: repo, notifications: notifications}
+}
+
+func (s *InventoryServiceImpl) GetLowStock(outletID *int) ([]models.LowStockItem, error) {
+	return s.repo.FindLowStock(outletID, nil)
+}
+
+// CheckLowStock raises a low-stock alert for every product the sale pushed
+// to or below its minimum. Products that were already low before the sale
+// do not alert again.
+func (s *InventoryServiceImpl) CheckLowStock(tr *models.Transaction) error {
+	sold := make(map[int]models.Quantity)
+	productIDs := make([]int, 0, len(tr.Details))
+	for _, d := range tr.Details {
+		// A bundle line took the stock of its components.
+		taken := d.Components
+		for _, item := range d.Ingredients {
+			taken = append(taken, models.DetailComponent{ProductID: item.IngredientID, Quantity: item.Quantity})
+		}
+		if taken == nil {
+			taken = []models.DetailComponent{{ProductID: d.ProductID, Quantity: d.Quantity}}
+		}
+		for _, c := range taken {
+			if _, ok := sold[c.ProductID]; !ok {
+				productIDs = append(productIDs, c.ProductID)
+			}
+			sold[c.ProductID] += c.Quantity
+		}
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
+	items, err := s.repo.FindLowStock(tr.OutletID, productIDs)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Stock+sold[item.ProductID] <= item.MinStock {
+			continue
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		err = s.notifications.Publish(&models.Notification{
+			Type:    models.NotificationLowStock,
+			Title:   fmt.Sprintf("Low stock: %s", item.ProductName),
+			Message: fmt.Sprintf("%s has %s left (minimum %s), reorder %s", item.ProductName, item.Stock, item.MinStock, item.SuggestedOrder),
+			Data:    data,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *InventoryServiceImpl) GetIngredientUsage(start, end string, outletID *int) ([]models.IngredientUsage, error) {
+	return s.repo.IngredientUsage(start, end, outletID)
+}
+
+func (s *InventoryServiceImpl) GetExpiringLots(days int, outletID *int) ([]models.ExpiringLot, error) {
+	if days < 0 {
+		return nil, errors.New("days cannot be negative")
+	}
+	return s.repo.FindExpiringLots(days, outletID)
+}
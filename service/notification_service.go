@@ -0,0 +1,9 @@
+package service
+
+import "gokasir-api/models"
+
+type NotificationService interface {
+	Publish(n *models.Notification) error
+	GetAllNotification(unreadOnly bool) ([]models.Notification, error)
+	MarkRead(id int) error
+}
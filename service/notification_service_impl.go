@@ -0,0 +1,42 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/notify"
+	"gokasir-api/repository"
+	"log"
+)
+
+type NotificationServiceImpl struct {
+	repo    repository.NotificationRepository
+	webhook *notify.Webhook
+}
+
+// NewNotificationService stores notifications in the in-app feed and, when
+// webhook is not nil, also posts them to the webhook.
+func NewNotificationService(repo repository.NotificationRepository, webhook *notify.Webhook) NotificationService {
+	return &NotificationServiceImpl{repo: repo, webhook: webhook}
+}
+
+func (s *NotificationServiceImpl) Publish(n *models.Notification) error {
+	if err := s.repo.CreateNotification(n); err != nil {
+		return err
+	}
+	if s.webhook != nil {
+		// Delivery must not hold up the request that raised the alert.
+		go func(n models.Notification) {
+			if err := s.webhook.Send(n); err != nil {
+				log.Printf("Error sending notification %d to webhook: %v", n.ID, err)
+			}
+		}(*n)
+	}
+	return nil
+}
+
+func (s *NotificationServiceImpl) GetAllNotification(unreadOnly bool) ([]models.Notification, error) {
+	return s.repo.FindAllNotification(unreadOnly)
+}
+
+func (s *NotificationServiceImpl) MarkRead(id int) error {
+	return s.repo.MarkRead(id)
+}
@@ -0,0 +1,15 @@
+package service
+
+import "gokasir-api/models"
+
+type OutletService interface {
+	GetAllOutlet() ([]models.Outlet, error)
+	CreateOutlet(req *models.OutletRequest) (*models.Outlet, error)
+	GetOutletByID(id int) (*models.Outlet, error)
+	UpdateOutlet(id int, req *models.OutletRequest) (*models.Outlet, error)
+	DeleteOutlet(id int) error
+	GetOutletStock(id int) ([]models.OutletStock, error)
+	SetOutletStock(id, productID int, req *models.SetOutletStockRequest) error
+	SetOutletPrice(id, productID int, req *models.SetOutletPriceRequest) error
+	DeleteOutletPrice(id, productID int) error
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type OutletServiceImpl struct {
+	repo repository.OutletRepository
+}
+
+func NewOutletService(repo repository.OutletRepository) OutletService {
+	return &OutletServiceImpl{repo: repo}
+}
+
+func (s *OutletServiceImpl) GetAllOutlet() ([]models.Outlet, error) {
+	return s.repo.FindAllOutlet()
+}
+
+func (s *OutletServiceImpl) CreateOutlet(req *models.OutletRequest) (*models.Outlet, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	outlet := &models.Outlet{
+		Code:          req.Code,
+		Name:          req.Name,
+		Address:       req.Address,
+		Phone:         req.Phone,
+		ReceiptFooter: req.ReceiptFooter,
+		PaperWidth:    req.PaperWidth,
+	}
+	if err := s.repo.CreateOutlet(outlet); err != nil {
+		return nil, err
+	}
+	return outlet, nil
+}
+
+func (s *OutletServiceImpl) GetOutletByID(id int) (*models.Outlet, error) {
+	return s.repo.FindOutletByID(id)
+}
+
+func (s *OutletServiceImpl) UpdateOutlet(id int, req *models.OutletRequest) (*models.Outlet, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	outlet := &models.Outlet{
+		ID:            id,
+		Code:          req.Code,
+		Name:          req.Name,
+		Address:       req.Address,
+		Phone:         req.Phone,
+		ReceiptFooter: req.ReceiptFooter,
+		PaperWidth:    req.PaperWidth,
+	}
+	if err := s.repo.UpdateOutlet(id, outlet); err != nil {
+		return nil, err
+	}
+	return outlet, nil
+}
+
+func (s *OutletServiceImpl) DeleteOutlet(id int) error {
+	return s.repo.DeleteOutlet(id)
+}
+
+func (s *OutletServiceImpl) GetOutletStock(id int) ([]models.OutletStock, error) {
+	if _, err := s.repo.FindOutletByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindOutletStock(id)
+}
+
+func (s *OutletServiceImpl) SetOutletStock(id, productID int, req *models.SetOutletStockRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.repo.FindOutletByID(id); err != nil {
+		return err
+	}
+	return s.repo.SetOutletStock(id, productID, req)
+}
+
+func (s *OutletServiceImpl) SetOutletPrice(id, productID int, req *models.SetOutletPriceRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.repo.FindOutletByID(id); err != nil {
+		return err
+	}
+	return s.repo.SetOutletPrice(id, productID, req.Price)
+}
+
+func (s *OutletServiceImpl) DeleteOutletPrice(id, productID int) error {
+	return s.repo.DeleteOutletPrice(id, productID)
+}
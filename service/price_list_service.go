@@ -0,0 +1,11 @@
+package service
+
+import "gokasir-api/models"
+
+type PriceListService interface {
+	GetAllPriceList() ([]models.PriceList, error)
+	CreatePriceList(req *models.PriceListRequest) (*models.PriceList, error)
+	GetPriceListByID(id int) (*models.PriceList, error)
+	UpdatePriceList(id int, req *models.PriceListRequest) (*models.PriceList, error)
+	DeletePriceList(id int) error
+}
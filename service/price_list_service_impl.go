@@ -0,0 +1,56 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type PriceListServiceImpl struct {
+	repo repository.PriceListRepository
+}
+
+func NewPriceListService(repo repository.PriceListRepository) PriceListService {
+	return &PriceListServiceImpl{repo: repo}
+}
+
+func (s *PriceListServiceImpl) GetAllPriceList() ([]models.PriceList, error) {
+	return s.repo.FindAllPriceList()
+}
+
+func (s *PriceListServiceImpl) CreatePriceList(req *models.PriceListRequest) (*models.PriceList, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	priceList := &models.PriceList{
+		Name:           req.Name,
+		CustomerGroups: req.CustomerGroups,
+		Items:          req.Items,
+	}
+	if err := s.repo.CreatePriceList(priceList); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPriceListByID(priceList.ID)
+}
+
+func (s *PriceListServiceImpl) GetPriceListByID(id int) (*models.PriceList, error) {
+	return s.repo.FindPriceListByID(id)
+}
+
+func (s *PriceListServiceImpl) UpdatePriceList(id int, req *models.PriceListRequest) (*models.PriceList, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	priceList := &models.PriceList{
+		Name:           req.Name,
+		CustomerGroups: req.CustomerGroups,
+		Items:          req.Items,
+	}
+	if err := s.repo.UpdatePriceList(id, priceList); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPriceListByID(id)
+}
+
+func (s *PriceListServiceImpl) DeletePriceList(id int) error {
+	return s.repo.DeletePriceList(id)
+}
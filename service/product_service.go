@@ -3,10 +3,23 @@ package service
 import "gokasir-api/models"
 
 type ProductService interface {
-	GetAllProduct(name string) ([]models.Product, error)
+	GetAllProduct(name string, grouped bool) ([]models.Product, error)
 	CreateProduct(req *models.CreateProductRequest) (*models.Product, error)
 	GetProductByID(id int) (*models.Product, error)
+	GetProductByBarcode(code string) (*models.Product, error)
 	UpdateProduct(id int, req *models.UpdateProductRequest) (*models.Product, error)
 	PatchProduct(id int, req *models.PatchProductRequest) (*models.Product, error)
 	DeleteProduct(id int) error
+	GetStockHistory(id int, outletID *int) ([]models.StockMovement, error)
+	GetVariants(id int) ([]models.Product, error)
+	GenerateVariants(id int, req *models.GenerateVariantsRequest) ([]models.Product, error)
+	GetBundle(id int, outletID *int) (*models.Bundle, error)
+	SetBundleComponents(id int, req *models.SetBundleRequest) (*models.Bundle, error)
+	GetRecipe(id int) (*models.Recipe, error)
+	SetRecipe(id int, req *models.SetRecipeRequest) (*models.Recipe, error)
+	GetUnits(id int) (*models.ProductUnits, error)
+	SetUnits(id int, req *models.SetUnitsRequest) (*models.ProductUnits, error)
+	GetLots(id int, outletID *int) (*models.ProductLots, error)
+	GetPriceTiers(id int) (*models.ProductPriceTiers, error)
+	SetPriceTiers(id int, req *models.SetPriceTiersRequest) (*models.ProductPriceTiers, error)
 }
@@ -13,8 +13,8 @@ func NewProductService(repo repository.ProductRepository) ProductService {
 	return &ProductServiceImpl{repo: repo}
 }
 
-func (s *ProductServiceImpl) GetAllProduct(name string) ([]models.Product, error) {
-	return s.repo.FindAllProduct(name)
+func (s *ProductServiceImpl) GetAllProduct(name string, grouped bool) ([]models.Product, error) {
+	return s.repo.FindAllProduct(name, grouped)
 }
 
 func (s *ProductServiceImpl) CreateProduct(req *models.CreateProductRequest) (*models.Product, error) {
@@ -22,10 +22,25 @@ func (s *ProductServiceImpl) CreateProduct(req *models.CreateProductRequest) (*m
 		return nil, err
 	}
 	product := &models.Product{
-		Name:        req.Name,
-		Price:       req.Price,
-		Stock:       req.Stock,
-		Category_ID: req.Category_ID,
+		Name:           req.Name,
+		Price:          req.Price,
+		Stock:          req.Stock,
+		Category_ID:    req.Category_ID,
+		TaxRate:        req.TaxRate,
+		TaxExempt:      req.TaxExempt,
+		TaxInclusive:   req.TaxInclusive,
+		MinStock:       req.MinStock,
+		ReorderQty:     req.ReorderQty,
+		SKU:            req.SKU,
+		PLU:            req.PLU,
+		Barcodes:       req.Barcodes,
+		IsIngredient:   req.IsIngredient,
+		Unit:           req.Unit,
+		Precision:      req.Precision,
+		Cost:           req.Cost,
+		CostMethod:     req.CostMethod,
+		Serialized:     req.Serialized,
+		WarrantyMonths: req.WarrantyMonths,
 	}
 	if err := s.repo.CreateProduct(product); err != nil {
 		return nil, err
@@ -37,15 +52,44 @@ func (s *ProductServiceImpl) GetProductByID(id int) (*models.Product, error) {
 	return s.repo.FindProductByID(id)
 }
 
+func (s *ProductServiceImpl) GetProductByBarcode(code string) (*models.Product, error) {
+	return s.repo.FindProductByBarcode(code)
+}
+
 func (s *ProductServiceImpl) UpdateProduct(id int, req *models.UpdateProductRequest) (*models.Product, error) {
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
+	current, err := s.repo.FindProductByID(id)
+	if err != nil {
+		return nil, err
+	}
 	product := &models.Product{
-		Name:        req.Name,
-		Price:       req.Price,
-		Stock:       req.Stock,
-		Category_ID: req.Category_ID,
+		Name:           req.Name,
+		Price:          req.Price,
+		Stock:          req.Stock,
+		Category_ID:    req.Category_ID,
+		TaxRate:        req.TaxRate,
+		TaxExempt:      req.TaxExempt,
+		TaxInclusive:   req.TaxInclusive,
+		MinStock:       req.MinStock,
+		ReorderQty:     req.ReorderQty,
+		SKU:            req.SKU,
+		PLU:            req.PLU,
+		Barcodes:       req.Barcodes,
+		IsIngredient:   req.IsIngredient,
+		Unit:           req.Unit,
+		Precision:      req.Precision,
+		Cost:           current.Cost,
+		CostMethod:     current.CostMethod,
+		Serialized:     req.Serialized,
+		WarrantyMonths: req.WarrantyMonths,
+	}
+	if req.Cost != nil {
+		product.Cost = *req.Cost
+	}
+	if req.CostMethod != nil {
+		product.CostMethod = *req.CostMethod
 	}
 	if err := s.repo.UpdateProduct(id, product); err != nil {
 		return nil, err
@@ -54,9 +98,97 @@ func (s *ProductServiceImpl) UpdateProduct(id int, req *models.UpdateProductRequ
 }
 
 func (s *ProductServiceImpl) PatchProduct(id int, req *models.PatchProductRequest) (*models.Product, error) {
-	return s.repo.PatchProduct(id, req.Name, req.Price, req.Stock, req.Category_ID)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	current, err := s.repo.FindProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.ValidateFor(current); err != nil {
+		return nil, err
+	}
+	return s.repo.PatchProduct(id, req)
 }
 
 func (s *ProductServiceImpl) DeleteProduct(id int) error {
 	return s.repo.DeleteProduct(id)
 }
+
+func (s *ProductServiceImpl) GetStockHistory(id int, outletID *int) ([]models.StockMovement, error) {
+	return s.repo.FindStockHistory(id, outletID)
+}
+
+func (s *ProductServiceImpl) GetVariants(id int) ([]models.Product, error) {
+	if _, err := s.repo.FindProductByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindVariants(id)
+}
+
+func (s *ProductServiceImpl) GenerateVariants(id int, req *models.GenerateVariantsRequest) ([]models.Product, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.GenerateVariants(id, req)
+}
+
+func (s *ProductServiceImpl) GetBundle(id int, outletID *int) (*models.Bundle, error) {
+	return s.repo.FindBundle(id, outletID)
+}
+
+func (s *ProductServiceImpl) SetBundleComponents(id int, req *models.SetBundleRequest) (*models.Bundle, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetBundleComponents(id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.FindBundle(id, nil)
+}
+
+func (s *ProductServiceImpl) GetRecipe(id int) (*models.Recipe, error) {
+	return s.repo.FindRecipe(id)
+}
+
+func (s *ProductServiceImpl) SetRecipe(id int, req *models.SetRecipeRequest) (*models.Recipe, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetRecipe(id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.FindRecipe(id)
+}
+
+func (s *ProductServiceImpl) GetUnits(id int) (*models.ProductUnits, error) {
+	return s.repo.FindUnits(id)
+}
+
+func (s *ProductServiceImpl) SetUnits(id int, req *models.SetUnitsRequest) (*models.ProductUnits, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetUnits(id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.FindUnits(id)
+}
+
+func (s *ProductServiceImpl) GetPriceTiers(id int) (*models.ProductPriceTiers, error) {
+	return s.repo.FindPriceTiers(id)
+}
+
+func (s *ProductServiceImpl) SetPriceTiers(id int, req *models.SetPriceTiersRequest) (*models.ProductPriceTiers, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPriceTiers(id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPriceTiers(id)
+}
+
+func (s *ProductServiceImpl) GetLots(id int, outletID *int) (*models.ProductLots, error) {
+	return s.repo.FindLots(id, outletID)
+}
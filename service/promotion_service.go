@@ -0,0 +1,11 @@
+package service
+
+import "gokasir-api/models"
+
+type PromotionService interface {
+	GetAllPromotion() ([]models.Promotion, error)
+	CreatePromotion(req *models.PromotionRequest) (*models.Promotion, error)
+	GetPromotionByID(id int) (*models.Promotion, error)
+	UpdatePromotion(id int, req *models.PromotionRequest) (*models.Promotion, error)
+	DeletePromotion(id int) error
+}
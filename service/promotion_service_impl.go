@@ -0,0 +1,49 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type PromotionServiceImpl struct {
+	repo repository.PromotionRepository
+}
+
+func NewPromotionService(repo repository.PromotionRepository) PromotionService {
+	return &PromotionServiceImpl{repo: repo}
+}
+
+func (s *PromotionServiceImpl) GetAllPromotion() ([]models.Promotion, error) {
+	return s.repo.FindAllPromotion()
+}
+
+func (s *PromotionServiceImpl) CreatePromotion(req *models.PromotionRequest) (*models.Promotion, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	promotion := req.ToPromotion()
+	if err := s.repo.CreatePromotion(promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (s *PromotionServiceImpl) GetPromotionByID(id int) (*models.Promotion, error) {
+	return s.repo.FindPromotionByID(id)
+}
+
+func (s *PromotionServiceImpl) UpdatePromotion(id int, req *models.PromotionRequest) (*models.Promotion, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	promotion := req.ToPromotion()
+	if err := s.repo.UpdatePromotion(id, promotion); err != nil {
+		return nil, err
+	}
+	promotion.ID = id
+	return promotion, nil
+}
+
+func (s *PromotionServiceImpl) DeletePromotion(id int) error {
+	return s.repo.DeletePromotion(id)
+}
@@ -0,0 +1,14 @@
+package service
+
+import "gokasir-api/models"
+
+type PurchaseOrderService interface {
+	GetAllPurchaseOrder(status string, supplierID *int) ([]models.PurchaseOrder, error)
+	CreatePurchaseOrder(req *models.PurchaseOrderRequest) (*models.PurchaseOrder, error)
+	GetPurchaseOrderByID(id int) (*models.PurchaseOrder, error)
+	UpdatePurchaseOrder(id int, req *models.PurchaseOrderRequest) (*models.PurchaseOrder, error)
+	OrderPurchaseOrder(id int) (*models.PurchaseOrder, error)
+	CancelPurchaseOrder(id int) (*models.PurchaseOrder, error)
+	ReceivePurchaseOrder(id int, req *models.ReceiveRequest) (*models.GoodsReceipt, error)
+	PaySupplier(id int, req *models.SupplierPaymentRequest) (*models.SupplierPayment, error)
+}
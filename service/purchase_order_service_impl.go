@@ -0,0 +1,99 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type PurchaseOrderServiceImpl struct {
+	repo         repository.PurchaseOrderRepository
+	supplierRepo repository.SupplierRepository
+}
+
+func NewPurchaseOrderService(repo repository.PurchaseOrderRepository, supplierRepo repository.SupplierRepository) PurchaseOrderService {
+	return &PurchaseOrderServiceImpl{repo: repo, supplierRepo: supplierRepo}
+}
+
+func (s *PurchaseOrderServiceImpl) GetAllPurchaseOrder(status string, supplierID *int) ([]models.PurchaseOrder, error) {
+	return s.repo.FindAllPurchaseOrder(status, supplierID)
+}
+
+// toPurchaseOrder validates the request and checks the supplier exists.
+func (s *PurchaseOrderServiceImpl) toPurchaseOrder(req *models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.supplierRepo.FindSupplierByID(req.SupplierID); err != nil {
+		return nil, err
+	}
+	po := &models.PurchaseOrder{
+		SupplierID: req.SupplierID,
+		OutletID:   req.OutletID,
+		Notes:      req.Notes,
+		CreatedBy:  req.CreatedBy,
+		Items:      make([]models.PurchaseOrderItem, 0, len(req.Items)),
+	}
+	for _, item := range req.Items {
+		po.Items = append(po.Items, models.PurchaseOrderItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitCost:  item.UnitCost,
+			Unit:      item.Unit,
+		})
+	}
+	return po, nil
+}
+
+func (s *PurchaseOrderServiceImpl) CreatePurchaseOrder(req *models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	po, err := s.toPurchaseOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePurchaseOrder(po); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPurchaseOrderByID(po.ID)
+}
+
+func (s *PurchaseOrderServiceImpl) GetPurchaseOrderByID(id int) (*models.PurchaseOrder, error) {
+	return s.repo.FindPurchaseOrderByID(id)
+}
+
+func (s *PurchaseOrderServiceImpl) UpdatePurchaseOrder(id int, req *models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	po, err := s.toPurchaseOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdatePurchaseOrder(id, po); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPurchaseOrderByID(id)
+}
+
+func (s *PurchaseOrderServiceImpl) OrderPurchaseOrder(id int) (*models.PurchaseOrder, error) {
+	if err := s.repo.OrderPurchaseOrder(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPurchaseOrderByID(id)
+}
+
+func (s *PurchaseOrderServiceImpl) CancelPurchaseOrder(id int) (*models.PurchaseOrder, error) {
+	if err := s.repo.CancelPurchaseOrder(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPurchaseOrderByID(id)
+}
+
+func (s *PurchaseOrderServiceImpl) ReceivePurchaseOrder(id int, req *models.ReceiveRequest) (*models.GoodsReceipt, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.ReceivePurchaseOrder(id, req)
+}
+
+func (s *PurchaseOrderServiceImpl) PaySupplier(id int, req *models.SupplierPaymentRequest) (*models.SupplierPayment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.PaySupplier(id, req)
+}
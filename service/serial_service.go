@@ -0,0 +1,9 @@
+package service
+
+import "gokasir-api/models"
+
+type SerialService interface {
+	GetAllSerial(productID int, outletID *int, status string) ([]models.SerialNumber, error)
+	GetSerial(serial string) (*models.SerialNumber, error)
+	RegisterSerials(req *models.RegisterSerialsRequest) ([]models.SerialNumber, error)
+}
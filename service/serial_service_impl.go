@@ -0,0 +1,32 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type SerialServiceImpl struct {
+	repo repository.SerialRepository
+}
+
+func NewSerialService(repo repository.SerialRepository) SerialService {
+	return &SerialServiceImpl{repo: repo}
+}
+
+func (s *SerialServiceImpl) GetAllSerial(productID int, outletID *int, status string) ([]models.SerialNumber, error) {
+	return s.repo.FindAllSerial(productID, outletID, status)
+}
+
+func (s *SerialServiceImpl) GetSerial(serial string) (*models.SerialNumber, error) {
+	return s.repo.FindSerial(serial)
+}
+
+func (s *SerialServiceImpl) RegisterSerials(req *models.RegisterSerialsRequest) ([]models.SerialNumber, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RegisterSerials(req); err != nil {
+		return nil, err
+	}
+	return s.repo.FindAllSerial(req.ProductID, req.OutletID, models.SerialInStock)
+}
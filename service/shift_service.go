@@ -0,0 +1,11 @@
+package service
+
+import "gokasir-api/models"
+
+type ShiftService interface {
+	OpenShift(req *models.OpenShiftRequest) (*models.Shift, error)
+	GetCurrentShift(outletID *int) (*models.Shift, error)
+	GetShiftSummary(id int) (*models.ShiftSummary, error)
+	RecordCashMovement(shiftID int, req *models.CashMovementRequest) (*models.CashMovement, error)
+	CloseShift(id int, req *models.CloseShiftRequest) (*models.ShiftSummary, error)
+}
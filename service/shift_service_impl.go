@@ -0,0 +1,61 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type ShiftServiceImpl struct {
+	repo repository.ShiftRepository
+}
+
+func NewShiftService(repo repository.ShiftRepository) ShiftService {
+	return &ShiftServiceImpl{repo: repo}
+}
+
+func (s *ShiftServiceImpl) OpenShift(req *models.OpenShiftRequest) (*models.Shift, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	shift := &models.Shift{
+		OutletID:    req.OutletID,
+		Cashier:     req.Cashier,
+		OpeningCash: req.OpeningCash,
+	}
+	if err := s.repo.OpenShift(shift); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+func (s *ShiftServiceImpl) GetCurrentShift(outletID *int) (*models.Shift, error) {
+	return s.repo.FindCurrentShift(outletID)
+}
+
+func (s *ShiftServiceImpl) GetShiftSummary(id int) (*models.ShiftSummary, error) {
+	return s.repo.ShiftSummary(id)
+}
+
+func (s *ShiftServiceImpl) RecordCashMovement(shiftID int, req *models.CashMovementRequest) (*models.CashMovement, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	movement := &models.CashMovement{
+		ShiftID:   shiftID,
+		Type:      req.Type,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+		CreatedBy: req.CreatedBy,
+	}
+	if err := s.repo.CreateCashMovement(movement); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+func (s *ShiftServiceImpl) CloseShift(id int, req *models.CloseShiftRequest) (*models.ShiftSummary, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.CloseShift(id, req)
+}
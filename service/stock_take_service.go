@@ -0,0 +1,12 @@
+package service
+
+import "gokasir-api/models"
+
+type StockTakeService interface {
+	GetAllStockTake(status string) ([]models.StockTake, error)
+	StartStockTake(req *models.StartStockTakeRequest) (*models.StockTake, error)
+	GetStockTakeByID(id int) (*models.StockTake, error)
+	RecordCounts(id int, req *models.StockCountRequest) (*models.StockTake, error)
+	ApproveStockTake(id int, req *models.ApproveStockTakeRequest) (*models.StockTake, error)
+	CancelStockTake(id int) (*models.StockTake, error)
+}
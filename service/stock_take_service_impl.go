@@ -0,0 +1,64 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type StockTakeServiceImpl struct {
+	repo repository.StockTakeRepository
+}
+
+func NewStockTakeService(repo repository.StockTakeRepository) StockTakeService {
+	return &StockTakeServiceImpl{repo: repo}
+}
+
+func (s *StockTakeServiceImpl) GetAllStockTake(status string) ([]models.StockTake, error) {
+	return s.repo.FindAllStockTake(status)
+}
+
+func (s *StockTakeServiceImpl) StartStockTake(req *models.StartStockTakeRequest) (*models.StockTake, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	stockTake := &models.StockTake{
+		OutletID:  req.OutletID,
+		Notes:     req.Notes,
+		CreatedBy: req.CreatedBy,
+	}
+	if err := s.repo.StartStockTake(stockTake, req.ProductIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.FindStockTakeByID(stockTake.ID)
+}
+
+func (s *StockTakeServiceImpl) GetStockTakeByID(id int) (*models.StockTake, error) {
+	return s.repo.FindStockTakeByID(id)
+}
+
+func (s *StockTakeServiceImpl) RecordCounts(id int, req *models.StockCountRequest) (*models.StockTake, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RecordCounts(id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.FindStockTakeByID(id)
+}
+
+func (s *StockTakeServiceImpl) ApproveStockTake(id int, req *models.ApproveStockTakeRequest) (*models.StockTake, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ApproveStockTake(id, req.ApprovedBy); err != nil {
+		return nil, err
+	}
+	return s.repo.FindStockTakeByID(id)
+}
+
+func (s *StockTakeServiceImpl) CancelStockTake(id int) (*models.StockTake, error) {
+	if err := s.repo.CancelStockTake(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindStockTakeByID(id)
+}
@@ -0,0 +1,13 @@
+package service
+
+import "gokasir-api/models"
+
+type StockTransferService interface {
+	GetAllStockTransfer(status string, outletID *int) ([]models.Transfer, error)
+	CreateStockTransfer(req *models.TransferRequest) (*models.Transfer, error)
+	GetStockTransferByID(id int) (*models.Transfer, error)
+	DispatchStockTransfer(id int, req *models.DispatchTransferRequest) (*models.Transfer, error)
+	ReceiveStockTransfer(id int, req *models.ReceiveTransferRequest) (*models.Transfer, error)
+	CancelStockTransfer(id int) (*models.Transfer, error)
+	GetInTransit(outletID *int) ([]models.InTransitStock, error)
+}
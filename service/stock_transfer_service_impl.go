@@ -0,0 +1,83 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type StockTransferServiceImpl struct {
+	repo       repository.StockTransferRepository
+	outletRepo repository.OutletRepository
+}
+
+func NewStockTransferService(repo repository.StockTransferRepository, outletRepo repository.OutletRepository) StockTransferService {
+	return &StockTransferServiceImpl{repo: repo, outletRepo: outletRepo}
+}
+
+func (s *StockTransferServiceImpl) GetAllStockTransfer(status string, outletID *int) ([]models.Transfer, error) {
+	return s.repo.FindAllStockTransfer(status, outletID)
+}
+
+func (s *StockTransferServiceImpl) CreateStockTransfer(req *models.TransferRequest) (*models.Transfer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	for _, outletID := range []int{req.FromOutletID, req.ToOutletID} {
+		if _, err := s.outletRepo.FindOutletByID(outletID); err != nil {
+			return nil, err
+		}
+	}
+	t := &models.Transfer{
+		FromOutletID: req.FromOutletID,
+		ToOutletID:   req.ToOutletID,
+		Notes:        req.Notes,
+		CreatedBy:    req.CreatedBy,
+		Items:        make([]models.TransferItem, 0, len(req.Items)),
+	}
+	for _, item := range req.Items {
+		t.Items = append(t.Items, models.TransferItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Serials:   item.Serials,
+		})
+	}
+	if err := s.repo.CreateStockTransfer(t); err != nil {
+		return nil, err
+	}
+	return s.repo.FindStockTransferByID(t.ID)
+}
+
+func (s *StockTransferServiceImpl) GetStockTransferByID(id int) (*models.Transfer, error) {
+	return s.repo.FindStockTransferByID(id)
+}
+
+func (s *StockTransferServiceImpl) DispatchStockTransfer(id int, req *models.DispatchTransferRequest) (*models.Transfer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.DispatchStockTransfer(id, req.DispatchedBy); err != nil {
+		return nil, err
+	}
+	return s.repo.FindStockTransferByID(id)
+}
+
+func (s *StockTransferServiceImpl) ReceiveStockTransfer(id int, req *models.ReceiveTransferRequest) (*models.Transfer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReceiveStockTransfer(id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.FindStockTransferByID(id)
+}
+
+func (s *StockTransferServiceImpl) CancelStockTransfer(id int) (*models.Transfer, error) {
+	if err := s.repo.CancelStockTransfer(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindStockTransferByID(id)
+}
+
+func (s *StockTransferServiceImpl) GetInTransit(outletID *int) ([]models.InTransitStock, error) {
+	return s.repo.FindInTransit(outletID)
+}
@@ -0,0 +1,11 @@
+package service
+
+import "gokasir-api/models"
+
+type SupplierService interface {
+	GetAllSupplier(search string) ([]models.Supplier, error)
+	CreateSupplier(req *models.SupplierRequest) (*models.Supplier, error)
+	GetSupplierByID(id int) (*models.Supplier, error)
+	UpdateSupplier(id int, req *models.SupplierRequest) (*models.Supplier, error)
+	DeleteSupplier(id int) error
+}
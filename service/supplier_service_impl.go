@@ -0,0 +1,60 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+type SupplierServiceImpl struct {
+	repo repository.SupplierRepository
+}
+
+func NewSupplierService(repo repository.SupplierRepository) SupplierService {
+	return &SupplierServiceImpl{repo: repo}
+}
+
+func (s *SupplierServiceImpl) GetAllSupplier(search string) ([]models.Supplier, error) {
+	return s.repo.FindAllSupplier(search)
+}
+
+func (s *SupplierServiceImpl) CreateSupplier(req *models.SupplierRequest) (*models.Supplier, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	supplier := &models.Supplier{
+		Name:        req.Name,
+		ContactName: req.ContactName,
+		Phone:       req.Phone,
+		Email:       req.Email,
+		Address:     req.Address,
+	}
+	if err := s.repo.CreateSupplier(supplier); err != nil {
+		return nil, err
+	}
+	return supplier, nil
+}
+
+func (s *SupplierServiceImpl) GetSupplierByID(id int) (*models.Supplier, error) {
+	return s.repo.FindSupplierByID(id)
+}
+
+func (s *SupplierServiceImpl) UpdateSupplier(id int, req *models.SupplierRequest) (*models.Supplier, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	supplier := &models.Supplier{
+		Name:        req.Name,
+		ContactName: req.ContactName,
+		Phone:       req.Phone,
+		Email:       req.Email,
+		Address:     req.Address,
+	}
+	if err := s.repo.UpdateSupplier(id, supplier); err != nil {
+		return nil, err
+	}
+	return s.repo.FindSupplierByID(id)
+}
+
+func (s *SupplierServiceImpl) DeleteSupplier(id int) error {
+	return s.repo.DeleteSupplier(id)
+}
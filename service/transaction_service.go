@@ -3,8 +3,15 @@ package service
 import "gokasir-api/models"
 
 type TransactionService interface {
-	Checkout(item []models.CheckoutItem) (*models.Transaction, error)
-	GetAllTransaction() ([]models.TransactionDetail, error)
-	TodaysTransaction() (*models.Report, error)
-	RangeTransaction(start, end string) (*models.Report, error)
+	Checkout(req *models.CheckoutRequest) (*models.Transaction, error)
+	GetAllTransaction(outletID *int) ([]models.TransactionDetail, error)
+	GetTransactionByID(id int) (*models.Transaction, error)
+	GetReceipt(id int, format string) ([]byte, string, error)
+	VoidTransaction(id int, req *models.RefundRequest) (*models.Transaction, error)
+	RefundTransaction(id int, req *models.RefundRequest) (*models.Transaction, error)
+	TodaysTransaction(outletID *int) (*models.Report, error)
+	RangeTransaction(start, end string, outletID *int) (*models.Report, error)
+	PromotionReport(start, end string, outletID *int) ([]models.PromotionUsage, error)
+	TaxReport(start, end string, outletID *int) (*models.TaxReport, error)
+	ProductSalesReport(start, end, groupBy string, outletID *int) ([]models.ProductSales, error)
 }
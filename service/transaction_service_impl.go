@@ -1,30 +1,113 @@
 package service
 
 import (
+	"errors"
 	"gokasir-api/models"
+	"gokasir-api/receipt"
 	"gokasir-api/repository"
+	"log"
 )
 
 type TransactionServiceImpl struct {
-	repo repository.TransactionRepository
+	repo       repository.TransactionRepository
+	outletRepo repository.OutletRepository
+	inventory  InventoryService
+	receipt    receipt.Template
 }
 
-func NewTransactionService(repo repository.TransactionRepository) TransactionService {
-	return &TransactionServiceImpl{repo: repo}
+func NewTransactionService(repo repository.TransactionRepository, outletRepo repository.OutletRepository, inventory InventoryService, receiptTemplate receipt.Template) TransactionService {
+	return &TransactionServiceImpl{repo: repo, outletRepo: outletRepo, inventory: inventory, receipt: receiptTemplate}
 }
 
-func (s *TransactionServiceImpl) Checkout(item []models.CheckoutItem) (*models.Transaction, error) {
-	return s.repo.CreateTransaction(item)
+func (s *TransactionServiceImpl) Checkout(req *models.CheckoutRequest) (*models.Transaction, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	transaction, err := s.repo.CreateTransaction(req)
+	if err != nil {
+		return nil, err
+	}
+	// The sale is already committed, a failed alert is only logged.
+	if err := s.inventory.CheckLowStock(transaction); err != nil {
+		log.Printf("Error checking low stock for transaction %d: %v", transaction.ID, err)
+	}
+	return transaction, nil
 }
 
-func (s *TransactionServiceImpl) GetAllTransaction() ([]models.TransactionDetail, error) {
-	return s.repo.FindAllTransaction()
+func (s *TransactionServiceImpl) GetAllTransaction(outletID *int) ([]models.TransactionDetail, error) {
+	return s.repo.FindAllTransaction(outletID)
 }
 
-func (s *TransactionServiceImpl) TodaysTransaction() (*models.Report, error) {
-	return s.repo.TodaysTransaction()
+func (s *TransactionServiceImpl) GetTransactionByID(id int) (*models.Transaction, error) {
+	return s.repo.FindTransactionByID(id)
 }
 
-func (s *TransactionServiceImpl) RangeTransaction(start, end string) (*models.Report, error) {
-	return s.repo.RangeTransaction(start, end)
+func (s *TransactionServiceImpl) GetReceipt(id int, format string) ([]byte, string, error) {
+	transaction, err := s.repo.FindTransactionByID(id)
+	if err != nil {
+		return nil, "", err
+	}
+	template := s.receipt
+	if transaction.OutletID != nil {
+		outlet, err := s.outletRepo.FindOutletByID(*transaction.OutletID)
+		if err != nil {
+			return nil, "", err
+		}
+		// What the outlet leaves empty comes from the receipt settings.
+		if outlet.Name != "" {
+			template.StoreName = outlet.Name
+		}
+		if outlet.Address != "" {
+			template.Address = outlet.Address
+		}
+		if outlet.Phone != "" {
+			template.Phone = outlet.Phone
+		}
+		if outlet.ReceiptFooter != "" {
+			template.Footer = outlet.ReceiptFooter
+		}
+		if outlet.PaperWidth != 0 {
+			template.PaperWidth = outlet.PaperWidth
+		}
+	}
+	return receipt.Render(format, template, transaction)
+}
+
+func (s *TransactionServiceImpl) VoidTransaction(id int, req *models.RefundRequest) (*models.Transaction, error) {
+	if err := req.Validate(true); err != nil {
+		return nil, err
+	}
+	return s.repo.RefundTransaction(id, req, true)
+}
+
+func (s *TransactionServiceImpl) RefundTransaction(id int, req *models.RefundRequest) (*models.Transaction, error) {
+	if err := req.Validate(false); err != nil {
+		return nil, err
+	}
+	return s.repo.RefundTransaction(id, req, false)
+}
+
+func (s *TransactionServiceImpl) TodaysTransaction(outletID *int) (*models.Report, error) {
+	return s.repo.TodaysTransaction(outletID)
+}
+
+func (s *TransactionServiceImpl) RangeTransaction(start, end string, outletID *int) (*models.Report, error) {
+	return s.repo.RangeTransaction(start, end, outletID)
+}
+
+func (s *TransactionServiceImpl) PromotionReport(start, end string, outletID *int) ([]models.PromotionUsage, error) {
+	return s.repo.PromotionReport(start, end, outletID)
+}
+
+func (s *TransactionServiceImpl) TaxReport(start, end string, outletID *int) (*models.TaxReport, error) {
+	return s.repo.TaxReport(start, end, outletID)
+}
+
+func (s *TransactionServiceImpl) ProductSalesReport(start, end, groupBy string, outletID *int) ([]models.ProductSales, error) {
+	switch groupBy {
+	case models.SalesByProduct, models.SalesByParent, models.SalesByComponent, models.SalesByCategory:
+	default:
+		return nil, errors.New("group_by must be parent, component or category")
+	}
+	return s.repo.ProductSalesReport(start, end, groupBy, outletID)
 }
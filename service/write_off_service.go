@@ -0,0 +1,12 @@
+package service
+
+import "gokasir-api/models"
+
+type WriteOffService interface {
+	GetAllWriteOff(status string, outletID *int) ([]models.WriteOff, error)
+	CreateWriteOff(req *models.WriteOffRequest) (*models.WriteOff, error)
+	GetWriteOffByID(id int) (*models.WriteOff, error)
+	ApproveWriteOff(id int, req *models.ApproveWriteOffRequest) (*models.WriteOff, error)
+	CancelWriteOff(id int) (*models.WriteOff, error)
+	WriteOffReport(start, end string, outletID *int) (*models.WriteOffReport, error)
+}
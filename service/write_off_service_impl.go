@@ -0,0 +1,67 @@
+package service
+
+import (
+	"gokasir-api/models"
+	"gokasir-api/repository"
+)
+
+// DefaultWriteOffApprovalLimit is the value at cost up to which a write-off
+// is posted without a manager's approval.
+const DefaultWriteOffApprovalLimit = 100000
+
+type WriteOffServiceImpl struct {
+	repo       repository.WriteOffRepository
+	outletRepo repository.OutletRepository
+	// approvalLimit is the value at cost above which a write-off needs a
+	// manager's approval.
+	approvalLimit int
+}
+
+func NewWriteOffService(repo repository.WriteOffRepository, outletRepo repository.OutletRepository, approvalLimit int) WriteOffService {
+	return &WriteOffServiceImpl{repo: repo, outletRepo: outletRepo, approvalLimit: approvalLimit}
+}
+
+func (s *WriteOffServiceImpl) GetAllWriteOff(status string, outletID *int) ([]models.WriteOff, error) {
+	return s.repo.FindAllWriteOff(status, outletID)
+}
+
+func (s *WriteOffServiceImpl) CreateWriteOff(req *models.WriteOffRequest) (*models.WriteOff, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.OutletID != nil {
+		if _, err := s.outletRepo.FindOutletByID(*req.OutletID); err != nil {
+			return nil, err
+		}
+	}
+	id, err := s.repo.CreateWriteOff(req, s.approvalLimit)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindWriteOffByID(id)
+}
+
+func (s *WriteOffServiceImpl) GetWriteOffByID(id int) (*models.WriteOff, error) {
+	return s.repo.FindWriteOffByID(id)
+}
+
+func (s *WriteOffServiceImpl) ApproveWriteOff(id int, req *models.ApproveWriteOffRequest) (*models.WriteOff, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ApproveWriteOff(id, req.ApprovedBy); err != nil {
+		return nil, err
+	}
+	return s.repo.FindWriteOffByID(id)
+}
+
+func (s *WriteOffServiceImpl) CancelWriteOff(id int) (*models.WriteOff, error) {
+	if err := s.repo.CancelWriteOff(id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindWriteOffByID(id)
+}
+
+func (s *WriteOffServiceImpl) WriteOffReport(start, end string, outletID *int) (*models.WriteOffReport, error) {
+	return s.repo.WriteOffReport(start, end, outletID)
+}